@@ -0,0 +1,80 @@
+package main
+
+import (
+	"lana/flagship-store/logging"
+	"lana/flagship-store/tracing"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const requestIdHeader = "X-Request-ID"
+
+// validRequestId restricts the request ids propagated from the clients, so
+// they can not forge log lines or headers.
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// logRequests assigns every request an id, propagated from X-Request-ID
+// when the client sends one, serves it with a logger carrying the id and
+// the trace id in its context and writes its access log.
+func (app *App) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		start := time.Now()
+		requestId := request.Header.Get(requestIdHeader)
+		if !validRequestId.MatchString(requestId) {
+			requestId = uuid.NewString()
+		}
+		response.Header().Set(requestIdHeader, requestId)
+
+		logger := app.logger().With("request-id", requestId)
+		if traceId := tracing.TraceId(request.Context()); traceId != "" {
+			logger.Annotate("trace-id", traceId)
+		}
+		if id := mux.Vars(request)["id"]; id != "" {
+			if key, known := routeIdKey(routeTemplate(request)); known {
+				logger.Annotate(key, id)
+			}
+		}
+		ctx := logging.WithRequestId(logging.NewContext(request.Context(), logger), requestId)
+		recorder := newStatusRecorder(response)
+
+		next.ServeHTTP(recorder, request.WithContext(ctx))
+
+		logger.Info("Request served", logging.Fields{
+			"method":      request.Method,
+			"route":       routeTemplate(request),
+			"status":      recorder.status,
+			"duration-ms": float64(time.Since(start).Microseconds()) / 1000,
+		})
+	})
+}
+
+// routeIdKeys are the access log keys of the {id} of the routes, by the
+// collection the id belongs to.
+var routeIdKeys = map[string]string{
+	"checkouts":   "checkout-id",
+	"customers":   "customer-id",
+	"saved-lists": "saved-list-id",
+}
+
+func routeIdKey(pathTemplate string) (string, bool) {
+	segments := strings.Split(pathTemplate, "/")
+	for position := 1; position < len(segments); position++ {
+		if segments[position] == "{id}" {
+			key, known := routeIdKeys[segments[position-1]]
+			return key, known
+		}
+	}
+	return "", false
+}
+
+func (app *App) logger() *logging.Logger {
+	if app.Logger == nil {
+		return logging.Default
+	}
+	return app.Logger
+}
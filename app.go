@@ -1,9 +1,16 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
+	"lana/flagship-store/auth"
+	"lana/flagship-store/idempotency"
+	"lana/flagship-store/logging"
+	"lana/flagship-store/metrics"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/ratelimit"
 	"lana/flagship-store/services"
 	"lana/flagship-store/services/commands"
 	"lana/flagship-store/services/errors"
@@ -11,102 +18,363 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/graphql-go/graphql"
 )
 
 type App struct {
-	Router                        *mux.Router
-	CreateCheckoutService         services.CreateCheckout
-	AddProductToCheckoutService   services.AddProductToCheckout
-	RetrieveCheckoutAmountService services.RetrieveCheckoutAmount
-	DeleteCheckoutService         services.DeleteCheckout
-}
-
-func (app *App) Initialize(createCheckoutService services.CreateCheckout, addProductToCheckoutService services.AddProductToCheckout, deleteCheckoutService services.DeleteCheckout, retrieveCheckoutAmountService services.RetrieveCheckoutAmount) {
-	app.CreateCheckoutService = createCheckoutService
-	app.AddProductToCheckoutService = addProductToCheckoutService
-	app.RetrieveCheckoutAmountService = retrieveCheckoutAmountService
-	app.DeleteCheckoutService = deleteCheckoutService
+	Router                           *mux.Router
+	Deprecations                     map[string]Deprecation
+	HealthCheckers                   map[string]persistence.HealthChecker
+	Logger                           *logging.Logger
+	Authenticator                    *auth.Authenticator
+	Limiter                          ratelimit.Limiter
+	IdempotencyStore                 idempotency.Store
+	CreateCheckoutService            services.CreateCheckout
+	AddProductToCheckoutService      services.AddProductToCheckout
+	RetrieveCheckoutAmountService    services.RetrieveCheckoutAmount
+	DeleteCheckoutService            services.DeleteCheckout
+	RetrieveCheckoutsAmountService   services.RetrieveCheckoutsAmount
+	ListCheckoutsService             services.ListCheckouts
+	RetrieveCheckoutService          services.RetrieveCheckout
+	RemoveProductFromCheckoutService services.RemoveProductFromCheckout
+	ListProductsService              services.ListProducts
+	RetrieveProductService           services.RetrieveProduct
+	RegisterCustomerService          services.RegisterCustomer
+	RetrieveCustomerService          services.RetrieveCustomer
+	AttachCheckoutToCustomerService  services.AttachCheckoutToCustomer
+	MergeCheckoutsService            services.MergeCheckouts
+	CreateSavedListService           services.CreateSavedList
+	ListSavedListsService            services.ListSavedLists
+	CheckoutSavedListService         services.CheckoutSavedList
+	CreatePriceListService           services.CreatePriceList
+	ListPriceListsService            services.ListPriceLists
+	CompleteCheckoutService          services.CompleteCheckout
+	RedeemLoyaltyPointsService       services.RedeemLoyaltyPoints
+	RetrieveLoyaltyAccountService    services.RetrieveLoyaltyAccount
+	GraphQLSchema                    graphql.Schema
+	shuttingDown                     int32
+}
+
+// Initialize routes the requests to the services of the app, which are
+// set before.
+func (app *App) Initialize() {
+	app.initializeGraphQLSchema()
+	app.Deprecations = make(map[string]Deprecation)
 	app.Router = mux.NewRouter().StrictSlash(true)
+	app.Router.NotFoundHandler = instrument(app.traceRequests(app.logRequests(http.HandlerFunc(notFound))))
+	app.Router.MethodNotAllowedHandler = instrument(app.traceRequests(app.logRequests(http.HandlerFunc(methodNotAllowed))))
 	app.initializeRoutes()
 }
 
-func (app *App) Run(addr string) {
-	fmt.Println("My first Golang application")
-	log.Fatal(http.ListenAndServe(addr, app.Router))
+// Run serves the app until ctx is done. Then the app is reported as not
+// ready for the shutdown delay, so no new traffic is routed to it, before
+// it stops accepting connections and waits up to the shutdown timeout for
+// the in-flight requests.
+func (app *App) Run(ctx context.Context, config Config) error {
+	server := &http.Server{
+		Addr:           config.Addr,
+		Handler:        app.Router,
+		ReadTimeout:    config.ReadTimeout,
+		WriteTimeout:   config.WriteTimeout,
+		IdleTimeout:    config.IdleTimeout,
+		MaxHeaderBytes: config.MaxHeaderBytes,
+	}
+
+	serveErrors := make(chan error, 1)
+	go func() {
+		log.Println("Serving HTTP at", config.Addr)
+		if config.usesTLS() {
+			serveErrors <- server.ListenAndServeTLS(config.TLSCertFile, config.TLSKeyFile)
+			return
+		}
+		serveErrors <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErrors:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down HTTP server")
+	app.startShutdown()
+	time.Sleep(config.ShutdownDelay)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
 }
 
 func (app *App) initializeRoutes() {
-	app.Router.HandleFunc("/checkouts", app.createCheckout).Methods("POST")
-	app.Router.HandleFunc("/checkouts/{id}", app.addProductToCheckout).Methods("PATCH")
-	app.Router.HandleFunc("/checkouts/{id}", app.deleteCheckout).Methods("DELETE")
-	app.Router.HandleFunc("/checkouts/{id}/amount", app.retrieveCheckoutAmount).Methods("GET")
+	app.Router.Use(instrument, app.traceRequests, app.logRequests)
+	app.Router.Handle("/metrics", metrics.Handler()).Methods("GET")
+	app.Router.HandleFunc("/openapi.json", app.retrieveOpenAPI).Methods("GET")
+	app.Router.HandleFunc("/healthz", app.retrieveLiveness).Methods("GET")
+	app.Router.HandleFunc("/readyz", app.retrieveReadiness).Methods("GET")
+	app.Router.HandleFunc("/version", app.retrieveVersion).Methods("GET")
+	app.Router.Handle("/graphql", app.authenticate(app.limitRequests(http.HandlerFunc(app.executeGraphQL)))).Methods("POST")
+
+	v1 := app.Router.PathPrefix("/v1").Subrouter()
+	v1.Use(app.versionHeaders("v1"), app.authenticate, app.limitRequests)
+	app.initializeV1Routes(v1)
+
+	v2 := app.Router.PathPrefix("/v2").Subrouter()
+	v2.Use(app.versionHeaders("v2"), app.authenticate, app.limitRequests)
+	app.initializeV2Routes(v2)
+
+	unversioned := app.Router.NewRoute().Subrouter()
+	unversioned.Use(app.versionHeaders("v1"), app.authenticate, app.limitRequests)
+	app.initializeV1Routes(unversioned)
+}
+
+func (app *App) initializeV1Routes(router *mux.Router) {
+	router.HandleFunc("/checkouts", app.authorize(policy.CreateCheckout, app.idempotent(app.createCheckout))).Methods("POST")
+	router.HandleFunc("/checkouts/{id}", app.authorize(policy.AddProductToCheckout, app.idempotent(app.addProductToCheckout))).Methods("PATCH")
+	app.initializeCheckoutRoutes(router)
+	app.initializeCustomerRoutes(router)
+	app.initializeSavedListRoutes(router)
+	app.initializePriceListRoutes(router)
+	app.initializeLoyaltyRoutes(router)
+}
+
+func (app *App) initializeV2Routes(router *mux.Router) {
+	router.HandleFunc("/checkouts", app.authorize(policy.CreateCheckout, app.idempotent(app.createCheckoutV2))).Methods("POST")
+	router.HandleFunc("/checkouts/{id}", app.authorize(policy.AddProductToCheckout, app.idempotent(app.addProductToCheckoutV2))).Methods("PATCH")
+	app.initializeCheckoutRoutes(router)
+	app.initializeCustomerRoutes(router)
+	app.initializeSavedListRoutes(router)
+	app.initializePriceListRoutes(router)
+	app.initializeLoyaltyRoutes(router)
+}
+
+func (app *App) initializeCheckoutRoutes(router *mux.Router) {
+	router.HandleFunc("/checkouts", app.authorize(policy.ListCheckouts, app.listCheckouts)).Methods("GET")
+	router.HandleFunc("/checkouts/{id}", app.authorize(policy.RetrieveCheckout, app.retrieveCheckout)).Methods("GET")
+	router.HandleFunc("/checkouts/{id}", app.authorize(policy.DeleteCheckout, app.deleteCheckout)).Methods("DELETE")
+	router.HandleFunc("/checkouts/{id}/amount", app.authorize(policy.RetrieveCheckoutAmount, app.retrieveCheckoutAmount)).Methods("GET")
+	router.HandleFunc("/checkouts/amounts", app.authorize(policy.RetrieveCheckoutsAmount, app.retrieveCheckoutsAmount)).Methods("POST")
+	router.HandleFunc("/checkouts/{id}/merge", app.authorize(policy.MergeCheckouts, app.mergeCheckouts)).Methods("POST")
 }
 
 func (app *App) createCheckout(response http.ResponseWriter, request *http.Request) {
-	body, _ := ioutil.ReadAll(request.Body)
 	var productCommand commands.Product
-	json.Unmarshal(body, &productCommand)
+	err := decodeBody(response, request, &productCommand)
+	if err == nil {
+		err = productCommand.Validate()
+	}
+	if err != nil {
+		writeProblem(response, request, err)
+		return
+	}
 
-	checkout, err := app.CreateCheckoutService.Do(productCommand)
+	app.doCreateCheckout(response, request, productCommand.CreateCheckout())
+}
 
-	if _, ok := err.(*errors.ProductNotFoundError); ok {
-		response.WriteHeader(http.StatusNotFound)
-		productNotFound := responses.ProductNotFound{
-			Message: "Product " + productCommand.Code + " not found",
-		}
-		json.NewEncoder(response).Encode(productNotFound)
+func (app *App) createCheckoutV2(response http.ResponseWriter, request *http.Request) {
+	var createCommand commands.CreateCheckout
+	if err := decodeBody(response, request, &createCommand); err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	app.doCreateCheckout(response, request, createCommand, problemStatus{errors.CodeProductNotFound, http.StatusUnprocessableEntity})
+}
+
+func (app *App) doCreateCheckout(response http.ResponseWriter, request *http.Request, createCommand commands.CreateCheckout, statuses ...problemStatus) {
+	checkout, err := app.CreateCheckoutService.Do(request.Context(), createCommand)
+
+	if err != nil {
+		writeProblem(response, request, err, statuses...)
 		return
 	}
 
+	logging.Annotate(request.Context(), "checkout-id", checkout.Id)
 	response.WriteHeader(http.StatusCreated)
 	json.NewEncoder(response).Encode(checkout)
 }
 
+func (app *App) listCheckouts(response http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	listCommand := commands.ListCheckouts{
+		ProductCode: query.Get("product"),
+		Status:      query.Get("status"),
+		Sort:        query.Get("sort"),
+		Cursor:      query.Get("cursor"),
+	}
+
+	var err error
+	if limit := query.Get("limit"); limit != "" {
+		if listCommand.Limit, err = strconv.Atoi(limit); err != nil {
+			err = errors.NewInvalidParameterError("limit")
+		}
+	}
+	if createdFrom := query.Get("created-from"); err == nil && createdFrom != "" {
+		if listCommand.CreatedFrom, err = time.Parse(time.RFC3339, createdFrom); err != nil {
+			err = errors.NewInvalidParameterError("created-from")
+		}
+	}
+	if createdTo := query.Get("created-to"); err == nil && createdTo != "" {
+		if listCommand.CreatedTo, err = time.Parse(time.RFC3339, createdTo); err != nil {
+			err = errors.NewInvalidParameterError("created-to")
+		}
+	}
+
+	var checkouts []models.Checkout
+	var nextCursor string
+	if err == nil {
+		checkouts, nextCursor, err = app.ListCheckoutsService.Do(request.Context(), listCommand)
+	}
+
+	if err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	responseCheckouts := responses.CheckoutsPage{
+		Checkouts:  checkouts,
+		NextCursor: nextCursor,
+	}
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(responseCheckouts)
+}
+
 func (app *App) addProductToCheckout(response http.ResponseWriter, request *http.Request) {
-	body, _ := ioutil.ReadAll(request.Body)
 	var addProductCommand commands.AddProduct
-	json.Unmarshal(body, &addProductCommand)
+	err := decodeBody(response, request, &addProductCommand)
+	if err == nil {
+		err = addProductCommand.Validate()
+	}
+	if err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	app.doAddProductToCheckout(response, request, addProductCommand.Line())
+}
+
+func (app *App) addProductToCheckoutV2(response http.ResponseWriter, request *http.Request) {
+	var lineCommand commands.Line
+	if err := decodeBody(response, request, &lineCommand); err != nil {
+		writeProblem(response, request, err)
+		return
+	}
 
+	app.doAddProductToCheckout(response, request, lineCommand)
+}
+
+func (app *App) doAddProductToCheckout(response http.ResponseWriter, request *http.Request, lineCommand commands.Line) {
 	vars := mux.Vars(request)
 	id := vars["id"]
 
-	_, err := app.AddProductToCheckoutService.Do(addProductCommand, id)
+	_, err := app.AddProductToCheckoutService.Do(request.Context(), lineCommand, id)
 
-	if _, isThisError := err.(*errors.CheckoutNotFoundError); isThisError {
-		response.WriteHeader(http.StatusNotFound)
-		checkoutNotFound := responses.CheckoutNotFound{
-			Message: "Checkout " + id + " not found",
-		}
-		json.NewEncoder(response).Encode(checkoutNotFound)
+	if err != nil {
+		writeProblem(response, request, err, problemStatus{errors.CodeProductNotFound, http.StatusUnprocessableEntity})
+		return
+	}
+
+	if !prefersRepresentation(request) {
+		response.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	checkoutSummary, err := app.RetrieveCheckoutService.Do(request.Context(), id)
+	if err != nil {
+		writeProblem(response, request, err)
 		return
 	}
 
-	if _, isThisError := err.(*errors.ProductNotFoundError); isThisError {
-		response.WriteHeader(http.StatusUnprocessableEntity)
-		productNotFound := responses.ProductNotFound{
-			Message: "Product " + addProductCommand.Code + " not found",
+	response.Header().Set("Preference-Applied", "return=representation")
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(newCheckoutDetail(checkoutSummary))
+}
+
+func prefersRepresentation(request *http.Request) bool {
+	for _, header := range request.Header.Values("Prefer") {
+		for _, preference := range strings.Split(header, ",") {
+			if strings.TrimSpace(preference) == "return=representation" {
+				return true
+			}
 		}
-		json.NewEncoder(response).Encode(productNotFound)
+	}
+	return false
+}
+
+func (app *App) retrieveCheckout(response http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	id := vars["id"]
+
+	checkoutSummary, err := app.RetrieveCheckoutService.Do(request.Context(), id)
+
+	if err != nil {
+		writeProblem(response, request, err)
 		return
 	}
 
-	response.WriteHeader(http.StatusNoContent)
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(newCheckoutDetail(checkoutSummary))
 }
 
-func (app *App) retrieveCheckoutAmount(response http.ResponseWriter, request *http.Request) {
+func (app *App) mergeCheckouts(response http.ResponseWriter, request *http.Request) {
 	vars := mux.Vars(request)
 	id := vars["id"]
 
-	amount, err := app.RetrieveCheckoutAmountService.Do(id)
+	var mergeCommand commands.MergeCheckouts
+	if err := decodeBody(response, request, &mergeCommand); err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	checkoutSummary, err := app.MergeCheckoutsService.Do(request.Context(), mergeCommand, id)
+	if err != nil {
+		writeProblem(response, request, err, problemStatus{errors.CodeProductNotFound, http.StatusUnprocessableEntity})
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(newCheckoutDetail(checkoutSummary))
+}
 
-	if _, ok := err.(*errors.CheckoutNotFoundError); ok {
-		response.WriteHeader(http.StatusNotFound)
-		checkoutNotFound := responses.CheckoutNotFound{
-			Message: "Checkout " + id + " not found",
+func newCheckoutDetail(checkoutSummary models.CheckoutSummary) responses.CheckoutDetail {
+	checkoutDetail := responses.CheckoutDetail{
+		Id:         checkoutSummary.Checkout.Id,
+		Status:     checkoutSummary.Checkout.Status,
+		CustomerId: checkoutSummary.Checkout.CustomerId,
+		Lines:      []responses.CheckoutLine{},
+		Subtotal:   formatCheckoutAmount(checkoutSummary.Subtotal),
+		Discount:   formatCheckoutAmount(checkoutSummary.Subtotal - checkoutSummary.PointsDiscount - checkoutSummary.Amount),
+		Amount:     formatCheckoutAmount(checkoutSummary.Amount),
+		CreatedAt:  checkoutSummary.Checkout.CreatedAt,
+		UpdatedAt:  checkoutSummary.Checkout.UpdatedAt,
+	}
+	for _, checkoutLine := range checkoutSummary.Lines {
+		responseCheckoutLine := responses.CheckoutLine{
+			ProductCode: checkoutLine.Product.Code,
+			ProductName: checkoutLine.Product.Name,
+			UnitPrice:   formatCheckoutAmount(checkoutLine.Product.Price),
+			Quantity:    checkoutLine.Quantity,
+			Amount:      formatCheckoutAmount(checkoutLine.Amount),
 		}
-		json.NewEncoder(response).Encode(checkoutNotFound)
+		checkoutDetail.Lines = append(checkoutDetail.Lines, responseCheckoutLine)
+	}
+	if checkoutSummary.RedeemedPoints > 0 {
+		checkoutDetail.RedeemedPoints = checkoutSummary.RedeemedPoints
+		checkoutDetail.PointsDiscount = formatCheckoutAmount(checkoutSummary.PointsDiscount)
+	}
+	return checkoutDetail
+}
+
+func (app *App) retrieveCheckoutAmount(response http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	id := vars["id"]
+
+	amount, err := app.RetrieveCheckoutAmountService.Do(request.Context(), id)
+
+	if err != nil {
+		writeProblem(response, request, err)
 		return
 	}
 
@@ -117,6 +385,42 @@ func (app *App) retrieveCheckoutAmount(response http.ResponseWriter, request *ht
 	json.NewEncoder(response).Encode(responseCheckout)
 }
 
+func (app *App) retrieveCheckoutsAmount(response http.ResponseWriter, request *http.Request) {
+	var checkoutsCommand commands.Checkouts
+	if err := decodeBody(response, request, &checkoutsCommand); err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	checkoutAmounts, err := app.RetrieveCheckoutsAmountService.Do(request.Context(), checkoutsCommand)
+	if err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	responseCheckouts := responses.CheckoutsAmount{
+		Amounts: []responses.CheckoutAmount{},
+		Errors:  []responses.CheckoutError{},
+	}
+	for _, checkoutAmount := range checkoutAmounts {
+		if checkoutAmount.Err != nil {
+			checkoutError := responses.CheckoutError{
+				Id:      checkoutAmount.CheckoutId,
+				Problem: newProblem(request, checkoutAmount.Err),
+			}
+			responseCheckouts.Errors = append(responseCheckouts.Errors, checkoutError)
+			continue
+		}
+		responseCheckoutAmount := responses.CheckoutAmount{
+			Id:     checkoutAmount.CheckoutId,
+			Amount: formatCheckoutAmount(checkoutAmount.Amount),
+		}
+		responseCheckouts.Amounts = append(responseCheckouts.Amounts, responseCheckoutAmount)
+	}
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(responseCheckouts)
+}
+
 func formatCheckoutAmount(amount int) string {
 	amount_with_decimals := float64(amount) / 100
 	amount_with_fixed_decimals := strconv.FormatFloat(amount_with_decimals, 'f', 2, 64)
@@ -127,14 +431,10 @@ func (app *App) deleteCheckout(response http.ResponseWriter, request *http.Reque
 	vars := mux.Vars(request)
 	id := vars["id"]
 
-	_, err := app.DeleteCheckoutService.Do(id)
+	_, err := app.DeleteCheckoutService.Do(request.Context(), id)
 
-	if _, ok := err.(*errors.CheckoutNotFoundError); ok {
-		response.WriteHeader(http.StatusNotFound)
-		checkoutNotFound := responses.CheckoutNotFound{
-			Message: "Checkout " + id + " not found",
-		}
-		json.NewEncoder(response).Encode(checkoutNotFound)
+	if err != nil {
+		writeProblem(response, request, err)
 		return
 	}
 
@@ -2,42 +2,82 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"lana/flagship-store/auth"
+	"lana/flagship-store/idempotency"
+	"lana/flagship-store/logging"
 	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/ratelimit"
 	"lana/flagship-store/services"
 	"lana/flagship-store/services/responses"
 	"lana/flagship-store/utils/mocks"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 )
 
 var app App
 
+const (
+	testAPIKey             = "a-test-api-key"
+	testMerchandiserAPIKey = "a-test-merchandiser-api-key"
+	testAdminAPIKey        = "a-test-admin-api-key"
+	testJWTSecret          = "a-test-jwt-secret"
+)
+
 func TestMain(m *testing.M) {
+	app = App{Logger: logging.New(ioutil.Discard), Authenticator: auth.NewAuthenticator(map[string]auth.APIKey{
+		"tests":      {Key: testAPIKey, Role: auth.RoleShopper},
+		"tests-crm":  {Key: testMerchandiserAPIKey, Role: auth.RoleMerchandiser},
+		"tests-root": {Key: testAdminAPIKey, Role: auth.RoleAdmin},
+	}, []byte(testJWTSecret), "")}
 	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
 	theProductRepositoryMock := mocks.ProductRepositoryMock{}
 	theProductWithDiscountRepositoryMock := mocks.ProductWithDiscountRepositoryMock{}
 	theProductWithPromotionRepositoryMock := mocks.ProductWithPromotionRepositoryMock{}
-	createCheckoutService := services.NewCreateCheckout(&theCheckoutRepositoryMock, &theProductRepositoryMock)
-	addProductToCheckoutService := services.NewAddProductToCheckout(&theCheckoutRepositoryMock, &theProductRepositoryMock)
-	retrieveCheckoutAmountService := services.NewRetrieveCheckoutAmount(&theCheckoutRepositoryMock, &theProductRepositoryMock, &theProductWithDiscountRepositoryMock, &theProductWithPromotionRepositoryMock)
-	deleteCheckoutService := services.NewDeleteCheckout(&theCheckoutRepositoryMock)
-
-	app = App{}
-	app.Initialize(createCheckoutService, addProductToCheckoutService, deleteCheckoutService, retrieveCheckoutAmountService)
+	app.CreateCheckoutService = services.NewCreateCheckout(&theCheckoutRepositoryMock, &theProductRepositoryMock)
+	app.AddProductToCheckoutService = services.NewAddProductToCheckout(&theCheckoutRepositoryMock, &theProductRepositoryMock)
+	app.RetrieveCheckoutAmountService = services.NewRetrieveCheckoutAmount(&theCheckoutRepositoryMock, &theProductRepositoryMock, &theProductWithDiscountRepositoryMock, &theProductWithPromotionRepositoryMock)
+	app.DeleteCheckoutService = services.NewDeleteCheckout(&theCheckoutRepositoryMock)
+	app.RetrieveCheckoutsAmountService = services.NewRetrieveCheckoutsAmount(&theCheckoutRepositoryMock, &theProductRepositoryMock, &theProductWithPromotionRepositoryMock, &theProductWithDiscountRepositoryMock)
+	app.ListCheckoutsService = services.NewListCheckouts(&theCheckoutRepositoryMock)
+	app.RetrieveCheckoutService = services.NewRetrieveCheckout(&theCheckoutRepositoryMock, &theProductRepositoryMock, &theProductWithPromotionRepositoryMock, &theProductWithDiscountRepositoryMock)
+	app.RemoveProductFromCheckoutService = services.NewRemoveProductFromCheckout(&theCheckoutRepositoryMock)
+	app.ListProductsService = services.NewListProducts(&theProductRepositoryMock)
+	app.RetrieveProductService = services.NewRetrieveProduct(&theProductRepositoryMock)
+	app.Initialize()
 
 	code := m.Run()
 
 	os.Exit(code)
 }
 
+// executeRequest serves the request authenticated with the test API key,
+// unless it sends its own credentials.
 func executeRequest(req *http.Request) *httptest.ResponseRecorder {
+	if req.Header.Get("X-API-Key") == "" && req.Header.Get("Authorization") == "" {
+		req.Header.Set("X-API-Key", testAPIKey)
+	}
 	rr := httptest.NewRecorder()
 	app.Router.ServeHTTP(rr, req)
 
@@ -51,7 +91,7 @@ func ACheckout() models.Checkout {
 	}
 }
 
-func ProductRepositoryMockWithAllProducts() mocks.ProductRepositoryMock {
+func ProductRepositoryMockWithAllProducts() *mocks.ProductRepositoryMock {
 	pen := models.Product{
 		Code:  "PEN",
 		Name:  "Lana Pen",
@@ -72,7 +112,7 @@ func ProductRepositoryMockWithAllProducts() mocks.ProductRepositoryMock {
 	theProductRepositoryMock.On("SearchById", mug.Code).Return(mug, true)
 	theProductRepositoryMock.On("SearchById", tshirt.Code).Return(tshirt, true)
 
-	return theProductRepositoryMock
+	return &theProductRepositoryMock
 }
 
 func TestReturn200WhenCreateCheckout(t *testing.T) {
@@ -84,6 +124,7 @@ func TestReturn200WhenCreateCheckout(t *testing.T) {
 	payload := []byte(`{"product-code":"PEN"}`)
 
 	req, _ := http.NewRequest("POST", "/checkouts", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
 	response := executeRequest(req)
 
 	var createdCheckout models.Checkout
@@ -104,33 +145,191 @@ func TestReturn404WhenCreateCheckoutWithNotValidProduct(t *testing.T) {
 	payload := []byte(`{"product-code":"FAKE"}`)
 
 	req, _ := http.NewRequest("POST", "/checkouts", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
 	response := executeRequest(req)
 
-	var productNotFound responses.ProductNotFound
+	var productNotFound responses.Problem
 	json.Unmarshal(response.Body.Bytes(), &productNotFound)
 	assert.EqualValues(t, 404, response.Code)
-	assert.EqualValues(t, "Product FAKE not found", productNotFound.Message)
+	assert.EqualValues(t, "application/problem+json", response.Header().Get("Content-Type"))
+	assert.EqualValues(t, "Product FAKE not found", productNotFound.Detail)
+	assert.EqualValues(t, "product-not-found", productNotFound.Code)
+	assert.EqualValues(t, 404, productNotFound.Status)
+	assert.EqualValues(t, "/checkouts", productNotFound.Instance)
 	theCheckoutRepositoryMock.AssertExpectations(t)
 	theProductRepositoryMock.AssertExpectations(t)
 }
 
+func TestReturn201WhenCreateCheckoutWithLines(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	theProductRepositoryMock := ProductRepositoryMockWithAllProducts()
+	app.CreateCheckoutService = services.NewCreateCheckout(&theCheckoutRepositoryMock, theProductRepositoryMock)
+	payload := []byte(`{"lines":[{"product-code":"PEN","quantity":2},{"product-code":"MUG","quantity":1}]}`)
+
+	req, _ := http.NewRequest("POST", "/v2/checkouts", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var createdCheckout models.Checkout
+	json.Unmarshal(response.Body.Bytes(), &createdCheckout)
+	assert.EqualValues(t, 201, response.Code)
+	assert.EqualValues(t, []string{"PEN", "PEN", "MUG"}, createdCheckout.Products)
+	theCheckoutRepositoryMock.AssertExpectations(t)
+}
+
+func TestReturn201WhenCreateEmptyCheckout(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	app.CreateCheckoutService = services.NewCreateCheckout(&theCheckoutRepositoryMock, &mocks.ProductRepositoryMock{})
+	payload := []byte(`{}`)
+
+	req, _ := http.NewRequest("POST", "/v2/checkouts", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var createdCheckout models.Checkout
+	json.Unmarshal(response.Body.Bytes(), &createdCheckout)
+	assert.EqualValues(t, 201, response.Code)
+	assert.EqualValues(t, 0, len(createdCheckout.Products))
+}
+
+func TestReturn422WhenCreateCheckoutWithLinesOfNotValidProduct(t *testing.T) {
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "FAKE").Return(models.Product{}, false)
+	app.CreateCheckoutService = services.NewCreateCheckout(&mocks.CheckoutRepositoryMock{}, &theProductRepositoryMock)
+	payload := []byte(`{"lines":[{"product-code":"FAKE","quantity":1}]}`)
+
+	req, _ := http.NewRequest("POST", "/v2/checkouts", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	assert.EqualValues(t, 422, response.Code)
+}
+
+func TestReturn200WhenListCheckouts(t *testing.T) {
+	checkout := ACheckout()
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Search", mock.AnythingOfType("persistence.CheckoutCriteria")).Return([]models.Checkout{checkout})
+	app.ListCheckoutsService = services.NewListCheckouts(&theCheckoutRepositoryMock)
+
+	req, _ := http.NewRequest("GET", "/checkouts?product=MUG&status=open&created-from=2021-03-01T00:00:00Z&sort=-created-at&limit=10", nil)
+	response := executeRequest(req)
+
+	var checkoutsPage responses.CheckoutsPage
+	json.Unmarshal(response.Body.Bytes(), &checkoutsPage)
+	assert.EqualValues(t, 200, response.Code)
+	assert.EqualValues(t, 1, len(checkoutsPage.Checkouts))
+	assert.EqualValues(t, checkout.Id, checkoutsPage.Checkouts[0].Id)
+	assert.EqualValues(t, "", checkoutsPage.NextCursor)
+	criteria := theCheckoutRepositoryMock.Calls[0].Arguments.Get(0).(persistence.CheckoutCriteria)
+	assert.EqualValues(t, "MUG", criteria.ProductCode)
+	assert.EqualValues(t, "open", criteria.Status)
+	assert.EqualValues(t, 2021, criteria.CreatedFrom.Year())
+	assert.EqualValues(t, true, criteria.Descending)
+	assert.EqualValues(t, 11, criteria.Limit)
+}
+
+func TestReturn400WhenListCheckoutsWithNotValidParameter(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	app.ListCheckoutsService = services.NewListCheckouts(&theCheckoutRepositoryMock)
+
+	req, _ := http.NewRequest("GET", "/checkouts?created-to=yesterday", nil)
+	response := executeRequest(req)
+
+	var invalidParameter responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &invalidParameter)
+	assert.EqualValues(t, 400, response.Code)
+	assert.EqualValues(t, "Invalid parameter created-to", invalidParameter.Detail)
+	theCheckoutRepositoryMock.AssertExpectations(t)
+}
+
 func TestReturn204AddingProductToCheckoutWhenCheckoutExists(t *testing.T) {
 	checkout := ACheckout()
 	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
 	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
-	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), mock.AnythingOfType("string")).Return(true)
 	theProductRepositoryMock := mocks.ProductRepositoryMock{}
 	theProductRepositoryMock.On("SearchById", "PEN").Return(models.Product{}, true)
 	app.AddProductToCheckoutService = services.NewAddProductToCheckout(&theCheckoutRepositoryMock, &theProductRepositoryMock)
 	payload := []byte(`{"product":"PEN"}`)
 
 	req, _ := http.NewRequest("PATCH", "/checkouts/"+checkout.Id, bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
 	response := executeRequest(req)
 	assert.EqualValues(t, 204, response.Code)
 	theCheckoutRepositoryMock.AssertExpectations(t)
 	theProductRepositoryMock.AssertExpectations(t)
 }
 
+func TestReturn200WithCheckoutAddingProductToCheckoutWhenRepresentationIsPreferred(t *testing.T) {
+	checkout := ACheckout()
+	modifiedCheckout := checkout
+	modifiedCheckout.Products = []string{"MUG", "PEN"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true).Once()
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), mock.AnythingOfType("string")).Return(true)
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(modifiedCheckout, true).Once()
+	theProductRepositoryMock := ProductRepositoryMockWithAllProducts()
+	theProductWithDiscountRepositoryMock := mocks.ProductWithDiscountRepositoryMock{}
+	theProductWithDiscountRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	theProductWithPromotionRepositoryMock := mocks.ProductWithPromotionRepositoryMock{}
+	theProductWithPromotionRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	app.AddProductToCheckoutService = services.NewAddProductToCheckout(&theCheckoutRepositoryMock, theProductRepositoryMock)
+	app.RetrieveCheckoutService = services.NewRetrieveCheckout(
+		&theCheckoutRepositoryMock,
+		theProductRepositoryMock,
+		&theProductWithPromotionRepositoryMock,
+		&theProductWithDiscountRepositoryMock)
+	payload := []byte(`{"product":"PEN"}`)
+
+	req, _ := http.NewRequest("PATCH", "/checkouts/"+checkout.Id, bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Prefer", "return=representation")
+	response := executeRequest(req)
+
+	var checkoutDetail responses.CheckoutDetail
+	json.Unmarshal(response.Body.Bytes(), &checkoutDetail)
+	assert.EqualValues(t, 200, response.Code)
+	assert.EqualValues(t, "return=representation", response.Header().Get("Preference-Applied"))
+	assert.EqualValues(t, checkout.Id, checkoutDetail.Id)
+	assert.EqualValues(t, 2, len(checkoutDetail.Lines))
+	assert.EqualValues(t, "12.50€", checkoutDetail.Amount)
+	theCheckoutRepositoryMock.AssertExpectations(t)
+}
+
+func TestReturn204AddingProductWithQuantityToCheckout(t *testing.T) {
+	checkout := ACheckout()
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), mock.AnythingOfType("string")).Return(true)
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "PEN").Return(models.Product{}, true)
+	app.AddProductToCheckoutService = services.NewAddProductToCheckout(&theCheckoutRepositoryMock, &theProductRepositoryMock)
+	payload := []byte(`{"product-code":"PEN","quantity":2}`)
+
+	req, _ := http.NewRequest("PATCH", "/v2/checkouts/"+checkout.Id, bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	assert.EqualValues(t, 204, response.Code)
+	persistedCheckout := theCheckoutRepositoryMock.Calls[1].Arguments.Get(0).(models.Checkout)
+	assert.EqualValues(t, []string{"MUG", "PEN", "PEN"}, persistedCheckout.Products)
+}
+
+func TestReturn400AddingProductWithoutQuantityToCheckout(t *testing.T) {
+	payload := []byte(`{"product-code":"PEN"}`)
+
+	req, _ := http.NewRequest("PATCH", "/v2/checkouts/an_id", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 400, response.Code)
+	assert.EqualValues(t, map[string]interface{}{"quantity": "must be between 1 and 99"}, problem.Details["fields"])
+}
+
 func TestReturn404AddingProductToCheckoutWhenCheckoutDoesNotExists(t *testing.T) {
 	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
 	theCheckoutRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Checkout{}, false)
@@ -139,12 +338,13 @@ func TestReturn404AddingProductToCheckoutWhenCheckoutDoesNotExists(t *testing.T)
 	payload := []byte(`{"product":"PEN"}`)
 
 	req, _ := http.NewRequest("PATCH", "/checkouts/a_fake_checkout", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
 	response := executeRequest(req)
 
-	var checkoutNotFound responses.CheckoutNotFound
+	var checkoutNotFound responses.Problem
 	json.Unmarshal(response.Body.Bytes(), &checkoutNotFound)
 	assert.EqualValues(t, 404, response.Code)
-	assert.EqualValues(t, "Checkout a_fake_checkout not found", checkoutNotFound.Message)
+	assert.EqualValues(t, "Checkout a_fake_checkout not found", checkoutNotFound.Detail)
 	theCheckoutRepositoryMock.AssertExpectations(t)
 	theProductRepositoryMock.AssertExpectations(t)
 }
@@ -159,16 +359,74 @@ func TestReturn422AddingProductToCheckoutWhenProductDoesNotExists(t *testing.T)
 	payload := []byte(`{"product":"FAKE"}`)
 
 	req, _ := http.NewRequest("PATCH", "/checkouts/"+checkout.Id, bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
 	response := executeRequest(req)
 
-	var productNotFound responses.ProductNotFound
+	var productNotFound responses.Problem
 	json.Unmarshal(response.Body.Bytes(), &productNotFound)
 	assert.EqualValues(t, 422, response.Code)
-	assert.EqualValues(t, "Product FAKE not found", productNotFound.Message)
+	assert.EqualValues(t, "Product FAKE not found", productNotFound.Detail)
+	assert.EqualValues(t, 422, productNotFound.Status)
+	assert.EqualValues(t, "FAKE", productNotFound.Details["product-code"])
 	theCheckoutRepositoryMock.AssertExpectations(t)
 	theProductRepositoryMock.AssertExpectations(t)
 }
 
+func TestReturn200RetrievingCheckoutWhenCheckoutExists(t *testing.T) {
+	checkout := ACheckout()
+	checkout.Products = []string{"MUG", "PEN", "PEN"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theProductRepositoryMock := ProductRepositoryMockWithAllProducts()
+	theProductWithDiscountRepositoryMock := mocks.ProductWithDiscountRepositoryMock{}
+	theProductWithDiscountRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	theProductWithPromotionRepositoryMock := mocks.ProductWithPromotionRepositoryMock{}
+	theProductWithPromotionRepositoryMock.On("SearchById", "PEN").Return(models.Product{}, true)
+	theProductWithPromotionRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	app.RetrieveCheckoutService = services.NewRetrieveCheckout(
+		&theCheckoutRepositoryMock,
+		theProductRepositoryMock,
+		&theProductWithPromotionRepositoryMock,
+		&theProductWithDiscountRepositoryMock)
+
+	req, _ := http.NewRequest("GET", "/checkouts/"+checkout.Id, nil)
+	response := executeRequest(req)
+
+	var checkoutDetail responses.CheckoutDetail
+	json.Unmarshal(response.Body.Bytes(), &checkoutDetail)
+	assert.EqualValues(t, 200, response.Code)
+	assert.EqualValues(t, checkout.Id, checkoutDetail.Id)
+	assert.EqualValues(t, 2, len(checkoutDetail.Lines))
+	assert.EqualValues(t, "MUG", checkoutDetail.Lines[0].ProductCode)
+	assert.EqualValues(t, "Lana Coffee Mug", checkoutDetail.Lines[0].ProductName)
+	assert.EqualValues(t, "7.50€", checkoutDetail.Lines[0].UnitPrice)
+	assert.EqualValues(t, "PEN", checkoutDetail.Lines[1].ProductCode)
+	assert.EqualValues(t, 2, checkoutDetail.Lines[1].Quantity)
+	assert.EqualValues(t, "5.00€", checkoutDetail.Lines[1].Amount)
+	assert.EqualValues(t, "17.50€", checkoutDetail.Subtotal)
+	assert.EqualValues(t, "5.00€", checkoutDetail.Discount)
+	assert.EqualValues(t, "12.50€", checkoutDetail.Amount)
+	theCheckoutRepositoryMock.AssertExpectations(t)
+}
+
+func TestReturn404RetrievingCheckoutWhenCheckoutDoesNotExists(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", "a_fake_checkout").Return(models.Checkout{}, false)
+	app.RetrieveCheckoutService = services.NewRetrieveCheckout(
+		&theCheckoutRepositoryMock,
+		&mocks.ProductRepositoryMock{},
+		&mocks.ProductWithPromotionRepositoryMock{},
+		&mocks.ProductWithDiscountRepositoryMock{})
+
+	req, _ := http.NewRequest("GET", "/checkouts/a_fake_checkout", nil)
+	response := executeRequest(req)
+
+	var checkoutNotFound responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &checkoutNotFound)
+	assert.EqualValues(t, 404, response.Code)
+	assert.EqualValues(t, "Checkout a_fake_checkout not found", checkoutNotFound.Detail)
+}
+
 func TestReturn200RetrievingCheckoutAmountWhenCheckoutExists(t *testing.T) {
 	checkout := ACheckout()
 	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
@@ -180,7 +438,7 @@ func TestReturn200RetrievingCheckoutAmountWhenCheckoutExists(t *testing.T) {
 	theProductWithPromotionRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
 	app.RetrieveCheckoutAmountService = services.NewRetrieveCheckoutAmount(
 		&theCheckoutRepositoryMock,
-		&theProductRepositoryMock,
+		theProductRepositoryMock,
 		&theProductWithPromotionRepositoryMock,
 		&theProductWithDiscountRepositoryMock)
 
@@ -212,11 +470,44 @@ func TestReturn404RetrievingCheckoutAmountWhenCheckoutDoesNotExists(t *testing.T
 	req, _ := http.NewRequest("GET", "/checkouts/a_fake_checkout/amount", nil)
 	response := executeRequest(req)
 
-	var checkoutNotFound responses.CheckoutNotFound
+	var checkoutNotFound responses.Problem
 	json.Unmarshal(response.Body.Bytes(), &checkoutNotFound)
 
 	assert.EqualValues(t, 404, response.Code)
-	assert.EqualValues(t, "Checkout a_fake_checkout not found", checkoutNotFound.Message)
+	assert.EqualValues(t, "Checkout a_fake_checkout not found", checkoutNotFound.Detail)
+	theCheckoutRepositoryMock.AssertExpectations(t)
+}
+
+func TestReturn200RetrievingCheckoutsAmount(t *testing.T) {
+	checkout := ACheckout()
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCheckoutRepositoryMock.On("SearchById", "a_fake_checkout").Return(models.Checkout{}, false)
+	theProductRepositoryMock := ProductRepositoryMockWithAllProducts()
+	theProductWithDiscountRepositoryMock := mocks.ProductWithDiscountRepositoryMock{}
+	theProductWithDiscountRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	theProductWithPromotionRepositoryMock := mocks.ProductWithPromotionRepositoryMock{}
+	theProductWithPromotionRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	app.RetrieveCheckoutsAmountService = services.NewRetrieveCheckoutsAmount(
+		&theCheckoutRepositoryMock,
+		theProductRepositoryMock,
+		&theProductWithPromotionRepositoryMock,
+		&theProductWithDiscountRepositoryMock)
+	payload := []byte(`{"ids":["` + checkout.Id + `","a_fake_checkout"]}`)
+
+	req, _ := http.NewRequest("POST", "/checkouts/amounts", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var responseCheckouts responses.CheckoutsAmount
+	json.Unmarshal(response.Body.Bytes(), &responseCheckouts)
+	assert.EqualValues(t, 200, response.Code)
+	assert.EqualValues(t, 1, len(responseCheckouts.Amounts))
+	assert.EqualValues(t, checkout.Id, responseCheckouts.Amounts[0].Id)
+	assert.EqualValues(t, "7.50€", responseCheckouts.Amounts[0].Amount)
+	assert.EqualValues(t, 1, len(responseCheckouts.Errors))
+	assert.EqualValues(t, "a_fake_checkout", responseCheckouts.Errors[0].Id)
+	assert.EqualValues(t, "Checkout a_fake_checkout not found", responseCheckouts.Errors[0].Detail)
 	theCheckoutRepositoryMock.AssertExpectations(t)
 }
 
@@ -224,7 +515,7 @@ func TestReturn204WhenDeleteCheckout(t *testing.T) {
 	checkout := ACheckout()
 	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
 	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
-	theCheckoutRepositoryMock.On("Delete", checkout)
+	theCheckoutRepositoryMock.On("DeleteIfStatus", checkout, mock.AnythingOfType("string")).Return(true)
 	app.DeleteCheckoutService = services.NewDeleteCheckout(&theCheckoutRepositoryMock)
 
 	req, _ := http.NewRequest("DELETE", "/checkouts/"+checkout.Id, nil)
@@ -242,8 +533,1333 @@ func TestReturn404DeletingCheckoutWhenCheckoutDoesNotExists(t *testing.T) {
 	req, _ := http.NewRequest("DELETE", "/checkouts/a_fake_checkout", nil)
 	response := executeRequest(req)
 
-	var checkoutNotFound responses.CheckoutNotFound
+	var checkoutNotFound responses.Problem
 	json.Unmarshal(response.Body.Bytes(), &checkoutNotFound)
 	assert.EqualValues(t, 404, response.Code)
-	assert.EqualValues(t, "Checkout a_fake_checkout not found", checkoutNotFound.Message)
+	assert.EqualValues(t, "Checkout a_fake_checkout not found", checkoutNotFound.Detail)
+}
+
+func TestReturn400WhenCreateCheckoutWithMalformedBody(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	app.CreateCheckoutService = services.NewCreateCheckout(&theCheckoutRepositoryMock, &theProductRepositoryMock)
+	payload := []byte(`{"product-code":`)
+
+	req, _ := http.NewRequest("POST", "/checkouts", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 400, response.Code)
+	assert.EqualValues(t, "malformed-body", problem.Code)
+	theProductRepositoryMock.AssertNotCalled(t, "SearchById", mock.Anything)
+}
+
+func TestReturn400WhenCreateCheckoutWithUnknownField(t *testing.T) {
+	payload := []byte(`{"product":"PEN"}`)
+
+	req, _ := http.NewRequest("POST", "/checkouts", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 400, response.Code)
+	assert.EqualValues(t, "validation-failed", problem.Code)
+	assert.EqualValues(t, map[string]interface{}{"product": "is not allowed"}, problem.Details["fields"])
+}
+
+func TestReturn400WhenCreateCheckoutWithoutProductCode(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	app.CreateCheckoutService = services.NewCreateCheckout(&theCheckoutRepositoryMock, &theProductRepositoryMock)
+	payload := []byte(`{}`)
+
+	req, _ := http.NewRequest("POST", "/checkouts", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 400, response.Code)
+	assert.EqualValues(t, "validation-failed", problem.Code)
+	assert.EqualValues(t, map[string]interface{}{"product-code": "is required"}, problem.Details["fields"])
+}
+
+func TestReturn400WhenAddingProductWithWrongFieldType(t *testing.T) {
+	payload := []byte(`{"product":42}`)
+
+	req, _ := http.NewRequest("PATCH", "/checkouts/an_id", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 400, response.Code)
+	assert.EqualValues(t, map[string]interface{}{"product": "must be a string"}, problem.Details["fields"])
+}
+
+func TestReturn415WhenBodyIsNotJson(t *testing.T) {
+	payload := []byte(`product-code=PEN`)
+
+	req, _ := http.NewRequest("POST", "/checkouts", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response := executeRequest(req)
+
+	assert.EqualValues(t, 415, response.Code)
+}
+
+func TestReturn413WhenBodyIsTooLarge(t *testing.T) {
+	payload := []byte(`{"product-code":"` + strings.Repeat("A", maxBodyBytes) + `"}`)
+
+	req, _ := http.NewRequest("POST", "/checkouts", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 413, response.Code)
+	assert.EqualValues(t, "payload-too-large", problem.Code)
+}
+
+func TestReturn413WhenBodyIsTooLargeAfterTheJsonObject(t *testing.T) {
+	payload := []byte(`{"product-code":"PEN"}` + strings.Repeat(" ", maxBodyBytes))
+
+	req, _ := http.NewRequest("POST", "/checkouts", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 413, response.Code)
+	assert.EqualValues(t, "payload-too-large", problem.Code)
+}
+
+func TestRoutesAreServedUnderEveryVersion(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Search", mock.AnythingOfType("persistence.CheckoutCriteria")).Return([]models.Checkout{})
+	app.ListCheckoutsService = services.NewListCheckouts(&theCheckoutRepositoryMock)
+
+	for path, version := range map[string]string{"/checkouts": "v1", "/v1/checkouts": "v1", "/v2/checkouts": "v2"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		response := executeRequest(req)
+
+		assert.EqualValues(t, 200, response.Code, path)
+		assert.EqualValues(t, version, response.Header().Get("API-Version"), path)
+		assert.EqualValues(t, "", response.Header().Get("Deprecation"), path)
+	}
+}
+
+func TestReturn400WhenCreateCheckoutUnderV1WithV2Shape(t *testing.T) {
+	payload := []byte(`{"product-code":"PEN","quantity":1}`)
+
+	v1Req, _ := http.NewRequest("POST", "/v1/checkouts", bytes.NewBuffer(payload))
+	v1Req.Header.Set("Content-Type", "application/json")
+	v1Response := executeRequest(v1Req)
+
+	var problem responses.Problem
+	json.Unmarshal(v1Response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 400, v1Response.Code)
+	assert.EqualValues(t, map[string]interface{}{"quantity": "is not allowed"}, problem.Details["fields"])
+}
+
+func TestReturnDeprecationHeadersForDeprecatedVersion(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Search", mock.AnythingOfType("persistence.CheckoutCriteria")).Return([]models.Checkout{})
+	app.ListCheckoutsService = services.NewListCheckouts(&theCheckoutRepositoryMock)
+	app.Deprecations["v1"] = Deprecation{
+		Date:      time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC),
+		Sunset:    time.Date(2021, time.September, 1, 0, 0, 0, 0, time.UTC),
+		Successor: "/v2/checkouts",
+	}
+	defer delete(app.Deprecations, "v1")
+
+	for _, path := range []string{"/checkouts", "/v1/checkouts"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		response := executeRequest(req)
+
+		assert.EqualValues(t, "Mon, 01 Mar 2021 00:00:00 GMT", response.Header().Get("Deprecation"), path)
+		assert.EqualValues(t, "Wed, 01 Sep 2021 00:00:00 GMT", response.Header().Get("Sunset"), path)
+		assert.EqualValues(t, `</v2/checkouts>; rel="successor-version"`, response.Header().Get("Link"), path)
+	}
+
+	req, _ := http.NewRequest("GET", "/v2/checkouts", nil)
+	response := executeRequest(req)
+	assert.EqualValues(t, "", response.Header().Get("Deprecation"))
+}
+
+func TestReturn404ProblemWhenRouteDoesNotExist(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/baskets", nil)
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 404, response.Code)
+	assert.EqualValues(t, "application/problem+json", response.Header().Get("Content-Type"))
+	assert.EqualValues(t, "not-found", problem.Code)
+}
+
+func TestReturn405ProblemWhenMethodIsNotAllowed(t *testing.T) {
+	req, _ := http.NewRequest("PUT", "/checkouts", nil)
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 405, response.Code)
+	assert.EqualValues(t, "method-not-allowed", problem.Code)
+}
+
+func TestInternalProblemDoesNotExposeTheError(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/checkouts", nil)
+
+	problem := newProblem(req, fmt.Errorf("database password is wrong"))
+
+	assert.EqualValues(t, 500, problem.Status)
+	assert.EqualValues(t, "internal", problem.Code)
+	assert.EqualValues(t, "", problem.Detail)
+}
+
+func TestReturn200ExecutingGraphQLQuery(t *testing.T) {
+	checkout := ACheckout()
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theProductWithDiscountRepositoryMock := mocks.ProductWithDiscountRepositoryMock{}
+	theProductWithDiscountRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	theProductWithPromotionRepositoryMock := mocks.ProductWithPromotionRepositoryMock{}
+	theProductWithPromotionRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	app.RetrieveCheckoutService = services.NewRetrieveCheckout(
+		&theCheckoutRepositoryMock,
+		ProductRepositoryMockWithAllProducts(),
+		&theProductWithPromotionRepositoryMock,
+		&theProductWithDiscountRepositoryMock)
+
+	body := []byte(`{"query":"query($id: ID!) { checkout(id: $id) { id amount } }","variables":{"id":"` + checkout.Id + `"}}`)
+	req, _ := http.NewRequest("POST", "/graphql", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var result struct {
+		Data struct {
+			Checkout struct {
+				Id     string `json:"id"`
+				Amount int    `json:"amount"`
+			} `json:"checkout"`
+		} `json:"data"`
+	}
+	json.Unmarshal(response.Body.Bytes(), &result)
+	assert.EqualValues(t, 200, response.Code)
+	assert.EqualValues(t, checkout.Id, result.Data.Checkout.Id)
+	assert.EqualValues(t, 750, result.Data.Checkout.Amount)
+}
+
+func TestReturn400ExecutingGraphQLWithoutQuery(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/graphql", bytes.NewBuffer([]byte(`{"variables":{}}`)))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 400, response.Code)
+	assert.EqualValues(t, "validation-failed", problem.Code)
+}
+
+func TestReturn400ExecutingGraphQLQueryNestedTooDeep(t *testing.T) {
+	query := strings.Repeat("{ a ", 20) + strings.Repeat("}", 20)
+	body, _ := json.Marshal(map[string]string{"query": query})
+	req, _ := http.NewRequest("POST", "/graphql", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 400, response.Code)
+	assert.EqualValues(t, "validation-failed", problem.Code)
+}
+
+type healthCheckerFunc func() error
+
+func (ping healthCheckerFunc) Ping(ctx context.Context) error {
+	return ping()
+}
+
+func TestReturn200CheckingLiveness(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	response := executeRequest(req)
+
+	assert.EqualValues(t, 200, response.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, response.Body.String())
+}
+
+func TestReturn200CheckingReadinessWhenRepositoriesAreReady(t *testing.T) {
+	app.HealthCheckers = map[string]persistence.HealthChecker{
+		"checkouts": healthCheckerFunc(func() error { return nil }),
+	}
+
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	response := executeRequest(req)
+
+	assert.EqualValues(t, 200, response.Code)
+	assert.JSONEq(t, `{"status":"ready","checks":{"checkouts":"ok"}}`, response.Body.String())
+}
+
+func TestReturn503CheckingReadinessWhenARepositoryIsNotReady(t *testing.T) {
+	app.HealthCheckers = map[string]persistence.HealthChecker{
+		"checkouts": healthCheckerFunc(func() error { return nil }),
+		"products":  healthCheckerFunc(func() error { return fmt.Errorf("connection refused") }),
+	}
+
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	response := executeRequest(req)
+
+	assert.EqualValues(t, 503, response.Code)
+	assert.JSONEq(t, `{"status":"not-ready","checks":{"checkouts":"ok","products":"connection refused"}}`, response.Body.String())
+}
+
+func TestReturn503CheckingReadinessWhileShuttingDown(t *testing.T) {
+	app.HealthCheckers = map[string]persistence.HealthChecker{}
+	app.startShutdown()
+	defer atomic.StoreInt32(&app.shuttingDown, 0)
+
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	response := executeRequest(req)
+
+	assert.EqualValues(t, 503, response.Code)
+	assert.JSONEq(t, `{"status":"shutting-down"}`, response.Body.String())
+}
+
+func TestReturn200RetrievingVersion(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/version", nil)
+	response := executeRequest(req)
+
+	assert.EqualValues(t, 200, response.Code)
+	assert.JSONEq(t, `{"version":"dev","commit":"unknown"}`, response.Body.String())
+}
+
+func TestRunDrainsInFlightRequestsOnShutdown(t *testing.T) {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := listener.Addr().String()
+	listener.Close()
+	requestStarted := make(chan bool)
+	slowApp := App{Router: mux.NewRouter()}
+	slowApp.Router.HandleFunc("/slow", func(response http.ResponseWriter, request *http.Request) {
+		requestStarted <- true
+		time.Sleep(100 * time.Millisecond)
+		response.WriteHeader(http.StatusNoContent)
+	})
+	config := defaultConfig()
+	config.Addr = addr
+	ctx, stop := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() { stopped <- slowApp.Run(ctx, config) }()
+
+	var response *http.Response
+	var err error
+	responded := make(chan bool)
+	go func() {
+		for attempt := 0; attempt < 50; attempt++ {
+			if response, err = http.Get("http://" + addr + "/slow"); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		responded <- true
+	}()
+	<-requestStarted
+	stop()
+	<-responded
+
+	assert.Nil(t, <-stopped)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 204, response.StatusCode)
+}
+
+func TestReturn200RetrievingMetricsWithRequestsByRouteTemplate(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", "a_fake_checkout").Return(models.Checkout{}, false)
+	app.DeleteCheckoutService = services.NewDeleteCheckout(&theCheckoutRepositoryMock)
+	deleteReq, _ := http.NewRequest("DELETE", "/v1/checkouts/a_fake_checkout", nil)
+	executeRequest(deleteReq)
+
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	response := executeRequest(req)
+
+	assert.EqualValues(t, 200, response.Code)
+	assert.Contains(t, response.Body.String(), `flagship_store_http_requests_total{method="DELETE",route="/v1/checkouts/{id}",status="404"} 1`)
+	assert.Contains(t, response.Body.String(), `flagship_store_http_request_duration_seconds_count{method="DELETE",route="/v1/checkouts/{id}",status="404"} 1`)
+}
+
+func TestRequestIdIsAssignedWhenClientDoesNotSendIt(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	response := executeRequest(req)
+
+	_, err := uuid.Parse(response.Header().Get("X-Request-ID"))
+	assert.Nil(t, err)
+}
+
+func TestRequestIdIsPropagatedWhenClientSendsIt(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	req.Header.Set("X-Request-ID", "a-request-id")
+	response := executeRequest(req)
+
+	assert.EqualValues(t, "a-request-id", response.Header().Get("X-Request-ID"))
+}
+
+func TestRequestIdIsReplacedWhenClientSendsAnInvalidOne(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	req.Header.Set("X-Request-ID", "a request\nid")
+	response := executeRequest(req)
+
+	assert.NotEqual(t, "a request\nid", response.Header().Get("X-Request-ID"))
+	assert.NotEmpty(t, response.Header().Get("X-Request-ID"))
+}
+
+func TestAccessLogHasRouteTemplateStatusAndCheckoutId(t *testing.T) {
+	output := bytes.Buffer{}
+	app.Logger = logging.New(&output)
+	defer func() { app.Logger = logging.New(ioutil.Discard) }()
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", "a_fake_checkout").Return(models.Checkout{}, false)
+	app.DeleteCheckoutService = services.NewDeleteCheckout(&theCheckoutRepositoryMock)
+
+	req, _ := http.NewRequest("DELETE", "/checkouts/a_fake_checkout", nil)
+	req.Header.Set("X-Request-ID", "a-request-id")
+	executeRequest(req)
+
+	var accessLog map[string]interface{}
+	json.Unmarshal(output.Bytes(), &accessLog)
+	assert.EqualValues(t, "Request served", accessLog["message"])
+	assert.EqualValues(t, "DELETE", accessLog["method"])
+	assert.EqualValues(t, "/checkouts/{id}", accessLog["route"])
+	assert.EqualValues(t, 404, accessLog["status"])
+	assert.EqualValues(t, "a_fake_checkout", accessLog["checkout-id"])
+	assert.EqualValues(t, "a-request-id", accessLog["request-id"])
+	assert.Contains(t, accessLog, "duration-ms")
+}
+
+func TestAccessLogHasTheCustomerIdOfCustomerRoutes(t *testing.T) {
+	output := bytes.Buffer{}
+	app.Logger = logging.New(&output)
+	defer func() { app.Logger = logging.New(ioutil.Discard) }()
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("SearchById", "a-customer").Return(models.Customer{}, false)
+	app.RetrieveCustomerService = services.NewRetrieveCustomer(&theCustomerRepositoryMock)
+
+	req, _ := http.NewRequest("GET", "/customers/a-customer", nil)
+	executeRequest(req)
+
+	var accessLog map[string]interface{}
+	json.Unmarshal(output.Bytes(), &accessLog)
+	assert.EqualValues(t, "/customers/{id}", accessLog["route"])
+	assert.EqualValues(t, "a-customer", accessLog["customer-id"])
+	assert.NotContains(t, accessLog, "checkout-id")
+}
+
+func TestAccessLogHasIdOfCreatedCheckout(t *testing.T) {
+	output := bytes.Buffer{}
+	app.Logger = logging.New(&output)
+	defer func() { app.Logger = logging.New(ioutil.Discard) }()
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	app.CreateCheckoutService = services.NewCreateCheckout(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts())
+
+	req, _ := http.NewRequest("POST", "/v2/checkouts", bytes.NewBuffer([]byte(`{}`)))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var checkout models.Checkout
+	json.Unmarshal(response.Body.Bytes(), &checkout)
+	var accessLog map[string]interface{}
+	json.Unmarshal(output.Bytes(), &accessLog)
+	assert.EqualValues(t, 201, accessLog["status"])
+	assert.EqualValues(t, checkout.Id, accessLog["checkout-id"])
+}
+
+func TestAccessLogOfUnmatchedRoutes(t *testing.T) {
+	output := bytes.Buffer{}
+	app.Logger = logging.New(&output)
+	defer func() { app.Logger = logging.New(ioutil.Discard) }()
+
+	req, _ := http.NewRequest("GET", "/unknown", nil)
+	executeRequest(req)
+
+	var accessLog map[string]interface{}
+	json.Unmarshal(output.Bytes(), &accessLog)
+	assert.EqualValues(t, "unmatched", accessLog["route"])
+	assert.EqualValues(t, 404, accessLog["status"])
+}
+
+func TestReturn499WhenClientCancelsTheRequest(t *testing.T) {
+	checkout := ACheckout()
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	app.DeleteCheckoutService = services.NewDeleteCheckout(&theCheckoutRepositoryMock)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, "DELETE", "/checkouts/"+checkout.Id, nil)
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 499, response.Code)
+	assert.EqualValues(t, "request-canceled", problem.Code)
+	theCheckoutRepositoryMock.AssertNotCalled(t, "DeleteIfStatus", mock.Anything, mock.Anything)
+}
+
+func TestTraceRequestsWithSpansOfTheServiceRepositoriesAndPricingRules(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(previousProvider)
+	defer otel.SetTextMapPropagator(previousPropagator)
+	checkout := ACheckout()
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theProductWithDiscountRepositoryMock := mocks.ProductWithDiscountRepositoryMock{}
+	theProductWithDiscountRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, true)
+	theProductWithPromotionRepositoryMock := mocks.ProductWithPromotionRepositoryMock{}
+	theProductWithPromotionRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, true)
+	app.RetrieveCheckoutAmountService = services.NewRetrieveCheckoutAmount(
+		persistence.NewTracedCheckoutRepository(&theCheckoutRepositoryMock),
+		persistence.NewTracedProductRepository(ProductRepositoryMockWithAllProducts(), "products"),
+		persistence.NewTracedProductRepository(&theProductWithPromotionRepositoryMock, "products-with-promotion"),
+		persistence.NewTracedProductRepository(&theProductWithDiscountRepositoryMock, "products-with-discount"))
+
+	req, _ := http.NewRequest("GET", "/checkouts/"+checkout.Id+"/amount", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	response := executeRequest(req)
+
+	assert.EqualValues(t, 200, response.Code)
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	}
+	handlerSpan := spans["GET /checkouts/{id}/amount"]
+	serviceSpan := spans["services.RetrieveCheckoutAmount"]
+	pricingSpan := spans["pricing.EvaluateRules"]
+	assert.Equal(t, "00f067aa0ba902b7", handlerSpan.Parent().SpanID().String())
+	assert.Contains(t, handlerSpan.Attributes(), semconv.HTTPStatusCodeKey.Int(200))
+	assert.Equal(t, handlerSpan.SpanContext().SpanID(), serviceSpan.Parent().SpanID())
+	assert.Equal(t, serviceSpan.SpanContext().SpanID(), spans["CheckoutRepository.SearchById"].Parent().SpanID())
+	assert.Equal(t, serviceSpan.SpanContext().SpanID(), pricingSpan.Parent().SpanID())
+	assert.Equal(t, pricingSpan.SpanContext().SpanID(), spans["pricing.Apply2X1Promotion"].Parent().SpanID())
+	assert.Equal(t, pricingSpan.SpanContext().SpanID(), spans["pricing.ApplyBulkDiscount"].Parent().SpanID())
+	assert.Equal(t, pricingSpan.SpanContext().SpanID(), spans["ProductRepository.SearchById"].Parent().SpanID())
+}
+
+func shopperToken(t *testing.T, subject string) string {
+	claims := jwt.StandardClaims{Subject: subject, ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestReturn401WhenRequestHasNoCredentials(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/checkouts/"+uuid.NewString()+"/amount", nil)
+	response := httptest.NewRecorder()
+	app.Router.ServeHTTP(response, req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 401, response.Code)
+	assert.EqualValues(t, "unauthorized", problem.Code)
+	assert.EqualValues(t, `Bearer realm="flagship-store"`, response.Header().Get("WWW-Authenticate"))
+}
+
+func TestReturn401WhenAPIKeyIsNotValid(t *testing.T) {
+	req, _ := http.NewRequest("DELETE", "/v2/checkouts/"+uuid.NewString(), nil)
+	req.Header.Set("X-API-Key", "not-a-key")
+	response := executeRequest(req)
+
+	assert.EqualValues(t, 401, response.Code)
+}
+
+func TestReturn401WhenBearerTokenIsNotValid(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/graphql", strings.NewReader(`{"query":"{ products { code } }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer not-a-token")
+	response := executeRequest(req)
+
+	assert.EqualValues(t, 401, response.Code)
+}
+
+func TestReturn200CheckingHealthWithoutCredentials(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	response := httptest.NewRecorder()
+	app.Router.ServeHTTP(response, req)
+
+	assert.EqualValues(t, 200, response.Code)
+}
+
+func TestReturn201CreatingCheckoutBoundToTheShopper(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	app.CreateCheckoutService = services.NewCreateCheckout(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts())
+
+	req, _ := http.NewRequest("POST", "/v2/checkouts", strings.NewReader(`{"lines":[]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+shopperToken(t, "a-shopper"))
+	response := executeRequest(req)
+
+	var createdCheckout models.Checkout
+	json.Unmarshal(response.Body.Bytes(), &createdCheckout)
+	assert.EqualValues(t, 201, response.Code)
+	assert.EqualValues(t, "jwt:a-shopper", createdCheckout.Owner)
+}
+
+func TestReturn403AccessingCheckoutOfAnotherShopper(t *testing.T) {
+	checkout := ACheckout()
+	checkout.Owner = "jwt:another-shopper"
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	app.AddProductToCheckoutService = services.NewAddProductToCheckout(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts())
+	app.DeleteCheckoutService = services.NewDeleteCheckout(&theCheckoutRepositoryMock)
+	app.RetrieveCheckoutAmountService = services.NewRetrieveCheckoutAmount(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts(), &mocks.ProductWithPromotionRepositoryMock{}, &mocks.ProductWithDiscountRepositoryMock{})
+	requests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"PATCH", "/v2/checkouts/" + checkout.Id, `{"product-code":"PEN","quantity":1}`},
+		{"DELETE", "/checkouts/" + checkout.Id, ""},
+		{"GET", "/checkouts/" + checkout.Id + "/amount", ""},
+	}
+
+	for _, request := range requests {
+		req, _ := http.NewRequest(request.method, request.path, strings.NewReader(request.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+shopperToken(t, "a-shopper"))
+		response := executeRequest(req)
+
+		var problem responses.Problem
+		json.Unmarshal(response.Body.Bytes(), &problem)
+		assert.EqualValues(t, 403, response.Code, request.method+" "+request.path)
+		assert.EqualValues(t, "forbidden", problem.Code, request.method+" "+request.path)
+	}
+	theCheckoutRepositoryMock.AssertNotCalled(t, "PersistIfStatus", mock.Anything, mock.Anything)
+	theCheckoutRepositoryMock.AssertNotCalled(t, "DeleteIfStatus", mock.Anything, mock.Anything)
+}
+
+func TestReturn403WhenRoleMayNotInvokeTheRoute(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	app.CreateCheckoutService = services.NewCreateCheckout(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts())
+	app.AddProductToCheckoutService = services.NewAddProductToCheckout(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts())
+	app.DeleteCheckoutService = services.NewDeleteCheckout(&theCheckoutRepositoryMock)
+	checkoutId := uuid.NewString()
+	deniedRoutes := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"POST", "/checkouts", `{"product-code":"PEN"}`},
+		{"POST", "/v1/checkouts", `{"product-code":"PEN"}`},
+		{"POST", "/v2/checkouts", `{"lines":[]}`},
+		{"PATCH", "/checkouts/" + checkoutId, `{"product-code":"PEN"}`},
+		{"PATCH", "/v1/checkouts/" + checkoutId, `{"product-code":"PEN"}`},
+		{"PATCH", "/v2/checkouts/" + checkoutId, `{"product-code":"PEN","quantity":1}`},
+		{"DELETE", "/checkouts/" + checkoutId, ""},
+		{"DELETE", "/v2/checkouts/" + checkoutId, ""},
+		{"POST", "/customers", `{"email":"ada@example.com","name":"Ada"}`},
+		{"PUT", "/checkouts/" + checkoutId + "/customer", `{"customer-id":"a-customer"}`},
+		{"POST", "/checkouts/" + checkoutId + "/merge", `{"source-id":"a-source"}`},
+		{"POST", "/saved-lists", `{"name":"Birthday","kind":"wishlist"}`},
+		{"POST", "/saved-lists/a-list/checkouts", ""},
+		{"POST", "/price-lists", `{"name":"Wholesale","group":"wholesale","prices":{"MUG":600}}`},
+		{"POST", "/checkouts/" + checkoutId + "/complete", ""},
+		{"PUT", "/checkouts/" + checkoutId + "/loyalty-points", `{"points":100}`},
+	}
+
+	for _, route := range deniedRoutes {
+		req, _ := http.NewRequest(route.method, route.path, strings.NewReader(route.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", testMerchandiserAPIKey)
+		response := executeRequest(req)
+
+		var problem responses.Problem
+		json.Unmarshal(response.Body.Bytes(), &problem)
+		assert.EqualValues(t, 403, response.Code, route.method+" "+route.path)
+		assert.EqualValues(t, "forbidden", problem.Code, route.method+" "+route.path)
+		assert.EqualValues(t, "merchandiser", problem.Details["role"], route.method+" "+route.path)
+	}
+	assert.Empty(t, theCheckoutRepositoryMock.Calls)
+}
+
+func TestReturn200WhenAdminRetrievesCheckoutAmountOfAnotherOwner(t *testing.T) {
+	checkout := ACheckout()
+	checkout.Owner = "jwt:a-shopper"
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theProductWithPromotionRepositoryMock := mocks.ProductWithPromotionRepositoryMock{}
+	theProductWithPromotionRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	theProductWithDiscountRepositoryMock := mocks.ProductWithDiscountRepositoryMock{}
+	theProductWithDiscountRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	app.RetrieveCheckoutAmountService = services.NewRetrieveCheckoutAmount(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts(), &theProductWithPromotionRepositoryMock, &theProductWithDiscountRepositoryMock)
+
+	req, _ := http.NewRequest("GET", "/checkouts/"+checkout.Id+"/amount", nil)
+	req.Header.Set("X-API-Key", testAdminAPIKey)
+	response := executeRequest(req)
+
+	assert.EqualValues(t, 200, response.Code)
+}
+
+func TestListCheckoutsOfTheShopperOrOfEveryOwnerForMerchandisers(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Search", mock.AnythingOfType("persistence.CheckoutCriteria")).Return([]models.Checkout{})
+	app.ListCheckoutsService = services.NewListCheckouts(&theCheckoutRepositoryMock)
+
+	shopperRequest, _ := http.NewRequest("GET", "/checkouts", nil)
+	shopperRequest.Header.Set("Authorization", "Bearer "+shopperToken(t, "a-shopper"))
+	executeRequest(shopperRequest)
+	merchandiserRequest, _ := http.NewRequest("GET", "/checkouts", nil)
+	merchandiserRequest.Header.Set("X-API-Key", testMerchandiserAPIKey)
+	executeRequest(merchandiserRequest)
+
+	assert.EqualValues(t, "jwt:a-shopper", theCheckoutRepositoryMock.Calls[0].Arguments.Get(0).(persistence.CheckoutCriteria).Owner)
+	assert.EqualValues(t, "", theCheckoutRepositoryMock.Calls[1].Arguments.Get(0).(persistence.CheckoutCriteria).Owner)
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	return false, 0, fmt.Errorf("limiter store unreachable")
+}
+
+func limitRequests(t *testing.T, limiter ratelimit.Limiter) {
+	app.Limiter = limiter
+	t.Cleanup(func() { app.Limiter = nil })
+}
+
+func TestReturn429WithRetryAfterWhenClientExceedsTheRateLimit(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	app.CreateCheckoutService = services.NewCreateCheckout(&theCheckoutRepositoryMock, &mocks.ProductRepositoryMock{})
+	limitRequests(t, ratelimit.NewTokenBucket(0.5, 1))
+	createCheckout := func(subject string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/v2/checkouts", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+shopperToken(t, subject))
+		return executeRequest(req)
+	}
+
+	first := createCheckout("a-shopper")
+	limited := createCheckout("a-shopper")
+	another := createCheckout("another-shopper")
+
+	var problem responses.Problem
+	json.Unmarshal(limited.Body.Bytes(), &problem)
+	assert.EqualValues(t, 201, first.Code)
+	assert.EqualValues(t, 429, limited.Code)
+	assert.EqualValues(t, "2", limited.Header().Get("Retry-After"))
+	assert.EqualValues(t, "rate-limited", problem.Code)
+	assert.EqualValues(t, 201, another.Code)
+	theCheckoutRepositoryMock.AssertNumberOfCalls(t, "Persist", 2)
+}
+
+func TestReturn429WhenIPFailingAuthenticationExceedsTheRateLimit(t *testing.T) {
+	limitRequests(t, ratelimit.NewTokenBucket(0.5, 1))
+	guessKey := func(remoteAddr string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/checkouts", nil)
+		req.Header.Set("X-API-Key", "a-guessed-key")
+		req.RemoteAddr = remoteAddr
+		return executeRequest(req)
+	}
+
+	first := guessKey("203.0.113.7:51000")
+	limited := guessKey("203.0.113.7:51001")
+	another := guessKey("198.51.100.2:51000")
+
+	assert.EqualValues(t, 401, first.Code)
+	assert.EqualValues(t, 429, limited.Code)
+	assert.EqualValues(t, 401, another.Code)
+}
+
+func TestServeRequestsWhenTheLimiterFails(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Search", mock.AnythingOfType("persistence.CheckoutCriteria")).Return([]models.Checkout{})
+	app.ListCheckoutsService = services.NewListCheckouts(&theCheckoutRepositoryMock)
+	limitRequests(t, failingLimiter{})
+
+	req, _ := http.NewRequest("GET", "/checkouts", nil)
+	response := executeRequest(req)
+
+	assert.EqualValues(t, 200, response.Code)
+}
+
+func TestReturn429WhenOwnerHasTheMaximumOpenCheckouts(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Search", mock.AnythingOfType("persistence.CheckoutCriteria")).Return([]models.Checkout{ACheckout()})
+	createCheckoutService := services.NewCreateCheckout(&theCheckoutRepositoryMock, &mocks.ProductRepositoryMock{})
+	createCheckoutService.MaxOpenCheckouts = 1
+	app.CreateCheckoutService = createCheckoutService
+
+	req, _ := http.NewRequest("POST", "/v2/checkouts", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 429, response.Code)
+	assert.EqualValues(t, "open-checkouts-limit-exceeded", problem.Code)
+	assert.EqualValues(t, "", response.Header().Get("Retry-After"))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "Persist", mock.Anything)
+}
+
+func storeIdempotencyKeys(t *testing.T) *idempotency.InMemoryStore {
+	store := idempotency.NewInMemoryStore(time.Hour)
+	app.IdempotencyStore = store
+	t.Cleanup(func() { app.IdempotencyStore = nil })
+	return store
+}
+
+func idempotentRequest(method string, path string, key string, body string) *http.Request {
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+	return req
+}
+
+func TestReplayTheResponseWhenAddProductIsRetriedWithTheSameIdempotencyKey(t *testing.T) {
+	storeIdempotencyKeys(t)
+	checkout := ACheckout()
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), mock.AnythingOfType("string")).Return(true)
+	app.AddProductToCheckoutService = services.NewAddProductToCheckout(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts())
+
+	first := executeRequest(idempotentRequest("PATCH", "/v2/checkouts/"+checkout.Id, "a-key", `{"product-code":"PEN","quantity":1}`))
+	retried := executeRequest(idempotentRequest("PATCH", "/v2/checkouts/"+checkout.Id, "a-key", `{"product-code":"PEN","quantity":1}`))
+
+	assert.EqualValues(t, 204, first.Code)
+	assert.EqualValues(t, "", first.Header().Get("Idempotent-Replayed"))
+	assert.EqualValues(t, 204, retried.Code)
+	assert.EqualValues(t, "true", retried.Header().Get("Idempotent-Replayed"))
+	theCheckoutRepositoryMock.AssertNumberOfCalls(t, "PersistIfStatus", 1)
+}
+
+func TestReplayTheCreatedCheckoutWhenCreateIsRetriedWithTheSameIdempotencyKey(t *testing.T) {
+	storeIdempotencyKeys(t)
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	app.CreateCheckoutService = services.NewCreateCheckout(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts())
+
+	first := executeRequest(idempotentRequest("POST", "/v2/checkouts", "a-key", `{"lines":[{"product-code":"PEN","quantity":1}]}`))
+	retried := executeRequest(idempotentRequest("POST", "/v2/checkouts", "a-key", `{"lines":[{"product-code":"PEN","quantity":1}]}`))
+
+	assert.EqualValues(t, 201, retried.Code)
+	assert.EqualValues(t, first.Body.String(), retried.Body.String())
+	assert.NotEqual(t, first.Header().Get("X-Request-ID"), retried.Header().Get("X-Request-ID"))
+	theCheckoutRepositoryMock.AssertNumberOfCalls(t, "Persist", 1)
+}
+
+func TestReturn422WhenIdempotencyKeyIsReusedWithADifferentBody(t *testing.T) {
+	storeIdempotencyKeys(t)
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	app.CreateCheckoutService = services.NewCreateCheckout(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts())
+
+	executeRequest(idempotentRequest("POST", "/v2/checkouts", "a-key", `{"lines":[{"product-code":"PEN","quantity":1}]}`))
+	response := executeRequest(idempotentRequest("POST", "/v2/checkouts", "a-key", `{"lines":[{"product-code":"MUG","quantity":1}]}`))
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 422, response.Code)
+	assert.EqualValues(t, "idempotency-key-reused", problem.Code)
+	theCheckoutRepositoryMock.AssertNumberOfCalls(t, "Persist", 1)
+}
+
+func TestReturn409WhenTheRequestWithTheIdempotencyKeyIsStillServed(t *testing.T) {
+	store := storeIdempotencyKeys(t)
+	req := idempotentRequest("POST", "/v2/checkouts", "a-key", `{}`)
+	store.Reserve(context.Background(), "api-key:tests a-key", fingerprintOf(req, []byte(`{}`)))
+
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 409, response.Code)
+	assert.EqualValues(t, "idempotency-key-in-use", problem.Code)
+}
+
+func TestIdempotencyKeysOfEveryClientApart(t *testing.T) {
+	storeIdempotencyKeys(t)
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	app.CreateCheckoutService = services.NewCreateCheckout(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts())
+
+	executeRequest(idempotentRequest("POST", "/v2/checkouts", "a-key", `{}`))
+	req := idempotentRequest("POST", "/v2/checkouts", "a-key", `{}`)
+	req.Header.Set("Authorization", "Bearer "+shopperToken(t, "a-shopper"))
+	response := executeRequest(req)
+
+	assert.EqualValues(t, 201, response.Code)
+	assert.EqualValues(t, "", response.Header().Get("Idempotent-Replayed"))
+	theCheckoutRepositoryMock.AssertNumberOfCalls(t, "Persist", 2)
+}
+
+func TestReturn400WhenIdempotencyKeyIsTooLong(t *testing.T) {
+	storeIdempotencyKeys(t)
+
+	response := executeRequest(idempotentRequest("POST", "/v2/checkouts", strings.Repeat("k", 256), `{}`))
+
+	assert.EqualValues(t, 400, response.Code)
+}
+
+func TestReturn413WhenIdempotentRequestBodyIsTooLarge(t *testing.T) {
+	storeIdempotencyKeys(t)
+	payload := `{"product-code":"` + strings.Repeat("A", maxBodyBytes) + `"}`
+
+	response := executeRequest(idempotentRequest("POST", "/v2/checkouts", "a-key", payload))
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 413, response.Code)
+	assert.EqualValues(t, "payload-too-large", problem.Code)
+}
+
+func TestReleaseTheIdempotencyKeyWhenTheHandlerPanics(t *testing.T) {
+	store := storeIdempotencyKeys(t)
+	req := idempotentRequest("POST", "/v2/checkouts", "a-key", `{}`)
+	handler := app.idempotent(func(response http.ResponseWriter, request *http.Request) {
+		panic("a handler failure")
+	})
+
+	assert.Panics(t, func() { handler(httptest.NewRecorder(), req) })
+
+	_, reserved, _ := store.Reserve(context.Background(), clientOf(req)+" a-key", fingerprintOf(req, []byte(`{}`)))
+	assert.True(t, reserved)
+}
+
+func TestReturn201WhenRegisterCustomer(t *testing.T) {
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("PersistIfEmailAbsent", mock.AnythingOfType("models.Customer")).Return(true)
+	app.RegisterCustomerService = services.NewRegisterCustomer(&theCustomerRepositoryMock)
+
+	req, _ := http.NewRequest("POST", "/customers", strings.NewReader(`{"email":"ada@example.com","name":"Ada"}`))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var customer models.Customer
+	json.Unmarshal(response.Body.Bytes(), &customer)
+	assert.EqualValues(t, 201, response.Code)
+	assert.EqualValues(t, "ada@example.com", customer.Email)
+	assert.EqualValues(t, "api-key:tests", customer.Owner)
+}
+
+func TestReturn409WhenRegisterCustomerWithRegisteredEmail(t *testing.T) {
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("PersistIfEmailAbsent", mock.AnythingOfType("models.Customer")).Return(false)
+	app.RegisterCustomerService = services.NewRegisterCustomer(&theCustomerRepositoryMock)
+
+	req, _ := http.NewRequest("POST", "/v2/customers", strings.NewReader(`{"email":"ada@example.com","name":"Ada"}`))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 409, response.Code)
+	assert.EqualValues(t, "customer-already-exists", problem.Code)
+}
+
+func TestReturn200WhenRetrieveCustomer(t *testing.T) {
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("SearchById", "a-customer").Return(models.Customer{Id: "a-customer", Owner: "api-key:tests"}, true)
+	app.RetrieveCustomerService = services.NewRetrieveCustomer(&theCustomerRepositoryMock)
+
+	req, _ := http.NewRequest("GET", "/customers/a-customer", nil)
+	response := executeRequest(req)
+
+	assert.EqualValues(t, 200, response.Code)
+}
+
+func TestReturn200WithTheBasketOfTheCustomerWhenAttachingGuestCheckout(t *testing.T) {
+	guest := models.Checkout{Id: "a-guest-checkout", Products: []string{"PEN"}, Status: models.CheckoutStatusOpen, Owner: "api-key:tests"}
+	basket := models.Checkout{Id: "a-basket", Products: []string{"MUG"}, Status: models.CheckoutStatusOpen, Owner: "api-key:tests", CustomerId: "a-customer"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", guest.Id).Return(guest, true)
+	theCheckoutRepositoryMock.On("Search", mock.AnythingOfType("persistence.CheckoutCriteria")).Return([]models.Checkout{basket})
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), mock.AnythingOfType("string")).Return(true)
+	theCheckoutRepositoryMock.On("DeleteIfStatus", guest, mock.AnythingOfType("string")).Return(true)
+	merged := basket
+	merged.Products = []string{"MUG", "PEN"}
+	theCheckoutRepositoryMock.On("SearchById", basket.Id).Return(merged, true)
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("SearchById", "a-customer").Return(models.Customer{Id: "a-customer", Owner: "api-key:tests"}, true)
+	theProductWithPromotionRepositoryMock := mocks.ProductWithPromotionRepositoryMock{}
+	theProductWithPromotionRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	theProductWithDiscountRepositoryMock := mocks.ProductWithDiscountRepositoryMock{}
+	theProductWithDiscountRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	app.AttachCheckoutToCustomerService = services.NewAttachCheckoutToCustomer(&theCheckoutRepositoryMock, &theCustomerRepositoryMock)
+	app.RetrieveCheckoutService = services.NewRetrieveCheckout(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts(), &theProductWithPromotionRepositoryMock, &theProductWithDiscountRepositoryMock)
+
+	req, _ := http.NewRequest("PUT", "/checkouts/"+guest.Id+"/customer", strings.NewReader(`{"customer-id":"a-customer"}`))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var checkoutDetail responses.CheckoutDetail
+	json.Unmarshal(response.Body.Bytes(), &checkoutDetail)
+	assert.EqualValues(t, 200, response.Code)
+	assert.EqualValues(t, "a-basket", checkoutDetail.Id)
+	assert.EqualValues(t, "a-customer", checkoutDetail.CustomerId)
+	assert.Len(t, checkoutDetail.Lines, 2)
+	theCheckoutRepositoryMock.AssertCalled(t, "DeleteIfStatus", guest, mock.Anything)
+}
+
+func TestReturn422WhenAttachingCheckoutToMissingCustomer(t *testing.T) {
+	checkout := ACheckout()
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("SearchById", "a-customer").Return(models.Customer{}, false)
+	app.AttachCheckoutToCustomerService = services.NewAttachCheckoutToCustomer(&theCheckoutRepositoryMock, &theCustomerRepositoryMock)
+
+	req, _ := http.NewRequest("PUT", "/checkouts/"+checkout.Id+"/customer", strings.NewReader(`{"customer-id":"a-customer"}`))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 422, response.Code)
+	assert.EqualValues(t, "customer-not-found", problem.Code)
+}
+
+func TestReturn200WithTheMergedCheckoutWhenMergingCheckouts(t *testing.T) {
+	target := models.Checkout{Id: "a-target", Products: []string{"MUG"}, Owner: "api-key:tests"}
+	source := models.Checkout{Id: "a-source", Products: []string{"PEN", "PEN"}, Owner: "api-key:tests"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", target.Id).Return(target, true)
+	theCheckoutRepositoryMock.On("SearchById", source.Id).Return(source, true)
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), mock.AnythingOfType("string")).Return(true)
+	theCheckoutRepositoryMock.On("DeleteIfStatus", source, mock.AnythingOfType("string")).Return(true)
+	theProductWithPromotionRepositoryMock := mocks.ProductWithPromotionRepositoryMock{}
+	theProductWithPromotionRepositoryMock.On("SearchById", "PEN").Return(models.Product{}, true)
+	theProductWithPromotionRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	theProductWithDiscountRepositoryMock := mocks.ProductWithDiscountRepositoryMock{}
+	theProductWithDiscountRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	app.MergeCheckoutsService = services.NewMergeCheckouts(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts(), &theProductWithPromotionRepositoryMock, &theProductWithDiscountRepositoryMock)
+
+	req, _ := http.NewRequest("POST", "/checkouts/"+target.Id+"/merge", strings.NewReader(`{"source-id":"a-source"}`))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var checkoutDetail responses.CheckoutDetail
+	json.Unmarshal(response.Body.Bytes(), &checkoutDetail)
+	assert.EqualValues(t, 200, response.Code)
+	assert.EqualValues(t, "a-target", checkoutDetail.Id)
+	assert.Len(t, checkoutDetail.Lines, 2)
+	assert.EqualValues(t, "17.50€", checkoutDetail.Subtotal)
+	assert.EqualValues(t, "12.50€", checkoutDetail.Amount)
+	theCheckoutRepositoryMock.AssertCalled(t, "DeleteIfStatus", source, mock.Anything)
+}
+
+func TestReturn400WhenMergingCheckoutIntoItself(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/checkouts/a-checkout/merge", strings.NewReader(`{"source-id":"a-checkout"}`))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 400, response.Code)
+	assert.EqualValues(t, map[string]interface{}{"source-id": "must not be the checkout merged into"}, problem.Details["fields"])
+}
+
+func TestReturn201WhenCreateSavedList(t *testing.T) {
+	theSavedListRepositoryMock := mocks.SavedListRepositoryMock{}
+	theSavedListRepositoryMock.On("Persist", mock.AnythingOfType("models.SavedList"))
+	app.CreateSavedListService = services.NewCreateSavedList(&theSavedListRepositoryMock, &mocks.CheckoutRepositoryMock{}, ProductRepositoryMockWithAllProducts())
+
+	req, _ := http.NewRequest("POST", "/saved-lists", strings.NewReader(`{"name":"Birthday","kind":"wishlist","lines":[{"product-code":"MUG","quantity":2}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var savedList models.SavedList
+	json.Unmarshal(response.Body.Bytes(), &savedList)
+	assert.EqualValues(t, 201, response.Code)
+	assert.NotEmpty(t, savedList.Id)
+	assert.EqualValues(t, []string{"MUG", "MUG"}, savedList.Products)
+	assert.EqualValues(t, "api-key:tests", savedList.Owner)
+}
+
+func TestReturn422WhenSavingCheckoutThatDoesNotExist(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", "a-checkout").Return(models.Checkout{}, false)
+	app.CreateSavedListService = services.NewCreateSavedList(&mocks.SavedListRepositoryMock{}, &theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts())
+
+	req, _ := http.NewRequest("POST", "/saved-lists", strings.NewReader(`{"name":"For later","kind":"cart","checkout-id":"a-checkout"}`))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 422, response.Code)
+	assert.EqualValues(t, "checkout-not-found", problem.Code)
+}
+
+func TestReturn200WhenListSavedLists(t *testing.T) {
+	savedList := models.SavedList{Id: "a-list", Name: "Birthday", Kind: models.SavedListKindWishlist, Products: []string{"MUG"}, Owner: "api-key:tests"}
+	theSavedListRepositoryMock := mocks.SavedListRepositoryMock{}
+	theSavedListRepositoryMock.On("SearchByOwner", "api-key:tests").Return([]models.SavedList{savedList})
+	app.ListSavedListsService = services.NewListSavedLists(&theSavedListRepositoryMock)
+
+	req, _ := http.NewRequest("GET", "/saved-lists", nil)
+	response := executeRequest(req)
+
+	var savedLists responses.SavedLists
+	json.Unmarshal(response.Body.Bytes(), &savedLists)
+	assert.EqualValues(t, 200, response.Code)
+	assert.Len(t, savedLists.SavedLists, 1)
+	assert.EqualValues(t, "a-list", savedLists.SavedLists[0].Id)
+}
+
+func TestReturn201WithUnavailableProductsWhenCheckingOutSavedList(t *testing.T) {
+	savedList := models.SavedList{Id: "a-list", Products: []string{"MUG", "RETIRED"}, Owner: "api-key:tests"}
+	theSavedListRepositoryMock := mocks.SavedListRepositoryMock{}
+	theSavedListRepositoryMock.On("SearchById", savedList.Id).Return(savedList, true)
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "MUG").Return(models.Product{Code: "MUG"}, true)
+	theProductRepositoryMock.On("SearchById", "RETIRED").Return(models.Product{}, false)
+	app.CheckoutSavedListService = services.NewCheckoutSavedList(&theSavedListRepositoryMock, &theProductRepositoryMock, services.NewCreateCheckout(&theCheckoutRepositoryMock, &theProductRepositoryMock))
+
+	req, _ := http.NewRequest("POST", "/saved-lists/"+savedList.Id+"/checkouts", nil)
+	response := executeRequest(req)
+
+	var savedListCheckout responses.SavedListCheckout
+	json.Unmarshal(response.Body.Bytes(), &savedListCheckout)
+	assert.EqualValues(t, 201, response.Code)
+	assert.NotEmpty(t, savedListCheckout.Checkout.Id)
+	assert.EqualValues(t, []string{"MUG"}, savedListCheckout.Checkout.Products)
+	assert.EqualValues(t, []string{"RETIRED"}, savedListCheckout.UnavailableProducts)
+}
+
+func TestReturn404WhenCheckingOutSavedListThatDoesNotExist(t *testing.T) {
+	theSavedListRepositoryMock := mocks.SavedListRepositoryMock{}
+	theSavedListRepositoryMock.On("SearchById", "a-list").Return(models.SavedList{}, false)
+	app.CheckoutSavedListService = services.NewCheckoutSavedList(&theSavedListRepositoryMock, ProductRepositoryMockWithAllProducts(), services.CreateCheckout{})
+
+	req, _ := http.NewRequest("POST", "/saved-lists/a-list/checkouts", nil)
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 404, response.Code)
+	assert.EqualValues(t, "saved-list-not-found", problem.Code)
+}
+
+func TestReturn201WhenAdminCreatesPriceList(t *testing.T) {
+	thePriceListRepositoryMock := mocks.PriceListRepositoryMock{}
+	thePriceListRepositoryMock.On("Persist", mock.AnythingOfType("models.PriceList"))
+	app.CreatePriceListService = services.NewCreatePriceList(&thePriceListRepositoryMock, &mocks.CustomerRepositoryMock{}, ProductRepositoryMockWithAllProducts())
+
+	req, _ := http.NewRequest("POST", "/price-lists", strings.NewReader(`{"name":"Wholesale","group":"wholesale","prices":{"MUG":600}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", testAdminAPIKey)
+	response := executeRequest(req)
+
+	var priceList models.PriceList
+	json.Unmarshal(response.Body.Bytes(), &priceList)
+	assert.EqualValues(t, 201, response.Code)
+	assert.NotEmpty(t, priceList.Id)
+	assert.EqualValues(t, "wholesale", priceList.Group)
+	assert.EqualValues(t, map[string]int{"MUG": 600}, priceList.Prices)
+}
+
+func TestReturn422WhenCreatingPriceListOfMissingCustomer(t *testing.T) {
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("SearchById", "a-customer").Return(models.Customer{}, false)
+	app.CreatePriceListService = services.NewCreatePriceList(&mocks.PriceListRepositoryMock{}, &theCustomerRepositoryMock, ProductRepositoryMockWithAllProducts())
+
+	req, _ := http.NewRequest("POST", "/price-lists", strings.NewReader(`{"name":"Acme","customer-id":"a-customer","prices":{"MUG":600}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", testAdminAPIKey)
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 422, response.Code)
+	assert.EqualValues(t, "customer-not-found", problem.Code)
+}
+
+func TestReturn200WhenMerchandiserListsPriceLists(t *testing.T) {
+	priceList := models.PriceList{Id: "a-price-list", Name: "Wholesale", Group: "wholesale", Prices: map[string]int{"MUG": 600}}
+	thePriceListRepositoryMock := mocks.PriceListRepositoryMock{}
+	thePriceListRepositoryMock.On("SearchAll").Return([]models.PriceList{priceList})
+	app.ListPriceListsService = services.NewListPriceLists(&thePriceListRepositoryMock)
+
+	req, _ := http.NewRequest("GET", "/price-lists", nil)
+	req.Header.Set("X-API-Key", testMerchandiserAPIKey)
+	response := executeRequest(req)
+
+	var priceLists responses.PriceLists
+	json.Unmarshal(response.Body.Bytes(), &priceLists)
+	assert.EqualValues(t, 200, response.Code)
+	assert.EqualValues(t, []models.PriceList{priceList}, priceLists.PriceLists)
+}
+
+func TestReturn200RetrievingCheckoutWithThePricesOfItsCustomer(t *testing.T) {
+	checkout := ACheckout()
+	checkout.CustomerId = "a-customer"
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("SearchById", "a-customer").Return(models.Customer{Id: "a-customer", Group: "wholesale"}, true)
+	thePriceListRepositoryMock := mocks.PriceListRepositoryMock{}
+	thePriceListRepositoryMock.On("SearchFor", "a-customer", "wholesale").Return([]models.PriceList{{Id: "a-price-list", Group: "wholesale", Prices: map[string]int{"MUG": 600}}})
+	theProductWithPromotionRepositoryMock := mocks.ProductWithPromotionRepositoryMock{}
+	theProductWithPromotionRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	theProductWithDiscountRepositoryMock := mocks.ProductWithDiscountRepositoryMock{}
+	theProductWithDiscountRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	app.RetrieveCheckoutService = services.NewRetrieveCheckout(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts(), &theProductWithPromotionRepositoryMock, &theProductWithDiscountRepositoryMock)
+	app.RetrieveCheckoutService.Pricing = services.NewPricing(&theCustomerRepositoryMock, &thePriceListRepositoryMock)
+
+	req, _ := http.NewRequest("GET", "/checkouts/"+checkout.Id, nil)
+	response := executeRequest(req)
+
+	var checkoutDetail responses.CheckoutDetail
+	json.Unmarshal(response.Body.Bytes(), &checkoutDetail)
+	assert.EqualValues(t, 200, response.Code)
+	assert.EqualValues(t, "6.00€", checkoutDetail.Lines[0].UnitPrice)
+	assert.EqualValues(t, "6.00€", checkoutDetail.Amount)
+}
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	walkAPIRoutes(app.Router, func(method string, pathTemplate string) {
+		_, documented := findAPIOperation(method, pathTemplate)
+
+		assert.True(t, documented, "%s %s has no OpenAPI operation", method, pathTemplate)
+	})
+}
+
+func TestReturn200RetrievingOpenAPIDocument(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	response := executeRequest(req)
+
+	var document struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas         map[string]map[string]interface{} `json:"schemas"`
+			SecuritySchemes map[string]interface{}            `json:"securitySchemes"`
+		} `json:"components"`
+	}
+	json.Unmarshal(response.Body.Bytes(), &document)
+	assert.EqualValues(t, 200, response.Code)
+	assert.EqualValues(t, "3.0.3", document.OpenAPI)
+	assert.Contains(t, document.Paths["/v2/checkouts"], "post")
+	assert.Contains(t, document.Paths["/checkouts/{id}"], "patch")
+	assert.EqualValues(t, "Create a checkout with a product", document.Paths["/v1/checkouts"]["post"]["summary"])
+	assert.Contains(t, document.Components.Schemas, "commands.CreateCheckout")
+	assert.EqualValues(t, []interface{}{"product-code"}, document.Components.Schemas["commands.Product"]["required"])
+	assert.Contains(t, document.Components.Schemas["responses.CheckoutError"]["properties"], "detail")
+	assert.Contains(t, document.Components.SecuritySchemes, "apiKey")
+	assert.Contains(t, document.Components.SecuritySchemes, "bearer")
+	assert.EqualValues(t, []interface{}{}, document.Paths["/healthz"]["get"]["security"])
+	assert.NotContains(t, document.Paths["/checkouts/{id}"]["delete"], "security")
+}
+
+func TestOpenAPIDocumentsTheLinesOfV2CheckoutCreationAsOptional(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	response := executeRequest(req)
+
+	var document struct {
+		Components struct {
+			Schemas map[string]map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	json.Unmarshal(response.Body.Bytes(), &document)
+	createCheckoutSchema := document.Components.Schemas["commands.CreateCheckout"]
+	assert.Contains(t, createCheckoutSchema["properties"], "lines")
+	assert.NotContains(t, createCheckoutSchema, "required")
+	assert.EqualValues(t, []interface{}{"product-code", "quantity"}, document.Components.Schemas["commands.Line"]["required"])
+}
+
+func TestReturn200WithThePointsDiscountWhenCompletingCheckoutOfACustomer(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", Products: []string{"MUG", "MUG"}, Status: models.CheckoutStatusOpen, Owner: "api-key:tests", CustomerId: "a-customer", RedeemedPoints: 500}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), models.CheckoutStatusOpen).Return(true)
+	theLoyaltyRepositoryMock := mocks.LoyaltyRepositoryMock{}
+	theLoyaltyRepositoryMock.On("Balance", "a-customer").Return(800)
+	theLoyaltyRepositoryMock.On("Redeem", mock.AnythingOfType("models.LoyaltyTransaction")).Return(nil)
+	theLoyaltyRepositoryMock.On("Record", mock.AnythingOfType("models.LoyaltyTransaction"))
+	theProductWithPromotionRepositoryMock := mocks.ProductWithPromotionRepositoryMock{}
+	theProductWithPromotionRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	theProductWithDiscountRepositoryMock := mocks.ProductWithDiscountRepositoryMock{}
+	theProductWithDiscountRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	app.CompleteCheckoutService = services.NewCompleteCheckout(&theCheckoutRepositoryMock, &theLoyaltyRepositoryMock, ProductRepositoryMockWithAllProducts(), &theProductWithPromotionRepositoryMock, &theProductWithDiscountRepositoryMock)
+
+	req, _ := http.NewRequest("POST", "/checkouts/"+checkout.Id+"/complete", nil)
+	response := executeRequest(req)
+
+	var checkoutDetail responses.CheckoutDetail
+	json.Unmarshal(response.Body.Bytes(), &checkoutDetail)
+	assert.EqualValues(t, 200, response.Code)
+	assert.EqualValues(t, models.CheckoutStatusCompleted, checkoutDetail.Status)
+	assert.EqualValues(t, "15.00€", checkoutDetail.Subtotal)
+	assert.EqualValues(t, "0.00€", checkoutDetail.Discount)
+	assert.EqualValues(t, 500, checkoutDetail.RedeemedPoints)
+	assert.EqualValues(t, "5.00€", checkoutDetail.PointsDiscount)
+	assert.EqualValues(t, "10.00€", checkoutDetail.Amount)
+	theLoyaltyRepositoryMock.AssertNumberOfCalls(t, "Redeem", 1)
+	theLoyaltyRepositoryMock.AssertNumberOfCalls(t, "Record", 1)
+}
+
+func TestReturn409WhenCompletingCheckoutAlreadyCompleted(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", Status: models.CheckoutStatusCompleted, Owner: "api-key:tests"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	app.CompleteCheckoutService = services.NewCompleteCheckout(&theCheckoutRepositoryMock, &mocks.LoyaltyRepositoryMock{}, ProductRepositoryMockWithAllProducts(), &mocks.ProductWithPromotionRepositoryMock{}, &mocks.ProductWithDiscountRepositoryMock{})
+
+	req, _ := http.NewRequest("POST", "/checkouts/"+checkout.Id+"/complete", nil)
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 409, response.Code)
+	assert.EqualValues(t, "checkout-not-open", problem.Code)
+}
+
+func TestReturn422WhenRedeemingMorePointsThanTheBalance(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", Status: models.CheckoutStatusOpen, Owner: "api-key:tests", CustomerId: "a-customer"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theLoyaltyRepositoryMock := mocks.LoyaltyRepositoryMock{}
+	theLoyaltyRepositoryMock.On("Balance", "a-customer").Return(100)
+	app.RedeemLoyaltyPointsService = services.NewRedeemLoyaltyPoints(&theCheckoutRepositoryMock, &theLoyaltyRepositoryMock)
+
+	req, _ := http.NewRequest("PUT", "/checkouts/"+checkout.Id+"/loyalty-points", strings.NewReader(`{"points":500}`))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+
+	var problem responses.Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	assert.EqualValues(t, 422, response.Code)
+	assert.EqualValues(t, "insufficient-loyalty-points", problem.Code)
+	theCheckoutRepositoryMock.AssertNotCalled(t, "PersistIfStatus", mock.Anything, mock.Anything)
+}
+
+func TestReturn200WhenMerchandiserRetrievesLoyaltyAccount(t *testing.T) {
+	transactions := []models.LoyaltyTransaction{{Id: "an-earn", CustomerId: "a-customer", CheckoutId: "a-checkout", Kind: models.LoyaltyTransactionEarn, Points: 15}}
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("SearchById", "a-customer").Return(models.Customer{Id: "a-customer", Owner: "api-key:tests"}, true)
+	theLoyaltyRepositoryMock := mocks.LoyaltyRepositoryMock{}
+	theLoyaltyRepositoryMock.On("Balance", "a-customer").Return(15)
+	theLoyaltyRepositoryMock.On("SearchByCustomer", "a-customer").Return(transactions)
+	app.RetrieveLoyaltyAccountService = services.NewRetrieveLoyaltyAccount(&theCustomerRepositoryMock, &theLoyaltyRepositoryMock)
+
+	req, _ := http.NewRequest("GET", "/customers/a-customer/loyalty", nil)
+	req.Header.Set("X-API-Key", testMerchandiserAPIKey)
+	response := executeRequest(req)
+
+	var account models.LoyaltyAccount
+	json.Unmarshal(response.Body.Bytes(), &account)
+	assert.EqualValues(t, 200, response.Code)
+	assert.EqualValues(t, 15, account.Balance)
+	assert.Len(t, account.Transactions, 1)
+	assert.EqualValues(t, "a-checkout", account.Transactions[0].CheckoutId)
 }
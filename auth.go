@@ -0,0 +1,68 @@
+package main
+
+import (
+	"lana/flagship-store/auth"
+	"lana/flagship-store/logging"
+	"lana/flagship-store/policy"
+	"net/http"
+	"strings"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+// authenticate serves the request with its principal, authenticated from
+// the API key or bearer token it sends, in the context. Requests without
+// valid credentials are rejected with 401, once their IP is within its rate
+// limit so credentials can not be guessed at will.
+func (app *App) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		principal, err := app.authenticator().Authenticate(credentialsOf(request))
+		if err != nil {
+			if !app.allowRequest(response, request) {
+				return
+			}
+			response.Header().Set("WWW-Authenticate", `Bearer realm="flagship-store"`)
+			writeProblem(response, request, err)
+			return
+		}
+
+		logging.Annotate(request.Context(), "principal", principal.Id())
+		next.ServeHTTP(response, request.WithContext(auth.NewContext(request.Context(), principal)))
+	})
+}
+
+func credentialsOf(request *http.Request) auth.Credentials {
+	credentials := auth.Credentials{APIKey: request.Header.Get(apiKeyHeader)}
+	scheme, token := splitAuthorization(request.Header.Get("Authorization"))
+	if strings.EqualFold(scheme, "Bearer") {
+		credentials.BearerToken = token
+	}
+	return credentials
+}
+
+func splitAuthorization(authorization string) (string, string) {
+	parts := strings.SplitN(authorization, " ", 2)
+	if len(parts) != 2 {
+		return "", ""
+	}
+	return parts[0], strings.TrimSpace(parts[1])
+}
+
+func (app *App) authenticator() *auth.Authenticator {
+	if app.Authenticator == nil {
+		return &auth.Authenticator{}
+	}
+	return app.Authenticator
+}
+
+// authorize guards the route with the policy: requests of principals whose
+// role may not invoke the command are rejected with 403 before being read.
+func (app *App) authorize(command policy.Command, handler http.HandlerFunc) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		if err := policy.Authorize(request.Context(), command); err != nil {
+			writeProblem(response, request, err)
+			return
+		}
+		handler(response, request)
+	}
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"lana/flagship-store/services/errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Credentials are sent by the callers with every request: an API key or a
+// bearer token.
+type Credentials struct {
+	APIKey      string
+	BearerToken string
+}
+
+// APIKey is a key accepted from a server, granting it the role.
+type APIKey struct {
+	Key  string
+	Role Role
+}
+
+// Claims are the claims of the bearer tokens: the registered claims and the
+// role of the shopper, a shopper when not given.
+type Claims struct {
+	jwt.StandardClaims
+	Role string `json:"role,omitempty"`
+}
+
+// Authenticator authenticates API keys by name and JWTs signed with HS256.
+// The zero value rejects every request.
+type Authenticator struct {
+	apiKeys   map[[sha256.Size]byte]Principal
+	jwtSecret []byte
+	jwtIssuer string
+}
+
+// NewAuthenticator accepts the API keys, by name, and the JWTs signed with
+// the secret, issued by the issuer when given. Bearer tokens are rejected
+// without secret. Only digests of the keys are kept in memory.
+func NewAuthenticator(apiKeys map[string]APIKey, jwtSecret []byte, jwtIssuer string) *Authenticator {
+	principals := make(map[[sha256.Size]byte]Principal)
+	for name, apiKey := range apiKeys {
+		principals[sha256.Sum256([]byte(apiKey.Key))] = Principal{Subject: name, Method: MethodAPIKey, Role: apiKey.Role}
+	}
+	return &Authenticator{principals, jwtSecret, jwtIssuer}
+}
+
+func (authenticator *Authenticator) Authenticate(credentials Credentials) (Principal, error) {
+	switch {
+	case credentials.APIKey != "":
+		return authenticator.authenticateAPIKey(credentials.APIKey)
+	case credentials.BearerToken != "":
+		return authenticator.authenticateToken(credentials.BearerToken)
+	}
+	return Principal{}, errors.NewUnauthorizedError("Missing credentials, send an API key in X-API-Key or a bearer token in Authorization")
+}
+
+func (authenticator *Authenticator) authenticateAPIKey(apiKey string) (Principal, error) {
+	principal, found := authenticator.apiKeys[sha256.Sum256([]byte(apiKey))]
+	if !found {
+		return Principal{}, errors.NewUnauthorizedError("Invalid API key")
+	}
+	return principal, nil
+}
+
+func (authenticator *Authenticator) authenticateToken(token string) (Principal, error) {
+	if len(authenticator.jwtSecret) == 0 {
+		return Principal{}, errors.NewUnauthorizedError("Bearer tokens are not accepted")
+	}
+
+	var claims Claims
+	parser := jwt.Parser{ValidMethods: []string{jwt.SigningMethodHS256.Alg()}}
+	_, err := parser.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return authenticator.jwtSecret, nil
+	})
+	if err != nil {
+		return Principal{}, errors.Wrap(err, errors.CodeUnauthorized, "Invalid bearer token")
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return Principal{}, errors.NewUnauthorizedError("Bearer token without expiration")
+	}
+	if authenticator.jwtIssuer != "" && !claims.VerifyIssuer(authenticator.jwtIssuer, true) {
+		return Principal{}, errors.NewUnauthorizedError("Bearer token issued by " + claims.Issuer + " is not accepted")
+	}
+	if claims.Subject == "" {
+		return Principal{}, errors.NewUnauthorizedError("Bearer token without subject")
+	}
+	role, known := RoleShopper, true
+	if claims.Role != "" {
+		role, known = ParseRole(claims.Role)
+	}
+	if !known {
+		return Principal{}, errors.NewUnauthorizedError("Bearer token with unknown role " + claims.Role)
+	}
+	return Principal{Subject: claims.Subject, Method: MethodJWT, Role: role}, nil
+}
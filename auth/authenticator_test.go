@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"lana/flagship-store/services/errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+var secret = []byte("a-secret")
+
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, claims Claims) string {
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func validClaims() Claims {
+	return Claims{StandardClaims: jwt.StandardClaims{Subject: "a-shopper", Issuer: "lana", ExpiresAt: time.Now().Add(time.Hour).Unix()}}
+}
+
+func TestAuthenticateAPIKeyByName(t *testing.T) {
+	authenticator := NewAuthenticator(map[string]APIKey{"billing": {Key: "a-key", Role: RoleShopper}}, nil, "")
+
+	principal, err := authenticator.Authenticate(Credentials{APIKey: "a-key"})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, Principal{Subject: "billing", Method: MethodAPIKey, Role: RoleShopper}, principal)
+	assert.EqualValues(t, "api-key:billing", principal.Id())
+}
+
+func TestAuthenticateRejectsUnknownAPIKey(t *testing.T) {
+	authenticator := NewAuthenticator(map[string]APIKey{"billing": {Key: "a-key", Role: RoleShopper}}, nil, "")
+
+	_, err := authenticator.Authenticate(Credentials{APIKey: "another-key"})
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrUnauthorized))
+}
+
+func TestAuthenticateRejectsMissingCredentials(t *testing.T) {
+	authenticator := NewAuthenticator(map[string]APIKey{"billing": {Key: "a-key", Role: RoleShopper}}, secret, "")
+
+	_, err := authenticator.Authenticate(Credentials{})
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrUnauthorized))
+}
+
+func TestZeroAuthenticatorRejectsEveryRequest(t *testing.T) {
+	_, err := (&Authenticator{}).Authenticate(Credentials{BearerToken: signToken(t, jwt.SigningMethodHS256, []byte{}, validClaims())})
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrUnauthorized))
+}
+
+func TestAuthenticateTokenBySubject(t *testing.T) {
+	authenticator := NewAuthenticator(nil, secret, "lana")
+
+	principal, err := authenticator.Authenticate(Credentials{BearerToken: signToken(t, jwt.SigningMethodHS256, secret, validClaims())})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, Principal{Subject: "a-shopper", Method: MethodJWT, Role: RoleShopper}, principal)
+}
+
+func TestAuthenticateTokenWithRole(t *testing.T) {
+	authenticator := NewAuthenticator(nil, secret, "")
+	claims := validClaims()
+	claims.Role = "merchandiser"
+
+	principal, err := authenticator.Authenticate(Credentials{BearerToken: signToken(t, jwt.SigningMethodHS256, secret, claims)})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, RoleMerchandiser, principal.Role)
+}
+
+func TestAuthenticateRejectsInvalidTokens(t *testing.T) {
+	authenticator := NewAuthenticator(nil, secret, "lana")
+	expired, withoutExpiration, withoutSubject, otherIssuer, unknownRole := validClaims(), validClaims(), validClaims(), validClaims(), validClaims()
+	expired.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+	withoutExpiration.ExpiresAt = 0
+	withoutSubject.Subject = ""
+	otherIssuer.Issuer = "another-issuer"
+	unknownRole.Role = "root"
+
+	tokens := map[string]string{
+		"signed with another secret": signToken(t, jwt.SigningMethodHS256, []byte("another-secret"), validClaims()),
+		"signed with another method": signToken(t, jwt.SigningMethodHS512, secret, validClaims()),
+		"not signed":                 signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, validClaims()),
+		"expired":                    signToken(t, jwt.SigningMethodHS256, secret, expired),
+		"without expiration":         signToken(t, jwt.SigningMethodHS256, secret, withoutExpiration),
+		"without subject":            signToken(t, jwt.SigningMethodHS256, secret, withoutSubject),
+		"issued by another issuer":   signToken(t, jwt.SigningMethodHS256, secret, otherIssuer),
+		"with unknown role":          signToken(t, jwt.SigningMethodHS256, secret, unknownRole),
+		"malformed":                  "not-a-token",
+	}
+	for name, token := range tokens {
+		_, err := authenticator.Authenticate(Credentials{BearerToken: token})
+
+		assert.EqualValues(t, true, errors.Is(err, errors.ErrUnauthorized), name)
+	}
+}
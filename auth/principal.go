@@ -0,0 +1,40 @@
+// Package auth authenticates the callers of the store, servers with API
+// keys and shoppers with signed JWTs, and carries them in the context of
+// their requests.
+package auth
+
+import "context"
+
+const (
+	MethodAPIKey = "api-key"
+	MethodJWT    = "jwt"
+)
+
+// Principal is an authenticated caller: a server named by its API key or a
+// shopper identified by the subject of its token, with the role granted.
+type Principal struct {
+	Subject string
+	Method  string
+	Role    Role
+}
+
+// Id identifies the principal as owner of checkouts, telling apart a server
+// and a shopper with the same name.
+func (principal Principal) Id() string {
+	return principal.Method + ":" + principal.Subject
+}
+
+type contextKey int
+
+const principalKey contextKey = iota
+
+func NewContext(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// FromContext returns the principal of the request being served, if it was
+// authenticated.
+func FromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey).(Principal)
+	return principal, ok
+}
@@ -0,0 +1,23 @@
+package auth
+
+// Role grants a principal the commands mapped to it by the policy.
+type Role string
+
+const (
+	RoleShopper      Role = "shopper"
+	RoleMerchandiser Role = "merchandiser"
+	RoleAdmin        Role = "admin"
+)
+
+// Roles are every role, from the least to the most privileged.
+var Roles = []Role{RoleShopper, RoleMerchandiser, RoleAdmin}
+
+// ParseRole returns the role named name, or false when there is none.
+func ParseRole(name string) (Role, bool) {
+	for _, role := range Roles {
+		if string(role) == name {
+			return role, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"lana/flagship-store/services/errors"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const maxBodyBytes = 64 * 1024
+
+// decodeBody strictly decodes the JSON body of the request into command:
+// the body must be a single JSON object, declared as application/json, not
+// larger than maxBodyBytes and without fields unknown to the command.
+func decodeBody(response http.ResponseWriter, request *http.Request, command interface{}) error {
+	contentType := request.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType != "application/json" {
+		return errors.NewUnsupportedMediaTypeError(contentType)
+	}
+
+	body := &limitedBody{body: http.MaxBytesReader(response, request.Body, maxBodyBytes)}
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(command); err != nil {
+		if body.exceeded {
+			return errors.NewPayloadTooLargeError(maxBodyBytes)
+		}
+		return newDecodingError(err)
+	}
+	if err := decoder.Decode(&struct{}{}); err != io.EOF {
+		if body.exceeded {
+			return errors.NewPayloadTooLargeError(maxBodyBytes)
+		}
+		return errors.NewMalformedBodyError("body must contain a single JSON object", nil)
+	}
+	return nil
+}
+
+// readBody reads the whole body of the request, not larger than
+// maxBodyBytes, e.g. to fingerprint it before it is decoded.
+func readBody(response http.ResponseWriter, request *http.Request) ([]byte, error) {
+	body := &limitedBody{body: http.MaxBytesReader(response, request.Body, maxBodyBytes)}
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		if body.exceeded {
+			return nil, errors.NewPayloadTooLargeError(maxBodyBytes)
+		}
+		return nil, newDecodingError(err)
+	}
+	return content, nil
+}
+
+// limitedBody counts the bytes read from a body limited to maxBodyBytes,
+// telling the limit exceeded apart from other read errors without
+// depending on their message.
+type limitedBody struct {
+	body     io.Reader
+	read     int64
+	exceeded bool
+}
+
+func (body *limitedBody) Read(p []byte) (int, error) {
+	n, err := body.body.Read(p)
+	body.read += int64(n)
+	if err != nil && err != io.EOF && body.read >= maxBodyBytes {
+		body.exceeded = true
+	}
+	return n, err
+}
+
+func newDecodingError(err error) error {
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+	switch {
+	case err == io.EOF:
+		return errors.NewMalformedBodyError("body must not be empty", nil)
+	case err == io.ErrUnexpectedEOF:
+		return errors.NewMalformedBodyError("body is truncated", nil)
+	case errors.As(err, &syntaxError):
+		return errors.NewMalformedBodyError(syntaxError.Error(), map[string]interface{}{"offset": syntaxError.Offset})
+	case errors.As(err, &typeError):
+		return errors.NewValidationError(map[string]string{typeError.Field: "must be a " + typeError.Type.String()})
+	// encoding/json has no type for unknown fields, only its message.
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return errors.NewValidationError(map[string]string{field: "is not allowed"})
+	}
+	return errors.NewMalformedBodyError(err.Error(), nil)
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"lana/flagship-store/auth"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const envPrefix = "FLAGSHIP_STORE_"
+
+// Config configures the servers of the store. Every setting is read from a
+// flag or, when the flag is not given, from its FLAGSHIP_STORE_ environment
+// variable, e.g. -read-timeout or FLAGSHIP_STORE_READ_TIMEOUT.
+type Config struct {
+	Addr              string
+	GRPCAddr          string
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownDelay     time.Duration
+	ShutdownTimeout   time.Duration
+	MaxHeaderBytes    int
+	TLSCertFile       string
+	TLSKeyFile        string
+	TraceExporter     string
+	OTLPEndpoint      string
+	OTLPInsecure      bool
+	APIKeys           APIKeys
+	JWTSecret         string
+	JWTIssuer         string
+	RateLimit         float64
+	RateBurst         int
+	MaxOpenCheckouts  int
+	IdempotencyWindow time.Duration
+}
+
+func defaultConfig() Config {
+	return Config{
+		Addr:              ":3080",
+		GRPCAddr:          ":3081",
+		ReadTimeout:       5 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		ShutdownTimeout:   15 * time.Second,
+		MaxHeaderBytes:    1 << 20,
+		OTLPEndpoint:      "localhost:4317",
+		RateLimit:         10,
+		RateBurst:         20,
+		MaxOpenCheckouts:  50,
+		IdempotencyWindow: 24 * time.Hour,
+	}
+}
+
+func loadConfig(args []string, getenv func(key string) string) (Config, error) {
+	config := defaultConfig()
+	environment := configEnvironment{getenv: getenv}
+	environment.string("ADDR", &config.Addr)
+	environment.string("GRPC_ADDR", &config.GRPCAddr)
+	environment.duration("READ_TIMEOUT", &config.ReadTimeout)
+	environment.duration("WRITE_TIMEOUT", &config.WriteTimeout)
+	environment.duration("IDLE_TIMEOUT", &config.IdleTimeout)
+	environment.duration("SHUTDOWN_DELAY", &config.ShutdownDelay)
+	environment.duration("SHUTDOWN_TIMEOUT", &config.ShutdownTimeout)
+	environment.int("MAX_HEADER_BYTES", &config.MaxHeaderBytes)
+	environment.string("TLS_CERT", &config.TLSCertFile)
+	environment.string("TLS_KEY", &config.TLSKeyFile)
+	environment.string("TRACE_EXPORTER", &config.TraceExporter)
+	environment.string("OTLP_ENDPOINT", &config.OTLPEndpoint)
+	environment.bool("OTLP_INSECURE", &config.OTLPInsecure)
+	environment.value("API_KEYS", &config.APIKeys, "must be a list of name=key or name:role=key, e.g. billing=s3cr3t,crm:merchandiser=t0k3n")
+	environment.string("JWT_SECRET", &config.JWTSecret)
+	environment.string("JWT_ISSUER", &config.JWTIssuer)
+	environment.float("RATE_LIMIT", &config.RateLimit)
+	environment.int("RATE_BURST", &config.RateBurst)
+	environment.int("MAX_OPEN_CHECKOUTS", &config.MaxOpenCheckouts)
+	environment.duration("IDEMPOTENCY_WINDOW", &config.IdempotencyWindow)
+	if environment.err != nil {
+		return Config{}, environment.err
+	}
+
+	flags := flag.NewFlagSet("flagship-store", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	flags.StringVar(&config.Addr, "addr", config.Addr, "HTTP address to listen on")
+	flags.StringVar(&config.GRPCAddr, "grpc-addr", config.GRPCAddr, "gRPC address to listen on")
+	flags.DurationVar(&config.ReadTimeout, "read-timeout", config.ReadTimeout, "maximum duration reading a request")
+	flags.DurationVar(&config.WriteTimeout, "write-timeout", config.WriteTimeout, "maximum duration writing a response")
+	flags.DurationVar(&config.IdleTimeout, "idle-timeout", config.IdleTimeout, "maximum duration of an idle keep-alive connection")
+	flags.DurationVar(&config.ShutdownDelay, "shutdown-delay", config.ShutdownDelay, "duration reported as not ready before shutting down")
+	flags.DurationVar(&config.ShutdownTimeout, "shutdown-timeout", config.ShutdownTimeout, "maximum duration draining in-flight requests on shutdown")
+	flags.IntVar(&config.MaxHeaderBytes, "max-header-bytes", config.MaxHeaderBytes, "maximum size of the request headers")
+	flags.StringVar(&config.TLSCertFile, "tls-cert", config.TLSCertFile, "TLS certificate file, serves HTTPS with -tls-key")
+	flags.StringVar(&config.TLSKeyFile, "tls-key", config.TLSKeyFile, "TLS key file, serves HTTPS with -tls-cert")
+	flags.StringVar(&config.TraceExporter, "trace-exporter", config.TraceExporter, "exporter of the trace spans, stdout or otlp")
+	flags.StringVar(&config.OTLPEndpoint, "otlp-endpoint", config.OTLPEndpoint, "address of the OTLP gRPC collector")
+	flags.BoolVar(&config.OTLPInsecure, "otlp-insecure", config.OTLPInsecure, "export to the OTLP collector without TLS")
+	flags.Var(&config.APIKeys, "api-keys", "API keys accepted in X-API-Key, as a list of name=key or name:role=key")
+	flags.StringVar(&config.JWTSecret, "jwt-secret", config.JWTSecret, "secret signing the bearer tokens with HS256")
+	flags.StringVar(&config.JWTIssuer, "jwt-issuer", config.JWTIssuer, "issuer required in the bearer tokens")
+	flags.Float64Var(&config.RateLimit, "rate-limit", config.RateLimit, "requests per second of every client, 0 disables the limit")
+	flags.IntVar(&config.RateBurst, "rate-burst", config.RateBurst, "requests of a client in a burst over the rate limit")
+	flags.IntVar(&config.MaxOpenCheckouts, "max-open-checkouts", config.MaxOpenCheckouts, "open checkouts of every owner, 0 disables the limit")
+	flags.DurationVar(&config.IdempotencyWindow, "idempotency-window", config.IdempotencyWindow, "duration the responses of the idempotency keys are replayed")
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if (config.TLSCertFile == "") != (config.TLSKeyFile == "") {
+		return Config{}, errors.New("tls-cert and tls-key must be given together")
+	}
+	if config.RateLimit < 0 || (config.RateLimit > 0 && config.RateBurst < 1) {
+		return Config{}, errors.New("rate-limit must not be negative and rate-burst must be at least 1")
+	}
+	return config, nil
+}
+
+func (config Config) usesTLS() bool {
+	return config.TLSCertFile != ""
+}
+
+// APIKeys are the API keys by name, given as a comma separated list of
+// name=key or name:role=key. Keys without role grant the shopper role.
+type APIKeys map[string]auth.APIKey
+
+func (apiKeys *APIKeys) Set(value string) error {
+	parsed := APIKeys{}
+	for _, entry := range strings.Split(value, ",") {
+		nameAndKey := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(nameAndKey) != 2 || nameAndKey[0] == "" || nameAndKey[1] == "" {
+			return errors.New("API keys must be given as name=key")
+		}
+		nameAndRole := strings.SplitN(nameAndKey[0], ":", 2)
+		role, known := auth.RoleShopper, true
+		if len(nameAndRole) == 2 {
+			role, known = auth.ParseRole(nameAndRole[1])
+		}
+		if !known {
+			return errors.New("API key " + nameAndRole[0] + " has an unknown role")
+		}
+		parsed[nameAndRole[0]] = auth.APIKey{Key: nameAndKey[1], Role: role}
+	}
+	*apiKeys = parsed
+	return nil
+}
+
+// String lists the names of the keys, so the keys are never printed.
+func (apiKeys APIKeys) String() string {
+	names := make([]string, 0, len(apiKeys))
+	for name := range apiKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// configEnvironment reads settings from the environment, keeping the first
+// variable that can not be parsed.
+type configEnvironment struct {
+	getenv func(key string) string
+	err    error
+}
+
+func (environment *configEnvironment) string(name string, value *string) {
+	if variable := environment.getenv(envPrefix + name); variable != "" {
+		*value = variable
+	}
+}
+
+func (environment *configEnvironment) duration(name string, value *time.Duration) {
+	variable := environment.getenv(envPrefix + name)
+	if variable == "" {
+		return
+	}
+	duration, err := time.ParseDuration(variable)
+	if err != nil {
+		environment.fail(name, "must be a duration, e.g. 5s")
+		return
+	}
+	*value = duration
+}
+
+func (environment *configEnvironment) int(name string, value *int) {
+	variable := environment.getenv(envPrefix + name)
+	if variable == "" {
+		return
+	}
+	number, err := strconv.Atoi(variable)
+	if err != nil {
+		environment.fail(name, "must be an integer")
+		return
+	}
+	*value = number
+}
+
+func (environment *configEnvironment) float(name string, value *float64) {
+	variable := environment.getenv(envPrefix + name)
+	if variable == "" {
+		return
+	}
+	number, err := strconv.ParseFloat(variable, 64)
+	if err != nil {
+		environment.fail(name, "must be a number")
+		return
+	}
+	*value = number
+}
+
+func (environment *configEnvironment) bool(name string, value *bool) {
+	variable := environment.getenv(envPrefix + name)
+	if variable == "" {
+		return
+	}
+	boolean, err := strconv.ParseBool(variable)
+	if err != nil {
+		environment.fail(name, "must be a boolean")
+		return
+	}
+	*value = boolean
+}
+
+func (environment *configEnvironment) value(name string, value flag.Value, reason string) {
+	variable := environment.getenv(envPrefix + name)
+	if variable == "" {
+		return
+	}
+	if err := value.Set(variable); err != nil {
+		environment.fail(name, reason)
+	}
+}
+
+func (environment *configEnvironment) fail(name string, reason string) {
+	if environment.err == nil {
+		environment.err = errors.New(envPrefix + name + " " + reason)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"lana/flagship-store/auth"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func environment(variables map[string]string) func(key string) string {
+	return func(key string) string {
+		return variables[key]
+	}
+}
+
+func TestLoadConfigWithDefaults(t *testing.T) {
+	config, err := loadConfig([]string{}, environment(nil))
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, defaultConfig(), config)
+	assert.EqualValues(t, ":3080", config.Addr)
+	assert.False(t, config.usesTLS())
+}
+
+func TestLoadConfigFromEnvironment(t *testing.T) {
+	config, err := loadConfig([]string{}, environment(map[string]string{
+		"FLAGSHIP_STORE_ADDR":               ":8080",
+		"FLAGSHIP_STORE_READ_TIMEOUT":       "2s",
+		"FLAGSHIP_STORE_MAX_HEADER_BYTES":   "4096",
+		"FLAGSHIP_STORE_IDEMPOTENCY_WINDOW": "1h",
+	}))
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, ":8080", config.Addr)
+	assert.EqualValues(t, 2*time.Second, config.ReadTimeout)
+	assert.EqualValues(t, 4096, config.MaxHeaderBytes)
+	assert.EqualValues(t, time.Hour, config.IdempotencyWindow)
+}
+
+func TestLoadConfigFlagsOverrideEnvironment(t *testing.T) {
+	config, err := loadConfig([]string{"-addr", ":9090", "-tls-cert", "cert.pem", "-tls-key", "key.pem"}, environment(map[string]string{
+		"FLAGSHIP_STORE_ADDR": ":8080",
+	}))
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, ":9090", config.Addr)
+	assert.True(t, config.usesTLS())
+}
+
+func TestLoadConfigOfTheTraceExporter(t *testing.T) {
+	config, err := loadConfig([]string{"-trace-exporter", "otlp"}, environment(map[string]string{
+		"FLAGSHIP_STORE_OTLP_ENDPOINT": "collector:4317",
+		"FLAGSHIP_STORE_OTLP_INSECURE": "true",
+	}))
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "otlp", config.TraceExporter)
+	assert.EqualValues(t, "collector:4317", config.OTLPEndpoint)
+	assert.True(t, config.OTLPInsecure)
+}
+
+func TestLoadConfigFailsWhenEnvironmentVariableIsNotValid(t *testing.T) {
+	_, err := loadConfig([]string{}, environment(map[string]string{
+		"FLAGSHIP_STORE_WRITE_TIMEOUT": "ten",
+	}))
+
+	assert.EqualError(t, err, "FLAGSHIP_STORE_WRITE_TIMEOUT must be a duration, e.g. 5s")
+}
+
+func TestLoadConfigOfTheAPIKeys(t *testing.T) {
+	config, err := loadConfig([]string{"-jwt-issuer", "lana"}, environment(map[string]string{
+		"FLAGSHIP_STORE_API_KEYS":   "billing=a-key, crm:merchandiser=another-key",
+		"FLAGSHIP_STORE_JWT_SECRET": "a-secret",
+	}))
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, APIKeys{
+		"billing": {Key: "a-key", Role: auth.RoleShopper},
+		"crm":     {Key: "another-key", Role: auth.RoleMerchandiser},
+	}, config.APIKeys)
+	assert.EqualValues(t, "billing,crm", config.APIKeys.String())
+	assert.EqualValues(t, "a-secret", config.JWTSecret)
+	assert.EqualValues(t, "lana", config.JWTIssuer)
+}
+
+func TestLoadConfigFailsWhenAPIKeyHasNoName(t *testing.T) {
+	_, err := loadConfig([]string{}, environment(map[string]string{
+		"FLAGSHIP_STORE_API_KEYS": "a-key",
+	}))
+
+	assert.EqualError(t, err, "FLAGSHIP_STORE_API_KEYS must be a list of name=key or name:role=key, e.g. billing=s3cr3t,crm:merchandiser=t0k3n")
+}
+
+func TestLoadConfigFailsWhenAPIKeyRoleIsUnknown(t *testing.T) {
+	_, err := loadConfig([]string{"-api-keys", "billing:root=a-key"}, environment(nil))
+
+	assert.Contains(t, err.Error(), "API key billing has an unknown role")
+}
+
+func TestLoadConfigOfTheRateLimits(t *testing.T) {
+	config, err := loadConfig([]string{"-rate-burst", "5"}, environment(map[string]string{
+		"FLAGSHIP_STORE_RATE_LIMIT":         "0.5",
+		"FLAGSHIP_STORE_MAX_OPEN_CHECKOUTS": "3",
+	}))
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 0.5, config.RateLimit)
+	assert.EqualValues(t, 5, config.RateBurst)
+	assert.EqualValues(t, 3, config.MaxOpenCheckouts)
+}
+
+func TestLoadConfigFailsWhenRateBurstIsNotPositive(t *testing.T) {
+	_, err := loadConfig([]string{"-rate-burst", "0"}, environment(nil))
+
+	assert.EqualError(t, err, "rate-limit must not be negative and rate-burst must be at least 1")
+}
+
+func TestLoadConfigFailsWhenTLSKeyIsMissing(t *testing.T) {
+	_, err := loadConfig([]string{"-tls-cert", "cert.pem"}, environment(nil))
+
+	assert.EqualError(t, err, "tls-cert and tls-key must be given together")
+}
+
+func TestLoadConfigFailsWhenFlagIsUnknown(t *testing.T) {
+	_, err := loadConfig([]string{"-port", "3080"}, environment(nil))
+
+	assert.NotNil(t, err)
+}
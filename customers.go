@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"lana/flagship-store/logging"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func (app *App) initializeCustomerRoutes(router *mux.Router) {
+	router.HandleFunc("/customers", app.authorize(policy.RegisterCustomer, app.registerCustomer)).Methods("POST")
+	router.HandleFunc("/customers/{id}", app.authorize(policy.RetrieveCustomer, app.retrieveCustomer)).Methods("GET")
+	router.HandleFunc("/checkouts/{id}/customer", app.authorize(policy.AttachCheckoutToCustomer, app.attachCheckoutToCustomer)).Methods("PUT")
+}
+
+func (app *App) registerCustomer(response http.ResponseWriter, request *http.Request) {
+	var registerCommand commands.RegisterCustomer
+	if err := decodeBody(response, request, &registerCommand); err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	customer, err := app.RegisterCustomerService.Do(request.Context(), registerCommand)
+	if err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	logging.Annotate(request.Context(), "customer-id", customer.Id)
+	response.WriteHeader(http.StatusCreated)
+	json.NewEncoder(response).Encode(customer)
+}
+
+func (app *App) retrieveCustomer(response http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	id := vars["id"]
+
+	customer, err := app.RetrieveCustomerService.Do(request.Context(), id)
+	if err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(customer)
+}
+
+// attachCheckoutToCustomer answers with the basket of the customer, which
+// is another checkout when the checkout was merged into it.
+func (app *App) attachCheckoutToCustomer(response http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	id := vars["id"]
+
+	var attachCommand commands.AttachCustomer
+	if err := decodeBody(response, request, &attachCommand); err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	basket, err := app.AttachCheckoutToCustomerService.Do(request.Context(), attachCommand, id)
+	if err != nil {
+		writeProblem(response, request, err, problemStatus{errors.CodeCustomerNotFound, http.StatusUnprocessableEntity})
+		return
+	}
+
+	checkoutSummary, err := app.RetrieveCheckoutService.Do(request.Context(), basket.Id)
+	if err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(newCheckoutDetail(checkoutSummary))
+}
@@ -0,0 +1,38 @@
+package graph
+
+import (
+	"context"
+	"lana/flagship-store/logging"
+	"lana/flagship-store/services/errors"
+)
+
+// serviceError exposes the code and details of a service error as the
+// extensions of the GraphQL error.
+type serviceError struct {
+	code    errors.Code
+	message string
+	details map[string]interface{}
+}
+
+func (e *serviceError) Error() string {
+	return e.message
+}
+
+func (e *serviceError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": string(e.code)}
+	if len(e.details) > 0 {
+		extensions["details"] = e.details
+	}
+	return extensions
+}
+
+// resolveError maps a service error to a GraphQL error. Errors unknown to
+// the services are logged and reported as internal without their message.
+func resolveError(ctx context.Context, err error) error {
+	var cause *errors.Error
+	if !errors.As(err, &cause) || cause.Code == errors.CodeInternal {
+		logging.FromContext(ctx).Error("Internal error resolving GraphQL request", err, nil)
+		return &serviceError{code: errors.CodeInternal, message: "internal error"}
+	}
+	return &serviceError{code: cause.Code, message: cause.Message, details: cause.Details}
+}
@@ -0,0 +1,87 @@
+package graph
+
+import (
+	"fmt"
+	"lana/flagship-store/services/errors"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+const (
+	// MaxDepth is the deepest a query can nest its fields, deep enough for
+	// the introspection query.
+	MaxDepth = 15
+	// MaxFields is the most fields a query can select, counting the fields
+	// of a fragment every time it is spread.
+	MaxFields = 300
+)
+
+// CheckLimits rejects the queries nesting fields deeper than MaxDepth or
+// selecting more than MaxFields, before they are executed. A query that
+// does not parse is left to the execution, which reports its errors.
+func CheckLimits(request Request) error {
+	document, err := parser.Parse(parser.ParseParams{Source: request.Query})
+	if err != nil {
+		return nil
+	}
+
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		size := querySize{fragments: fragments, spreading: map[string]bool{}}
+		size.measure(operation.SelectionSet, 1)
+		if size.depth > MaxDepth {
+			return errors.NewValidationError(map[string]string{"query": fmt.Sprintf("must not nest fields deeper than %d", MaxDepth)})
+		}
+		if size.fields > MaxFields {
+			return errors.NewValidationError(map[string]string{"query": fmt.Sprintf("must not select more than %d fields", MaxFields)})
+		}
+	}
+	return nil
+}
+
+// querySize measures the depth and the fields of an operation. It stops
+// as soon as a limit is exceeded, so fragments spreading each other many
+// times can not make it measure for long.
+type querySize struct {
+	fragments map[string]*ast.FragmentDefinition
+	spreading map[string]bool
+	depth     int
+	fields    int
+}
+
+func (size *querySize) measure(selectionSet *ast.SelectionSet, depth int) {
+	if selectionSet == nil || size.depth > MaxDepth || size.fields > MaxFields {
+		return
+	}
+	for _, selection := range selectionSet.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			size.fields++
+			if depth > size.depth {
+				size.depth = depth
+			}
+			size.measure(selection.SelectionSet, depth+1)
+		case *ast.InlineFragment:
+			size.measure(selection.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			// A fragment spreading itself is rejected by the execution.
+			fragment, exists := size.fragments[selection.Name.Value]
+			if !exists || size.spreading[selection.Name.Value] {
+				continue
+			}
+			size.spreading[selection.Name.Value] = true
+			size.measure(fragment.SelectionSet, depth)
+			size.spreading[selection.Name.Value] = false
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package graph
+
+import (
+	"lana/flagship-store/services/errors"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckLimitsAllowsTheQueriesWithinTheLimits(t *testing.T) {
+	query := `query($id: ID!) { checkout(id: $id) { ...summary } } fragment summary on Checkout { id lines { product { name } amount } amount }`
+
+	assert.Nil(t, CheckLimits(Request{Query: query}))
+	assert.Nil(t, CheckLimits(Request{Query: testutil.IntrospectionQuery}))
+}
+
+func TestCheckLimitsRejectsQueriesNestedDeeperThanTheMaximum(t *testing.T) {
+	query := strings.Repeat("{ checkout ", MaxDepth+1) + strings.Repeat("}", MaxDepth+1)
+
+	err := CheckLimits(Request{Query: query})
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrValidation))
+}
+
+func TestCheckLimitsRejectsQueriesSelectingMoreFieldsThanTheMaximum(t *testing.T) {
+	query := "{ " + strings.Repeat("products { code } ", MaxFields/2+1) + "}"
+
+	err := CheckLimits(Request{Query: query})
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrValidation))
+}
+
+func TestCheckLimitsCountsTheFieldsOfFragmentsEveryTimeTheyAreSpread(t *testing.T) {
+	query := `{ ...a } fragment a on Query { ...b ...b ...b ...b } fragment b on Query { ...c ...c ...c ...c } fragment c on Query { ...d ...d ...d ...d } fragment d on Query { ...e ...e ...e ...e } fragment e on Query { products { code } }`
+
+	err := CheckLimits(Request{Query: query})
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrValidation))
+}
+
+func TestCheckLimitsLeavesFragmentCyclesAndSyntaxErrorsToTheExecution(t *testing.T) {
+	assert.Nil(t, CheckLimits(Request{Query: `{ ...a } fragment a on Query { ...a }`}))
+	assert.Nil(t, CheckLimits(Request{Query: `{ checkout(`}))
+}
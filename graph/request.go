@@ -0,0 +1,8 @@
+package graph
+
+// Request is the body of a GraphQL request over HTTP.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
@@ -0,0 +1,184 @@
+package graph
+
+import (
+	"context"
+	"lana/flagship-store/logging"
+	"lana/flagship-store/models"
+	"lana/flagship-store/services"
+	"lana/flagship-store/services/commands"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Resolver resolves the queries and mutations of the schema with the same
+// services as the REST API.
+type Resolver struct {
+	CreateCheckoutService            *services.CreateCheckout
+	AddProductToCheckoutService      *services.AddProductToCheckout
+	RemoveProductFromCheckoutService *services.RemoveProductFromCheckout
+	DeleteCheckoutService            *services.DeleteCheckout
+	ListCheckoutsService             *services.ListCheckouts
+	RetrieveCheckoutService          *services.RetrieveCheckout
+	ListProductsService              *services.ListProducts
+	RetrieveProductService           *services.RetrieveProduct
+}
+
+// checkoutNode is the source of a Checkout object. Its lines and totals
+// are only calculated when the query selects them.
+type checkoutNode struct {
+	checkout models.Checkout
+	summary  *models.CheckoutSummary
+	batch    *checkoutBatch
+	resolver *Resolver
+}
+
+// checkoutBatch is the checkouts of a page, summarized all at once the
+// first time the query selects the lines or totals of any of them, instead
+// of retrieving every checkout again.
+type checkoutBatch struct {
+	once  sync.Once
+	nodes []*checkoutNode
+	err   error
+}
+
+type checkoutsPage struct {
+	Checkouts  []*checkoutNode
+	NextCursor string
+}
+
+func (resolver *Resolver) newCheckoutNode(checkout models.Checkout) *checkoutNode {
+	return &checkoutNode{checkout: checkout, resolver: resolver}
+}
+
+func (node *checkoutNode) retrieveSummary(ctx context.Context) (models.CheckoutSummary, error) {
+	if node.summary == nil && node.batch != nil {
+		if err := node.batch.summarize(ctx, node.resolver); err != nil {
+			return models.CheckoutSummary{}, err
+		}
+	}
+	if node.summary == nil {
+		summary, err := node.resolver.RetrieveCheckoutService.Do(ctx, node.checkout.Id)
+		if err != nil {
+			return models.CheckoutSummary{}, err
+		}
+		node.summary = &summary
+	}
+	return *node.summary, nil
+}
+
+func (batch *checkoutBatch) summarize(ctx context.Context, resolver *Resolver) error {
+	batch.once.Do(func() {
+		checkouts := make([]models.Checkout, 0, len(batch.nodes))
+		for _, node := range batch.nodes {
+			checkouts = append(checkouts, node.checkout)
+		}
+		summaries, err := resolver.RetrieveCheckoutService.Summarize(ctx, checkouts)
+		if err != nil {
+			batch.err = err
+			return
+		}
+		for i := range summaries {
+			batch.nodes[i].summary = &summaries[i]
+		}
+	})
+	return batch.err
+}
+
+func (resolver *Resolver) checkout(p graphql.ResolveParams) (interface{}, error) {
+	summary, err := resolver.RetrieveCheckoutService.Do(p.Context, p.Args["id"].(string))
+	if err != nil {
+		return nil, resolveError(p.Context, err)
+	}
+	return &checkoutNode{checkout: summary.Checkout, summary: &summary, resolver: resolver}, nil
+}
+
+func (resolver *Resolver) checkouts(p graphql.ResolveParams) (interface{}, error) {
+	listCommand := commands.ListCheckouts{}
+	listCommand.ProductCode, _ = p.Args["product"].(string)
+	listCommand.Status, _ = p.Args["status"].(string)
+	listCommand.Sort, _ = p.Args["sort"].(string)
+	listCommand.Cursor, _ = p.Args["cursor"].(string)
+	listCommand.Limit, _ = p.Args["limit"].(int)
+	listCommand.CreatedFrom, _ = p.Args["createdFrom"].(time.Time)
+	listCommand.CreatedTo, _ = p.Args["createdTo"].(time.Time)
+
+	checkouts, nextCursor, err := resolver.ListCheckoutsService.Do(p.Context, listCommand)
+	if err != nil {
+		return nil, resolveError(p.Context, err)
+	}
+
+	page := checkoutsPage{Checkouts: []*checkoutNode{}, NextCursor: nextCursor}
+	batch := &checkoutBatch{}
+	for _, checkout := range checkouts {
+		node := resolver.newCheckoutNode(checkout)
+		node.batch = batch
+		page.Checkouts = append(page.Checkouts, node)
+	}
+	batch.nodes = page.Checkouts
+	return page, nil
+}
+
+func (resolver *Resolver) product(p graphql.ResolveParams) (interface{}, error) {
+	product, err := resolver.RetrieveProductService.Do(p.Context, p.Args["code"].(string))
+	if err != nil {
+		return nil, resolveError(p.Context, err)
+	}
+	return product, nil
+}
+
+func (resolver *Resolver) products(p graphql.ResolveParams) (interface{}, error) {
+	products, err := resolver.ListProductsService.Do(p.Context)
+	if err != nil {
+		return nil, resolveError(p.Context, err)
+	}
+	return products, nil
+}
+
+func (resolver *Resolver) createCheckout(p graphql.ResolveParams) (interface{}, error) {
+	createCommand := commands.CreateCheckout{}
+	lines, _ := p.Args["lines"].([]interface{})
+	for _, line := range lines {
+		createCommand.Lines = append(createCommand.Lines, newLineCommand(line))
+	}
+
+	checkout, err := resolver.CreateCheckoutService.Do(p.Context, createCommand)
+	if err != nil {
+		return nil, resolveError(p.Context, err)
+	}
+	logging.Annotate(p.Context, "checkout-id", checkout.Id)
+	return resolver.newCheckoutNode(checkout), nil
+}
+
+func (resolver *Resolver) addProduct(p graphql.ResolveParams) (interface{}, error) {
+	checkout, err := resolver.AddProductToCheckoutService.Do(p.Context, newLineCommand(p.Args["line"]), p.Args["checkoutId"].(string))
+	if err != nil {
+		return nil, resolveError(p.Context, err)
+	}
+	return resolver.newCheckoutNode(checkout), nil
+}
+
+func (resolver *Resolver) removeProduct(p graphql.ResolveParams) (interface{}, error) {
+	checkout, err := resolver.RemoveProductFromCheckoutService.Do(p.Context, newLineCommand(p.Args["line"]), p.Args["checkoutId"].(string))
+	if err != nil {
+		return nil, resolveError(p.Context, err)
+	}
+	return resolver.newCheckoutNode(checkout), nil
+}
+
+func (resolver *Resolver) deleteCheckout(p graphql.ResolveParams) (interface{}, error) {
+	checkout, err := resolver.DeleteCheckoutService.Do(p.Context, p.Args["id"].(string))
+	if err != nil {
+		return nil, resolveError(p.Context, err)
+	}
+	return checkout.Id, nil
+}
+
+func newLineCommand(line interface{}) commands.Line {
+	lineFields, _ := line.(map[string]interface{})
+	lineCommand := commands.Line{}
+	lineCommand.ProductCode, _ = lineFields["productCode"].(string)
+	lineCommand.Quantity, _ = lineFields["quantity"].(int)
+	return lineCommand
+}
@@ -0,0 +1,184 @@
+package graph
+
+import (
+	"lana/flagship-store/models"
+
+	"github.com/graphql-go/graphql"
+)
+
+var productType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Product",
+	Fields: graphql.Fields{
+		"code":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"name":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"price": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Description: "Unit price in cents"},
+	},
+})
+
+var lineType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Line",
+	Fields: graphql.Fields{
+		"product":  &graphql.Field{Type: graphql.NewNonNull(productType)},
+		"quantity": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"subtotal": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Description: "Amount in cents before promotions"},
+		"amount":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Description: "Amount in cents with promotions applied"},
+	},
+})
+
+var lineInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "LineInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"productCode": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"quantity":    &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+var checkoutType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Checkout",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type:    graphql.NewNonNull(graphql.ID),
+			Resolve: resolveCheckoutField(func(checkout models.Checkout) interface{} { return checkout.Id }),
+		},
+		"status": &graphql.Field{
+			Type:    graphql.NewNonNull(graphql.String),
+			Resolve: resolveCheckoutField(func(checkout models.Checkout) interface{} { return checkout.Status }),
+		},
+		"products": &graphql.Field{
+			Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+			Resolve: resolveCheckoutField(func(checkout models.Checkout) interface{} { return checkout.Products }),
+		},
+		"createdAt": &graphql.Field{
+			Type:    graphql.NewNonNull(graphql.DateTime),
+			Resolve: resolveCheckoutField(func(checkout models.Checkout) interface{} { return checkout.CreatedAt }),
+		},
+		"updatedAt": &graphql.Field{
+			Type:    graphql.NewNonNull(graphql.DateTime),
+			Resolve: resolveCheckoutField(func(checkout models.Checkout) interface{} { return checkout.UpdatedAt }),
+		},
+		"lines": &graphql.Field{
+			Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(lineType))),
+			Resolve: resolveSummaryField(func(summary models.CheckoutSummary) interface{} { return summary.Lines }),
+		},
+		"subtotal": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Int),
+			Description: "Amount in cents before promotions",
+			Resolve:     resolveSummaryField(func(summary models.CheckoutSummary) interface{} { return summary.Subtotal }),
+		},
+		"discount": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Int),
+			Description: "Savings in cents of the promotions",
+			Resolve: resolveSummaryField(func(summary models.CheckoutSummary) interface{} {
+				return summary.Subtotal - summary.PointsDiscount - summary.Amount
+			}),
+		},
+		"redeemedPoints": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Int),
+			Description: "Loyalty points redeemed on the checkout",
+			Resolve:     resolveSummaryField(func(summary models.CheckoutSummary) interface{} { return summary.RedeemedPoints }),
+		},
+		"pointsDiscount": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Int),
+			Description: "Discount in cents of the loyalty points redeemed",
+			Resolve:     resolveSummaryField(func(summary models.CheckoutSummary) interface{} { return summary.PointsDiscount }),
+		},
+		"amount": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Int),
+			Description: "Amount in cents to pay",
+			Resolve:     resolveSummaryField(func(summary models.CheckoutSummary) interface{} { return summary.Amount }),
+		},
+	},
+})
+
+var checkoutsPageType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "CheckoutsPage",
+	Fields: graphql.Fields{
+		"checkouts":  &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(checkoutType)))},
+		"nextCursor": &graphql.Field{Type: graphql.String, Description: "Cursor of the next page, empty on the last one"},
+	},
+})
+
+func resolveCheckoutField(field func(checkout models.Checkout) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return field(p.Source.(*checkoutNode).checkout), nil
+	}
+}
+
+func resolveSummaryField(field func(summary models.CheckoutSummary) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		summary, err := p.Source.(*checkoutNode).retrieveSummary(p.Context)
+		if err != nil {
+			return nil, resolveError(p.Context, err)
+		}
+		return field(summary), nil
+	}
+}
+
+// NewSchema returns the GraphQL schema of the store resolved by resolver.
+func NewSchema(resolver *Resolver) (graphql.Schema, error) {
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"checkout": &graphql.Field{
+				Type:    checkoutType,
+				Args:    graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: resolver.checkout,
+			},
+			"checkouts": &graphql.Field{
+				Type: graphql.NewNonNull(checkoutsPageType),
+				Args: graphql.FieldConfigArgument{
+					"product":     &graphql.ArgumentConfig{Type: graphql.String, Description: "Only checkouts containing the product code"},
+					"status":      &graphql.ArgumentConfig{Type: graphql.String, Description: "Only checkouts with the status"},
+					"createdFrom": &graphql.ArgumentConfig{Type: graphql.DateTime, Description: "Only checkouts created from the time"},
+					"createdTo":   &graphql.ArgumentConfig{Type: graphql.DateTime, Description: "Only checkouts created before the time"},
+					"sort":        &graphql.ArgumentConfig{Type: graphql.String, Description: "created-at or id, prefixed with - for descending order"},
+					"cursor":      &graphql.ArgumentConfig{Type: graphql.String, Description: "nextCursor of the previous page"},
+					"limit":       &graphql.ArgumentConfig{Type: graphql.Int, Description: "Page size, up to 100"},
+				},
+				Resolve: resolver.checkouts,
+			},
+			"product": &graphql.Field{
+				Type:    productType,
+				Args:    graphql.FieldConfigArgument{"code": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: resolver.product,
+			},
+			"products": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(productType))),
+				Resolve: resolver.products,
+			},
+		},
+	})
+
+	lineArgs := graphql.FieldConfigArgument{
+		"checkoutId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+		"line":       &graphql.ArgumentConfig{Type: graphql.NewNonNull(lineInputType)},
+	}
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createCheckout": &graphql.Field{
+				Type:    graphql.NewNonNull(checkoutType),
+				Args:    graphql.FieldConfigArgument{"lines": &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(lineInputType))}},
+				Resolve: resolver.createCheckout,
+			},
+			"addProduct": &graphql.Field{
+				Type:    graphql.NewNonNull(checkoutType),
+				Args:    lineArgs,
+				Resolve: resolver.addProduct,
+			},
+			"removeProduct": &graphql.Field{
+				Type:    graphql.NewNonNull(checkoutType),
+				Args:    lineArgs,
+				Resolve: resolver.removeProduct,
+			},
+			"deleteCheckout": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.ID),
+				Description: "Deletes the checkout and returns its id",
+				Args:        graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve:     resolver.deleteCheckout,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
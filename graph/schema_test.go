@@ -0,0 +1,229 @@
+package graph
+
+import (
+	"context"
+	"lana/flagship-store/auth"
+	"lana/flagship-store/models"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func execute(t *testing.T, resolver *Resolver, query string, variables map[string]interface{}) *graphql.Result {
+	return executeAs(t, policy.WithTrustedCaller(context.Background()), resolver, query, variables)
+}
+
+func executeAs(t *testing.T, ctx context.Context, resolver *Resolver, query string, variables map[string]interface{}) *graphql.Result {
+	schema, err := NewSchema(resolver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return graphql.Do(graphql.Params{Schema: schema, RequestString: query, VariableValues: variables, Context: ctx})
+}
+
+func ProductRepositoryMockWithAllProducts() *mocks.ProductRepositoryMock {
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "PEN").Return(models.Product{Code: "PEN", Name: "Lana Pen", Price: 500}, true)
+	theProductRepositoryMock.On("SearchById", "TSHIRT").Return(models.Product{Code: "TSHIRT", Name: "Lana T-Shirt", Price: 2000}, true)
+	theProductRepositoryMock.On("SearchById", "MUG").Return(models.Product{Code: "MUG", Name: "Lana Coffee Mug", Price: 750}, true)
+	theProductRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	return &theProductRepositoryMock
+}
+
+func ProductWithPromotionRepositoryMockWithPen() *mocks.ProductWithPromotionRepositoryMock {
+	theProductWithPromotionRepositoryMock := mocks.ProductWithPromotionRepositoryMock{}
+	theProductWithPromotionRepositoryMock.On("SearchById", "PEN").Return(models.Product{Code: "PEN"}, true)
+	theProductWithPromotionRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	return &theProductWithPromotionRepositoryMock
+}
+
+func ProductWithDiscountRepositoryMockWithoutProducts() *mocks.ProductWithDiscountRepositoryMock {
+	theProductWithDiscountRepositoryMock := mocks.ProductWithDiscountRepositoryMock{}
+	theProductWithDiscountRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	return &theProductWithDiscountRepositoryMock
+}
+
+func TestQueryCheckoutWithLinesAndAmount(t *testing.T) {
+	checkout := models.Checkout{Id: uuid.NewString(), Products: []string{"PEN", "MUG", "PEN"}, Status: models.CheckoutStatusOpen}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	retrieveCheckout := services.NewRetrieveCheckout(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts(), ProductWithPromotionRepositoryMockWithPen(), ProductWithDiscountRepositoryMockWithoutProducts())
+
+	result := execute(t, &Resolver{RetrieveCheckoutService: &retrieveCheckout}, `query($id: ID!) {
+		checkout(id: $id) { id status lines { product { code name price } quantity amount } subtotal discount amount }
+	}`, map[string]interface{}{"id": checkout.Id})
+
+	assert.Empty(t, result.Errors)
+	assert.EqualValues(t, map[string]interface{}{
+		"id":     checkout.Id,
+		"status": "open",
+		"lines": []interface{}{
+			map[string]interface{}{"product": map[string]interface{}{"code": "MUG", "name": "Lana Coffee Mug", "price": 750}, "quantity": 1, "amount": 750},
+			map[string]interface{}{"product": map[string]interface{}{"code": "PEN", "name": "Lana Pen", "price": 500}, "quantity": 2, "amount": 500},
+		},
+		"subtotal": 1750,
+		"discount": 500,
+		"amount":   1250,
+	}, result.Data.(map[string]interface{})["checkout"])
+	theCheckoutRepositoryMock.AssertNumberOfCalls(t, "SearchById", 1)
+}
+
+func TestQueryCheckoutWithThePointsDiscountApartFromThePromotions(t *testing.T) {
+	checkout := models.Checkout{Id: uuid.NewString(), Products: []string{"PEN", "MUG", "PEN"}, Status: models.CheckoutStatusOpen, RedeemedPoints: 250}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	retrieveCheckout := services.NewRetrieveCheckout(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts(), ProductWithPromotionRepositoryMockWithPen(), ProductWithDiscountRepositoryMockWithoutProducts())
+
+	result := execute(t, &Resolver{RetrieveCheckoutService: &retrieveCheckout}, `query($id: ID!) {
+		checkout(id: $id) { subtotal discount redeemedPoints pointsDiscount amount }
+	}`, map[string]interface{}{"id": checkout.Id})
+
+	assert.Empty(t, result.Errors)
+	assert.EqualValues(t, map[string]interface{}{
+		"subtotal":       1750,
+		"discount":       500,
+		"redeemedPoints": 250,
+		"pointsDiscount": 250,
+		"amount":         1000,
+	}, result.Data.(map[string]interface{})["checkout"])
+}
+
+func TestQueryCheckoutReturnErrorWithCodeWhenCheckoutDoesNotExist(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", "a_fake_checkout").Return(models.Checkout{}, false)
+	retrieveCheckout := services.NewRetrieveCheckout(&theCheckoutRepositoryMock, &mocks.ProductRepositoryMock{}, &mocks.ProductWithPromotionRepositoryMock{}, &mocks.ProductWithDiscountRepositoryMock{})
+
+	result := execute(t, &Resolver{RetrieveCheckoutService: &retrieveCheckout}, `{ checkout(id: "a_fake_checkout") { id } }`, nil)
+
+	assert.Len(t, result.Errors, 1)
+	assert.EqualValues(t, "Checkout a_fake_checkout not found", result.Errors[0].Message)
+	assert.EqualValues(t, "checkout-not-found", result.Errors[0].Extensions["code"])
+	assert.EqualValues(t, map[string]interface{}{"checkout": nil}, result.Data)
+}
+
+func TestQueryCheckoutsWithNextCursor(t *testing.T) {
+	checkouts := []models.Checkout{{Id: "a"}, {Id: "b"}}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Search", mock.AnythingOfType("persistence.CheckoutCriteria")).Return(checkouts)
+	listCheckouts := services.NewListCheckouts(&theCheckoutRepositoryMock)
+
+	result := execute(t, &Resolver{ListCheckoutsService: &listCheckouts}, `{ checkouts(limit: 1, sort: "id") { checkouts { id } nextCursor } }`, nil)
+
+	assert.Empty(t, result.Errors)
+	page := result.Data.(map[string]interface{})["checkouts"].(map[string]interface{})
+	assert.EqualValues(t, []interface{}{map[string]interface{}{"id": "a"}}, page["checkouts"])
+	assert.NotEmpty(t, page["nextCursor"])
+}
+
+func TestQueryCheckoutsSummarizesThePageReadingEachProductOnce(t *testing.T) {
+	checkouts := []models.Checkout{{Id: "a", Products: []string{"MUG"}}, {Id: "b", Products: []string{"PEN", "PEN", "MUG"}}}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Search", mock.AnythingOfType("persistence.CheckoutCriteria")).Return(checkouts)
+	theProductRepositoryMock := ProductRepositoryMockWithAllProducts()
+	listCheckouts := services.NewListCheckouts(&theCheckoutRepositoryMock)
+	retrieveCheckout := services.NewRetrieveCheckout(&theCheckoutRepositoryMock, theProductRepositoryMock, ProductWithPromotionRepositoryMockWithPen(), ProductWithDiscountRepositoryMockWithoutProducts())
+
+	result := execute(t, &Resolver{ListCheckoutsService: &listCheckouts, RetrieveCheckoutService: &retrieveCheckout}, `{ checkouts(sort: "id") { checkouts { id amount lines { quantity } } } }`, nil)
+
+	assert.Empty(t, result.Errors)
+	page := result.Data.(map[string]interface{})["checkouts"].(map[string]interface{})
+	assert.EqualValues(t, 750, page["checkouts"].([]interface{})[0].(map[string]interface{})["amount"])
+	assert.EqualValues(t, 1250, page["checkouts"].([]interface{})[1].(map[string]interface{})["amount"])
+	theCheckoutRepositoryMock.AssertNotCalled(t, "SearchById", mock.Anything)
+	theProductRepositoryMock.AssertNumberOfCalls(t, "SearchById", 3)
+}
+
+func TestQueryProducts(t *testing.T) {
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchAll").Return([]models.Product{{Code: "MUG", Name: "Lana Coffee Mug", Price: 750}})
+	listProducts := services.NewListProducts(&theProductRepositoryMock)
+
+	result := execute(t, &Resolver{ListProductsService: &listProducts}, `{ products { code price } }`, nil)
+
+	assert.Empty(t, result.Errors)
+	assert.EqualValues(t, map[string]interface{}{"products": []interface{}{map[string]interface{}{"code": "MUG", "price": 750}}}, result.Data)
+}
+
+func TestQueryProductReturnErrorWithCodeWhenProductDoesNotExist(t *testing.T) {
+	retrieveProduct := services.NewRetrieveProduct(ProductRepositoryMockWithAllProducts())
+
+	result := execute(t, &Resolver{RetrieveProductService: &retrieveProduct}, `{ product(code: "FAKE") { name } }`, nil)
+
+	assert.Len(t, result.Errors, 1)
+	assert.EqualValues(t, "product-not-found", result.Errors[0].Extensions["code"])
+	assert.EqualValues(t, map[string]interface{}{"product-code": "FAKE"}, result.Errors[0].Extensions["details"])
+}
+
+func TestMutationCreateCheckout(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	createCheckout := services.NewCreateCheckout(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts())
+
+	result := execute(t, &Resolver{CreateCheckoutService: &createCheckout}, `mutation {
+		createCheckout(lines: [{productCode: "PEN", quantity: 2}]) { products status }
+	}`, nil)
+
+	assert.Empty(t, result.Errors)
+	assert.EqualValues(t, map[string]interface{}{"products": []interface{}{"PEN", "PEN"}, "status": "open"}, result.Data.(map[string]interface{})["createCheckout"])
+	theCheckoutRepositoryMock.AssertExpectations(t)
+}
+
+func TestMutationAddProductReturnErrorWithCodeWhenQuantityIsNotValid(t *testing.T) {
+	addProduct := services.NewAddProductToCheckout(&mocks.CheckoutRepositoryMock{}, &mocks.ProductRepositoryMock{})
+
+	result := execute(t, &Resolver{AddProductToCheckoutService: &addProduct}, `mutation {
+		addProduct(checkoutId: "an_id", line: {productCode: "PEN", quantity: 0}) { id }
+	}`, nil)
+
+	assert.Len(t, result.Errors, 1)
+	assert.EqualValues(t, "validation-failed", result.Errors[0].Extensions["code"])
+}
+
+func TestMutationRemoveProduct(t *testing.T) {
+	checkout := models.Checkout{Id: uuid.NewString(), Products: []string{"PEN", "MUG"}}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), mock.AnythingOfType("string")).Return(true)
+	removeProduct := services.NewRemoveProductFromCheckout(&theCheckoutRepositoryMock)
+
+	result := execute(t, &Resolver{RemoveProductFromCheckoutService: &removeProduct}, `mutation($id: ID!) {
+		removeProduct(checkoutId: $id, line: {productCode: "PEN", quantity: 1}) { products }
+	}`, map[string]interface{}{"id": checkout.Id})
+
+	assert.Empty(t, result.Errors)
+	assert.EqualValues(t, map[string]interface{}{"products": []interface{}{"MUG"}}, result.Data.(map[string]interface{})["removeProduct"])
+}
+
+func TestMutationDeleteCheckout(t *testing.T) {
+	checkout := models.Checkout{Id: uuid.NewString(), Products: []string{"MUG"}}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCheckoutRepositoryMock.On("DeleteIfStatus", checkout, mock.AnythingOfType("string")).Return(true)
+	deleteCheckout := services.NewDeleteCheckout(&theCheckoutRepositoryMock)
+
+	result := execute(t, &Resolver{DeleteCheckoutService: &deleteCheckout}, `mutation($id: ID!) { deleteCheckout(id: $id) }`, map[string]interface{}{"id": checkout.Id})
+
+	assert.Empty(t, result.Errors)
+	assert.EqualValues(t, map[string]interface{}{"deleteCheckout": checkout.Id}, result.Data)
+	theCheckoutRepositoryMock.AssertExpectations(t)
+}
+
+func TestMutationRemoveProductReturnForbiddenErrorForMerchandisers(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	removeProduct := services.NewRemoveProductFromCheckout(&theCheckoutRepositoryMock)
+	ctx := auth.NewContext(context.Background(), auth.Principal{Subject: "crm", Method: auth.MethodAPIKey, Role: auth.RoleMerchandiser})
+
+	result := executeAs(t, ctx, &Resolver{RemoveProductFromCheckoutService: &removeProduct}, `mutation {
+		removeProduct(checkoutId: "an_id", line: {productCode: "PEN", quantity: 1}) { id }
+	}`, nil)
+
+	assert.Len(t, result.Errors, 1)
+	assert.EqualValues(t, "forbidden", result.Errors[0].Extensions["code"])
+	assert.Empty(t, theCheckoutRepositoryMock.Calls)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"lana/flagship-store/graph"
+	"lana/flagship-store/services/errors"
+	"log"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+)
+
+// initializeGraphQLSchema resolves the schema with the services of the
+// app, so services replaced after Initialize are used too.
+func (app *App) initializeGraphQLSchema() {
+	schema, err := graph.NewSchema(&graph.Resolver{
+		CreateCheckoutService:            &app.CreateCheckoutService,
+		AddProductToCheckoutService:      &app.AddProductToCheckoutService,
+		RemoveProductFromCheckoutService: &app.RemoveProductFromCheckoutService,
+		DeleteCheckoutService:            &app.DeleteCheckoutService,
+		ListCheckoutsService:             &app.ListCheckoutsService,
+		RetrieveCheckoutService:          &app.RetrieveCheckoutService,
+		ListProductsService:              &app.ListProductsService,
+		RetrieveProductService:           &app.RetrieveProductService,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	app.GraphQLSchema = schema
+}
+
+func (app *App) executeGraphQL(response http.ResponseWriter, request *http.Request) {
+	var graphQLRequest graph.Request
+	err := decodeBody(response, request, &graphQLRequest)
+	if err == nil && graphQLRequest.Query == "" {
+		err = errors.NewValidationError(map[string]string{"query": "is required"})
+	}
+	if err == nil {
+		err = graph.CheckLimits(graphQLRequest)
+	}
+	if err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         app.GraphQLSchema,
+		RequestString:  graphQLRequest.Query,
+		OperationName:  graphQLRequest.OperationName,
+		VariableValues: graphQLRequest.Variables,
+		Context:        request.Context(),
+	})
+
+	response.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(response).Encode(result)
+}
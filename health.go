@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/services/responses"
+	"net/http"
+	"sort"
+	"sync/atomic"
+)
+
+// version and commit of the build, injected at link time with
+// -ldflags "-X main.version=... -X main.commit=...".
+var (
+	version = "dev"
+	commit  = "unknown"
+)
+
+const (
+	healthStatusOK           = "ok"
+	healthStatusReady        = "ready"
+	healthStatusNotReady     = "not-ready"
+	healthStatusShuttingDown = "shutting-down"
+)
+
+func (app *App) retrieveLiveness(response http.ResponseWriter, request *http.Request) {
+	writeHealth(response, http.StatusOK, responses.Health{Status: healthStatusOK})
+}
+
+// retrieveReadiness checks the backend of every repository, and fails since
+// the shutdown starts so no new traffic is routed to the app.
+func (app *App) retrieveReadiness(response http.ResponseWriter, request *http.Request) {
+	if app.isShuttingDown() {
+		writeHealth(response, http.StatusServiceUnavailable, responses.Health{Status: healthStatusShuttingDown})
+		return
+	}
+
+	health := responses.Health{Status: healthStatusReady, Checks: map[string]string{}}
+	status := http.StatusOK
+	for _, name := range sortedHealthCheckerNames(app.HealthCheckers) {
+		health.Checks[name] = healthStatusOK
+		if err := app.HealthCheckers[name].Ping(request.Context()); err != nil {
+			health.Checks[name] = err.Error()
+			health.Status = healthStatusNotReady
+			status = http.StatusServiceUnavailable
+		}
+	}
+	writeHealth(response, status, health)
+}
+
+func (app *App) retrieveVersion(response http.ResponseWriter, request *http.Request) {
+	response.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(response).Encode(responses.Version{Version: version, Commit: commit})
+}
+
+func (app *App) startShutdown() {
+	atomic.StoreInt32(&app.shuttingDown, 1)
+}
+
+func (app *App) isShuttingDown() bool {
+	return atomic.LoadInt32(&app.shuttingDown) == 1
+}
+
+func writeHealth(response http.ResponseWriter, status int, health responses.Health) {
+	response.Header().Set("Content-Type", "application/json")
+	response.Header().Set("Cache-Control", "no-store")
+	response.WriteHeader(status)
+	json.NewEncoder(response).Encode(health)
+}
+
+func sortedHealthCheckerNames(healthCheckers map[string]persistence.HealthChecker) []string {
+	names := make([]string, 0, len(healthCheckers))
+	for name := range healthCheckers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"lana/flagship-store/idempotency"
+	"lana/flagship-store/services/errors"
+	"net/http"
+	"strings"
+)
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
+)
+
+// idempotent serves the requests with an Idempotency-Key header once per
+// client and key: the first response, unless it is a server error, is
+// stored and replayed to the requests repeating the key with the same
+// method, path and body, with the Idempotent-Replayed header. A key
+// repeated with a different request is rejected with 422, and while the
+// first request is served with 409.
+func (app *App) idempotent(handler http.HandlerFunc) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		key := request.Header.Get(idempotencyKeyHeader)
+		if key == "" || app.IdempotencyStore == nil {
+			handler(response, request)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			writeProblem(response, request, errors.NewInvalidParameterError(idempotencyKeyHeader))
+			return
+		}
+
+		body, err := readBody(response, request)
+		if err != nil {
+			writeProblem(response, request, err)
+			return
+		}
+		request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		storeKey := clientOf(request) + " " + key
+		fingerprint := fingerprintOf(request, body)
+		record, reserved, err := app.IdempotencyStore.Reserve(request.Context(), storeKey, fingerprint)
+		switch {
+		case err != nil:
+			writeProblem(response, request, err)
+		case record.Fingerprint != fingerprint:
+			writeProblem(response, request, errors.NewIdempotencyKeyReusedError(key))
+		case !reserved && record.Response == nil:
+			writeProblem(response, request, errors.NewIdempotencyKeyInUseError(key))
+		case !reserved:
+			replay(response, *record.Response)
+		default:
+			app.serveIdempotent(response, request, handler, storeKey)
+		}
+	}
+}
+
+// serveIdempotent serves the request holding the reservation of the key,
+// which is released unless the response is saved, also when the handler
+// panics, so the request can be retried.
+func (app *App) serveIdempotent(response http.ResponseWriter, request *http.Request, handler http.HandlerFunc, storeKey string) {
+	saved := false
+	defer func() {
+		if !saved {
+			app.IdempotencyStore.Release(request.Context(), storeKey)
+		}
+	}()
+
+	headerBefore := response.Header().Clone()
+	recorder := &responseRecorder{statusRecorder: newStatusRecorder(response)}
+	handler(recorder, request)
+
+	if recorder.status >= http.StatusInternalServerError {
+		return
+	}
+	saved = true
+	app.IdempotencyStore.Save(request.Context(), storeKey, idempotency.Response{
+		Status: recorder.status,
+		Header: changedHeader(headerBefore, response.Header()),
+		Body:   recorder.body.Bytes(),
+	})
+}
+
+func replay(response http.ResponseWriter, stored idempotency.Response) {
+	for name, values := range stored.Header {
+		response.Header()[name] = values
+	}
+	response.Header().Set("Idempotent-Replayed", "true")
+	response.WriteHeader(stored.Status)
+	response.Write(stored.Body)
+}
+
+func fingerprintOf(request *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(request.Method + " " + request.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// changedHeader returns the header fields set by the handler, leaving out
+// the ones set before, e.g. the request id, that belong to every response.
+func changedHeader(before http.Header, after http.Header) http.Header {
+	changed := http.Header{}
+	for name, values := range after {
+		if strings.Join(before[name], "\n") != strings.Join(values, "\n") {
+			changed[name] = values
+		}
+	}
+	return changed
+}
+
+// responseRecorder records the status and the body written to the
+// response.
+type responseRecorder struct {
+	*statusRecorder
+	body bytes.Buffer
+}
+
+func (recorder *responseRecorder) Write(data []byte) (int, error) {
+	recorder.body.Write(data)
+	return recorder.statusRecorder.Write(data)
+}
@@ -0,0 +1,76 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// InMemoryStore is a Store keeping the records in the process until the
+// window since they were reserved or saved ends. Expired records are swept
+// at most once per window.
+type InMemoryStore struct {
+	window    time.Duration
+	now       func() time.Time
+	mutex     sync.Mutex
+	entries   map[string]*entry
+	lastSweep time.Time
+}
+
+type entry struct {
+	record    Record
+	expiresAt time.Time
+}
+
+func NewInMemoryStore(window time.Duration) *InMemoryStore {
+	return &InMemoryStore{
+		window:  window,
+		now:     time.Now,
+		entries: make(map[string]*entry),
+	}
+}
+
+func (store *InMemoryStore) Reserve(ctx context.Context, key string, fingerprint string) (Record, bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := store.now()
+	store.sweep(now)
+
+	if existing, found := store.entries[key]; found && now.Before(existing.expiresAt) {
+		return existing.record, false, nil
+	}
+	store.entries[key] = &entry{record: Record{Fingerprint: fingerprint}, expiresAt: now.Add(store.window)}
+	return Record{Fingerprint: fingerprint}, true, nil
+}
+
+func (store *InMemoryStore) Save(ctx context.Context, key string, response Response) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if existing, found := store.entries[key]; found {
+		existing.record.Response = &response
+		existing.expiresAt = store.now().Add(store.window)
+	}
+	return nil
+}
+
+func (store *InMemoryStore) Release(ctx context.Context, key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.entries, key)
+	return nil
+}
+
+func (store *InMemoryStore) sweep(now time.Time) {
+	if now.Sub(store.lastSweep) < store.window {
+		return
+	}
+	for key, existing := range store.entries {
+		if !now.Before(existing.expiresAt) {
+			delete(store.entries, key)
+		}
+	}
+	store.lastSweep = now
+}
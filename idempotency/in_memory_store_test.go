@@ -0,0 +1,80 @@
+package idempotency
+
+import (
+	"context"
+	"lana/flagship-store/utils/fakeclock"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestStore(window time.Duration) (*InMemoryStore, *fakeclock.Clock) {
+	clock := fakeclock.New()
+	store := NewInMemoryStore(window)
+	store.now = clock.Now
+	return store, clock
+}
+
+func TestReserveNewKey(t *testing.T) {
+	store, _ := newTestStore(time.Hour)
+
+	record, reserved, err := store.Reserve(context.Background(), "a-key", "a-fingerprint")
+
+	assert.Nil(t, err)
+	assert.True(t, reserved)
+	assert.EqualValues(t, Record{Fingerprint: "a-fingerprint"}, record)
+}
+
+func TestReserveReturnsTheRecordOfAKeyBeingServed(t *testing.T) {
+	store, _ := newTestStore(time.Hour)
+	store.Reserve(context.Background(), "a-key", "a-fingerprint")
+
+	record, reserved, _ := store.Reserve(context.Background(), "a-key", "another-fingerprint")
+
+	assert.False(t, reserved)
+	assert.EqualValues(t, Record{Fingerprint: "a-fingerprint"}, record)
+}
+
+func TestReserveReturnsTheSavedResponse(t *testing.T) {
+	store, _ := newTestStore(time.Hour)
+	store.Reserve(context.Background(), "a-key", "a-fingerprint")
+	store.Save(context.Background(), "a-key", Response{Status: 201, Body: []byte(`{}`)})
+
+	record, reserved, _ := store.Reserve(context.Background(), "a-key", "a-fingerprint")
+
+	assert.False(t, reserved)
+	assert.EqualValues(t, &Response{Status: 201, Body: []byte(`{}`)}, record.Response)
+}
+
+func TestReserveAgainOnceTheWindowEnds(t *testing.T) {
+	store, clock := newTestStore(time.Hour)
+	store.Reserve(context.Background(), "a-key", "a-fingerprint")
+	store.Save(context.Background(), "a-key", Response{Status: 201})
+
+	clock.Advance(time.Hour)
+	_, reserved, _ := store.Reserve(context.Background(), "a-key", "a-fingerprint")
+
+	assert.True(t, reserved)
+}
+
+func TestReserveAgainOnceReleased(t *testing.T) {
+	store, _ := newTestStore(time.Hour)
+	store.Reserve(context.Background(), "a-key", "a-fingerprint")
+	store.Release(context.Background(), "a-key")
+
+	_, reserved, _ := store.Reserve(context.Background(), "a-key", "a-fingerprint")
+
+	assert.True(t, reserved)
+}
+
+func TestSweepExpiredRecords(t *testing.T) {
+	store, clock := newTestStore(time.Hour)
+	store.Reserve(context.Background(), "a-key", "a-fingerprint")
+
+	clock.Advance(time.Hour)
+	store.Reserve(context.Background(), "another-key", "a-fingerprint")
+
+	assert.Len(t, store.entries, 1)
+	assert.Contains(t, store.entries, "another-key")
+}
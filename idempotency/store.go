@@ -0,0 +1,35 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+)
+
+// Response is the response stored for an idempotency key, replayed to the
+// requests repeating the key.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Record is the request made with an idempotency key: the fingerprint of
+// its method, path and body, and its response, nil while it is served.
+type Record struct {
+	Fingerprint string
+	Response    *Response
+}
+
+// Store keeps the records of the idempotency keys for a window. Stores
+// sharing the records between replicas, e.g. in Redis, can be plugged in
+// instead of the InMemoryStore.
+type Store interface {
+	// Reserve records the key for the request with the fingerprint and
+	// returns true, or returns the record of the key when it was already
+	// reserved.
+	Reserve(ctx context.Context, key string, fingerprint string) (Record, bool, error)
+	// Save stores the response of the reserved key.
+	Save(ctx context.Context, key string, response Response) error
+	// Release forgets the reserved key, so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
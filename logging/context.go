@@ -0,0 +1,42 @@
+package logging
+
+import "context"
+
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	requestIdKey
+)
+
+func NewContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// FromContext returns the logger of the request being served, or Default.
+func FromContext(ctx context.Context) *Logger {
+	if logger, ok := ctx.Value(loggerKey).(*Logger); ok {
+		return logger
+	}
+	return Default
+}
+
+// Annotate adds the field to the logger of the request being served, e.g.
+// the id of the checkout it creates, so the access log reports it. Nothing
+// is annotated when the context carries no logger.
+func Annotate(ctx context.Context, key string, value interface{}) {
+	if logger, ok := ctx.Value(loggerKey).(*Logger); ok {
+		logger.Annotate(key, value)
+	}
+}
+
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey, requestId)
+}
+
+// RequestId returns the id of the request being served, or "" outside of
+// a request.
+func RequestId(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey).(string)
+	return requestId
+}
@@ -0,0 +1,92 @@
+// Package logging writes structured logs as JSON lines, with the fields of
+// the request being served carried in its context.
+package logging
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+type Fields map[string]interface{}
+
+// Default logs to the standard error, used when the context carries no
+// logger.
+var Default = New(os.Stderr)
+
+type output struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+// Logger writes every entry as a JSON object with its time, level, message,
+// the fields of the logger and the fields of the entry.
+type Logger struct {
+	output *output
+	mu     sync.RWMutex
+	fields Fields
+}
+
+func New(writer io.Writer) *Logger {
+	return &Logger{output: &output{writer: writer}, fields: Fields{}}
+}
+
+// With returns a child logger writing to the same output with the field
+// added to its fields.
+func (logger *Logger) With(key string, value interface{}) *Logger {
+	child := &Logger{output: logger.output, fields: Fields{key: value}}
+	logger.mu.RLock()
+	for fieldKey, fieldValue := range logger.fields {
+		if fieldKey != key {
+			child.fields[fieldKey] = fieldValue
+		}
+	}
+	logger.mu.RUnlock()
+	return child
+}
+
+// Annotate adds the field to the logger itself, so it is written with the
+// following entries of everyone holding the logger.
+func (logger *Logger) Annotate(key string, value interface{}) {
+	logger.mu.Lock()
+	logger.fields[key] = value
+	logger.mu.Unlock()
+}
+
+func (logger *Logger) Info(message string, fields Fields) {
+	logger.log("info", message, fields)
+}
+
+func (logger *Logger) Error(message string, err error, fields Fields) {
+	entryFields := Fields{"error": err.Error()}
+	for key, value := range fields {
+		entryFields[key] = value
+	}
+	logger.log("error", message, entryFields)
+}
+
+func (logger *Logger) log(level string, message string, fields Fields) {
+	entry := Fields{}
+	logger.mu.RLock()
+	for key, value := range logger.fields {
+		entry[key] = value
+	}
+	logger.mu.RUnlock()
+	for key, value := range fields {
+		entry[key] = value
+	}
+	entry["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	entry["level"] = level
+	entry["message"] = message
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		line, _ = json.Marshal(Fields{"time": entry["time"], "level": "error", "message": "Unencodable log entry: " + err.Error()})
+	}
+
+	logger.output.mu.Lock()
+	defer logger.output.mu.Unlock()
+	logger.output.writer.Write(append(line, '\n'))
+}
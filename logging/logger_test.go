@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeEntries(output *bytes.Buffer) []map[string]interface{} {
+	entries := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		var entry map[string]interface{}
+		json.Unmarshal([]byte(line), &entry)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestLoggerWritesJSONEntriesWithItsFields(t *testing.T) {
+	output := bytes.Buffer{}
+	logger := New(&output).With("request-id", "a_request")
+
+	logger.Info("Request served", Fields{"status": 200})
+
+	entry := decodeEntries(&output)[0]
+	assert.EqualValues(t, "info", entry["level"])
+	assert.EqualValues(t, "Request served", entry["message"])
+	assert.EqualValues(t, "a_request", entry["request-id"])
+	assert.EqualValues(t, 200, entry["status"])
+	assert.NotEmpty(t, entry["time"])
+}
+
+func TestWithDoesNotChangeTheParentLogger(t *testing.T) {
+	output := bytes.Buffer{}
+	parent := New(&output)
+
+	parent.With("request-id", "a_request")
+	parent.Info("Serving", nil)
+
+	assert.NotContains(t, decodeEntries(&output)[0], "request-id")
+}
+
+func TestErrorWritesTheError(t *testing.T) {
+	output := bytes.Buffer{}
+
+	New(&output).Error("Internal error", errors.New("disk full"), Fields{"path": "/checkouts"})
+
+	entry := decodeEntries(&output)[0]
+	assert.EqualValues(t, "error", entry["level"])
+	assert.EqualValues(t, "disk full", entry["error"])
+	assert.EqualValues(t, "/checkouts", entry["path"])
+}
+
+func TestAnnotateAddsTheFieldToTheLoggerOfTheContext(t *testing.T) {
+	output := bytes.Buffer{}
+	logger := New(&output)
+	ctx := NewContext(context.Background(), logger)
+
+	Annotate(ctx, "checkout-id", "a_checkout")
+	FromContext(ctx).Info("Request served", nil)
+
+	assert.EqualValues(t, "a_checkout", decodeEntries(&output)[0]["checkout-id"])
+}
+
+func TestFromContextReturnDefaultWithoutLogger(t *testing.T) {
+	ctx := context.Background()
+
+	Annotate(ctx, "checkout-id", "a_checkout")
+
+	assert.Same(t, Default, FromContext(ctx))
+	assert.NotContains(t, Default.fields, "checkout-id")
+	assert.EqualValues(t, "", RequestId(ctx))
+}
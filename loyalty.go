@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"lana/flagship-store/logging"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/commands"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func (app *App) initializeLoyaltyRoutes(router *mux.Router) {
+	router.HandleFunc("/checkouts/{id}/complete", app.authorize(policy.CompleteCheckout, app.idempotent(app.completeCheckout))).Methods("POST")
+	router.HandleFunc("/checkouts/{id}/loyalty-points", app.authorize(policy.RedeemLoyaltyPoints, app.redeemLoyaltyPoints)).Methods("PUT")
+	router.HandleFunc("/customers/{id}/loyalty", app.authorize(policy.RetrieveLoyaltyAccount, app.retrieveLoyaltyAccount)).Methods("GET")
+}
+
+func (app *App) completeCheckout(response http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	id := vars["id"]
+
+	checkoutSummary, err := app.CompleteCheckoutService.Do(request.Context(), id)
+	if err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	logging.Annotate(request.Context(), "checkout-id", checkoutSummary.Checkout.Id)
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(newCheckoutDetail(checkoutSummary))
+}
+
+func (app *App) redeemLoyaltyPoints(response http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	id := vars["id"]
+
+	var redeemCommand commands.RedeemPoints
+	if err := decodeBody(response, request, &redeemCommand); err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	checkout, err := app.RedeemLoyaltyPointsService.Do(request.Context(), redeemCommand, id)
+	if err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	checkoutSummary, err := app.RetrieveCheckoutService.Do(request.Context(), checkout.Id)
+	if err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(newCheckoutDetail(checkoutSummary))
+}
+
+func (app *App) retrieveLoyaltyAccount(response http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	id := vars["id"]
+
+	account, err := app.RetrieveLoyaltyAccountService.Do(request.Context(), id)
+	if err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(account)
+}
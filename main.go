@@ -1,22 +1,202 @@
 package main
 
 import (
+	"context"
+	"lana/flagship-store/auth"
+	"lana/flagship-store/idempotency"
+	"lana/flagship-store/logging"
+	"lana/flagship-store/metrics"
 	"lana/flagship-store/models"
 	"lana/flagship-store/persistence"
+	"lana/flagship-store/ratelimit"
+	"lana/flagship-store/rpc"
 	"lana/flagship-store/services"
+	"lana/flagship-store/tracing"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc"
 )
 
 func main() {
-	app := App{}
+	config, err := loadConfig(os.Args[1:], os.Getenv)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tracerProvider, err := tracing.NewProvider(context.Background(), tracing.Config{
+		Exporter:       config.TraceExporter,
+		OTLPEndpoint:   config.OTLPEndpoint,
+		OTLPInsecure:   config.OTLPInsecure,
+		ServiceVersion: version,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	authenticator := auth.NewAuthenticator(config.APIKeys, []byte(config.JWTSecret), config.JWTIssuer)
+	app := App{Logger: logging.New(os.Stdout), Authenticator: authenticator, IdempotencyStore: idempotency.NewInMemoryStore(config.IdempotencyWindow)}
 	checkoutRepository := populate_checkouts()
 	productRepository := populate_products()
-	createCheckoutService := services.NewCreateCheckout(checkoutRepository, productRepository)
-	addProductToCheckoutService := services.NewAddProductToCheckout(checkoutRepository, productRepository)
-	retrieveCheckoutAmountService := services.NewRetrieveCheckoutAmount(checkoutRepository, productRepository, populate_products_with_promotion(), populate_products_with_discount())
-	deleteCheckoutService := services.NewDeleteCheckout(checkoutRepository)
+	productWithPromotionRepository := populate_products_with_promotion()
+	productWithDiscountRepository := populate_products_with_discount()
+	customerRepository := populate_customers()
+	savedListRepository := populate_saved_lists()
+	priceListRepository := populate_price_lists()
+	loyaltyRepository := populate_loyalty()
+	tracedCheckoutRepository := persistence.NewTracedCheckoutRepository(checkoutRepository)
+	tracedProductRepository := persistence.NewTracedProductRepository(productRepository, "products")
+	tracedProductWithPromotionRepository := persistence.NewTracedProductRepository(productWithPromotionRepository, "products-with-promotion")
+	tracedProductWithDiscountRepository := persistence.NewTracedProductRepository(productWithDiscountRepository, "products-with-discount")
+	tracedCustomerRepository := persistence.NewTracedCustomerRepository(customerRepository)
+	tracedSavedListRepository := persistence.NewTracedSavedListRepository(savedListRepository)
+	tracedPriceListRepository := persistence.NewTracedPriceListRepository(priceListRepository)
+	tracedLoyaltyRepository := persistence.NewTracedLoyaltyRepository(loyaltyRepository)
+	pricing := services.NewPricing(tracedCustomerRepository, tracedPriceListRepository)
+	app.CreateCheckoutService = services.NewCreateCheckout(tracedCheckoutRepository, tracedProductRepository)
+	app.CreateCheckoutService.MaxOpenCheckouts = config.MaxOpenCheckouts
+	app.AddProductToCheckoutService = services.NewAddProductToCheckout(tracedCheckoutRepository, tracedProductRepository)
+	app.RetrieveCheckoutAmountService = services.NewRetrieveCheckoutAmount(tracedCheckoutRepository, tracedProductRepository, tracedProductWithPromotionRepository, tracedProductWithDiscountRepository)
+	app.RetrieveCheckoutAmountService.Pricing = pricing
+	app.RetrieveCheckoutsAmountService = services.NewRetrieveCheckoutsAmount(tracedCheckoutRepository, tracedProductRepository, tracedProductWithPromotionRepository, tracedProductWithDiscountRepository)
+	app.RetrieveCheckoutsAmountService.Pricing = pricing
+	app.DeleteCheckoutService = services.NewDeleteCheckout(tracedCheckoutRepository)
+	app.ListCheckoutsService = services.NewListCheckouts(tracedCheckoutRepository)
+	app.RetrieveCheckoutService = services.NewRetrieveCheckout(tracedCheckoutRepository, tracedProductRepository, tracedProductWithPromotionRepository, tracedProductWithDiscountRepository)
+	app.RetrieveCheckoutService.Pricing = pricing
+	app.RemoveProductFromCheckoutService = services.NewRemoveProductFromCheckout(tracedCheckoutRepository)
+	app.ListProductsService = services.NewListProducts(tracedProductRepository)
+	app.RetrieveProductService = services.NewRetrieveProduct(tracedProductRepository)
+	app.RegisterCustomerService = services.NewRegisterCustomer(tracedCustomerRepository)
+	app.RetrieveCustomerService = services.NewRetrieveCustomer(tracedCustomerRepository)
+	app.AttachCheckoutToCustomerService = services.NewAttachCheckoutToCustomer(tracedCheckoutRepository, tracedCustomerRepository)
+	app.MergeCheckoutsService = services.NewMergeCheckouts(tracedCheckoutRepository, tracedProductRepository, tracedProductWithPromotionRepository, tracedProductWithDiscountRepository)
+	app.MergeCheckoutsService.Pricing = pricing
+	app.CreateSavedListService = services.NewCreateSavedList(tracedSavedListRepository, tracedCheckoutRepository, tracedProductRepository)
+	app.ListSavedListsService = services.NewListSavedLists(tracedSavedListRepository)
+	app.CheckoutSavedListService = services.NewCheckoutSavedList(tracedSavedListRepository, tracedProductRepository, app.CreateCheckoutService)
+	app.CreatePriceListService = services.NewCreatePriceList(tracedPriceListRepository, tracedCustomerRepository, tracedProductRepository)
+	app.ListPriceListsService = services.NewListPriceLists(tracedPriceListRepository)
+	app.CompleteCheckoutService = services.NewCompleteCheckout(tracedCheckoutRepository, tracedLoyaltyRepository, tracedProductRepository, tracedProductWithPromotionRepository, tracedProductWithDiscountRepository)
+	app.CompleteCheckoutService.Pricing = pricing
+	app.RedeemLoyaltyPointsService = services.NewRedeemLoyaltyPoints(tracedCheckoutRepository, tracedLoyaltyRepository)
+	app.RetrieveLoyaltyAccountService = services.NewRetrieveLoyaltyAccount(tracedCustomerRepository, tracedLoyaltyRepository)
+
+	app.Initialize()
+	app.HealthCheckers = repositoryHealthCheckers(map[string]interface{}{
+		"checkouts":               checkoutRepository,
+		"products":                productRepository,
+		"products-with-promotion": productWithPromotionRepository,
+		"products-with-discount":  productWithDiscountRepository,
+		"customers":               customerRepository,
+		"saved-lists":             savedListRepository,
+		"price-lists":             priceListRepository,
+		"loyalty":                 loyaltyRepository,
+	})
+	if err := metrics.RegisterCheckouts(checkoutRepository); err != nil {
+		log.Fatal(err)
+	}
+	var limiter ratelimit.Limiter
+	if config.RateLimit > 0 {
+		limiter = ratelimit.NewTokenBucket(config.RateLimit, config.RateBurst)
+		app.Limiter = limiter
+	}
+	grpcInterceptors := []grpc.UnaryServerInterceptor{rpc.Authenticate(authenticator, limiter)}
+	if limiter != nil {
+		grpcInterceptors = append(grpcInterceptors, rpc.Limit(limiter))
+	}
+	grpcServer := rpc.NewServer(rpc.NewCheckoutServer(app.CreateCheckoutService, app.AddProductToCheckoutService, app.RetrieveCheckoutAmountService, app.DeleteCheckoutService), grpc.ChainUnaryInterceptor(grpcInterceptors...))
+	go runGRPC(config.GRPCAddr, grpcServer)
+
+	ctx, stop := context.WithCancel(context.Background())
+	go stopOnSignal(stop, syscall.SIGINT, syscall.SIGTERM)
+	if err := app.Run(ctx, config); err != nil {
+		if ctx.Err() == nil {
+			log.Fatal(err)
+		}
+		log.Println("HTTP shutdown failed:", err)
+	}
+	stopGRPC(grpcServer, config.ShutdownTimeout)
+
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancelFlush()
+	if err := flushRepositories(flushCtx, checkoutRepository, productRepository, productWithPromotionRepository, productWithDiscountRepository, customerRepository, savedListRepository, priceListRepository, loyaltyRepository); err != nil {
+		log.Fatal(err)
+	}
+	if config.TraceExporter != tracing.ExporterNone {
+		if err := tracerProvider.Shutdown(flushCtx); err != nil {
+			log.Fatal(err)
+		}
+	}
+	log.Println("Shutdown completed")
+}
+
+func runGRPC(addr string, grpcServer *grpc.Server) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("Serving gRPC at", addr)
+	if err := grpcServer.Serve(listener); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// stopGRPC stops the server once its calls are served, or right away once
+// the timeout expires.
+func stopGRPC(grpcServer *grpc.Server, timeout time.Duration) {
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		log.Println("gRPC shutdown timed out, stopping pending calls")
+		grpcServer.Stop()
+	}
+}
+
+func stopOnSignal(stop context.CancelFunc, signals ...os.Signal) {
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+	log.Println("Received", <-received)
+	signal.Stop(received)
+	stop()
+}
+
+// flushRepositories flushes every repository with buffered changes, so
+// they are not lost on shutdown.
+func flushRepositories(ctx context.Context, repositories ...interface{}) error {
+	for _, repository := range repositories {
+		if flusher, buffered := repository.(persistence.Flusher); buffered {
+			if err := flusher.Flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
 
-	app.Initialize(createCheckoutService, addProductToCheckoutService, deleteCheckoutService, retrieveCheckoutAmountService)
-	app.Run(":3080")
+// repositoryHealthCheckers returns the repositories, by name, that can
+// check their backend.
+func repositoryHealthCheckers(repositories map[string]interface{}) map[string]persistence.HealthChecker {
+	healthCheckers := make(map[string]persistence.HealthChecker)
+	for name, repository := range repositories {
+		if healthChecker, checkable := repository.(persistence.HealthChecker); checkable {
+			healthCheckers[name] = healthChecker
+		}
+	}
+	return healthCheckers
 }
 
 func populate_checkouts() persistence.CheckoutRepository {
@@ -24,6 +204,26 @@ func populate_checkouts() persistence.CheckoutRepository {
 	return persistence.NewCheckoutRepository(checkouts)
 }
 
+func populate_customers() persistence.CustomerRepository {
+	customers := make(map[string]models.Customer)
+	return persistence.NewCustomerRepository(customers)
+}
+
+func populate_saved_lists() persistence.SavedListRepository {
+	savedLists := make(map[string]models.SavedList)
+	return persistence.NewSavedListRepository(savedLists)
+}
+
+func populate_price_lists() persistence.PriceListRepository {
+	priceLists := make(map[string]models.PriceList)
+	return persistence.NewPriceListRepository(priceLists)
+}
+
+func populate_loyalty() persistence.LoyaltyRepository {
+	ledgers := make(map[string][]models.LoyaltyTransaction)
+	return persistence.NewLoyaltyRepository(ledgers)
+}
+
 func populate_products() persistence.ProductRepository {
 	products := make(map[string]models.Product)
 	pen := models.Product{
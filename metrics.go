@@ -0,0 +1,41 @@
+package main
+
+import (
+	"lana/flagship-store/metrics"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// unmatchedRoute labels the requests that match no route, so unknown
+// paths do not create new series.
+const unmatchedRoute = "unmatched"
+
+// instrument records the count and latency of the requests by method,
+// route template and status.
+func instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		start := time.Now()
+		recorder := newStatusRecorder(response)
+
+		next.ServeHTTP(recorder, request)
+
+		labels := []string{request.Method, routeTemplate(request), strconv.Itoa(recorder.status)}
+		metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+	})
+}
+
+func routeTemplate(request *http.Request) string {
+	route := mux.CurrentRoute(request)
+	if route == nil {
+		return unmatchedRoute
+	}
+	pathTemplate, err := route.GetPathTemplate()
+	if err != nil {
+		return unmatchedRoute
+	}
+	return pathTemplate
+}
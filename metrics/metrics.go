@@ -0,0 +1,77 @@
+// Package metrics defines the Prometheus metrics of the store, registered
+// in Registry and served by Handler.
+package metrics
+
+import (
+	"context"
+	"lana/flagship-store/persistence"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "flagship_store"
+
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the HTTP requests by method, route template and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	CheckoutsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "checkouts_created_total",
+		Help:      "Checkouts created.",
+	})
+
+	ProductsAdded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "products_added_total",
+		Help:      "Units of products added to checkouts by product code.",
+	}, []string{"product_code"})
+
+	PromotionSavings = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "promotion_savings_cents_total",
+		Help:      "Savings in cents of the promotions applied to completed checkouts, by product code.",
+	}, []string{"product_code"})
+)
+
+func init() {
+	Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		CheckoutsCreated,
+		ProductsAdded,
+		PromotionSavings,
+	)
+}
+
+// RegisterCheckouts registers the gauge of the current checkouts, counted
+// in the repository on every scrape.
+func RegisterCheckouts(checkoutRepository persistence.CheckoutRepository) error {
+	return Registry.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "checkouts",
+		Help:      "Current checkouts.",
+	}, func() float64 {
+		return float64(checkoutRepository.Count(context.Background()))
+	}))
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
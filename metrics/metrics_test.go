@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckoutsGaugeCountsRepositoryCheckouts(t *testing.T) {
+	checkoutRepository := persistence.NewCheckoutRepository(map[string]models.Checkout{
+		"a": {Id: "a"},
+		"b": {Id: "b"},
+	})
+
+	err := RegisterCheckouts(checkoutRepository)
+
+	assert.Nil(t, err)
+	expected := `
+		# HELP flagship_store_checkouts Current checkouts.
+		# TYPE flagship_store_checkouts gauge
+		flagship_store_checkouts 2
+	`
+	assert.Nil(t, testutil.GatherAndCompare(Registry, strings.NewReader(expected), "flagship_store_checkouts"))
+}
@@ -1,6 +1,24 @@
 package models
 
+import "time"
+
+const (
+	CheckoutStatusOpen      = "open"
+	CheckoutStatusCompleted = "completed"
+
+	MaxProductQuantity = 99
+)
+
 type Checkout struct {
-	Id       string   `json:"id"`
-	Products []string `json:"products"`
+	Id             string    `json:"id"`
+	Products       []string  `json:"products"`
+	Status         string    `json:"status"`
+	Owner          string    `json:"owner,omitempty"`
+	CustomerId     string    `json:"customer-id,omitempty"`
+	RedeemedPoints int       `json:"redeemed-points,omitempty"`
+	CreatedAt      time.Time `json:"created-at"`
+	UpdatedAt      time.Time `json:"updated-at"`
+	// Version is bumped by every conditional write of the checkout, so a
+	// change made from a stale copy is refused.
+	Version int `json:"-"`
 }
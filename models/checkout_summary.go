@@ -0,0 +1,20 @@
+package models
+
+type CheckoutLine struct {
+	Product  Product
+	Quantity int
+	Subtotal int
+	Amount   int
+}
+
+// CheckoutSummary is a checkout with its lines and totals. The amount is
+// the one of the lines less the points discount, the value of the loyalty
+// points redeemed, never more than the amount of the lines.
+type CheckoutSummary struct {
+	Checkout       Checkout
+	Lines          []CheckoutLine
+	Subtotal       int
+	RedeemedPoints int
+	PointsDiscount int
+	Amount         int
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+type Customer struct {
+	Id        string    `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Group     string    `json:"group,omitempty"`
+	Owner     string    `json:"owner,omitempty"`
+	CreatedAt time.Time `json:"created-at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+const (
+	LoyaltyTransactionEarn   = "earn"
+	LoyaltyTransactionRedeem = "redeem"
+
+	// LoyaltyPointsPerEuro are the points earned for every whole euro paid.
+	LoyaltyPointsPerEuro = 1
+	// LoyaltyPointValue is the discount, in cents, of every point redeemed.
+	LoyaltyPointValue = 1
+)
+
+// LoyaltyTransaction is an entry of the ledger of the loyalty points of a
+// customer: the points earned completing a checkout, positive, or the
+// points redeemed on it, negative.
+type LoyaltyTransaction struct {
+	Id         string    `json:"id"`
+	CustomerId string    `json:"customer-id"`
+	CheckoutId string    `json:"checkout-id"`
+	Kind       string    `json:"kind"`
+	Points     int       `json:"points"`
+	CreatedAt  time.Time `json:"created-at"`
+}
+
+// LoyaltyAccount is the balance of loyalty points of a customer and the
+// ledger of its transactions, oldest first.
+type LoyaltyAccount struct {
+	CustomerId   string               `json:"customer-id"`
+	Balance      int                  `json:"balance"`
+	Transactions []LoyaltyTransaction `json:"transactions"`
+}
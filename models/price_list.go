@@ -0,0 +1,12 @@
+package models
+
+// PriceList overrides the catalog price of some products, in cents, for a
+// customer or for every customer of a group, e.g. the prices negotiated
+// with a B2B customer.
+type PriceList struct {
+	Id         string         `json:"id"`
+	Name       string         `json:"name"`
+	CustomerId string         `json:"customer-id,omitempty"`
+	Group      string         `json:"group,omitempty"`
+	Prices     map[string]int `json:"prices"`
+}
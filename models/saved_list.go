@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+const (
+	SavedListKindCart     = "cart"
+	SavedListKindWishlist = "wishlist"
+)
+
+// SavedList is a basket saved for later or a wishlist of products. Saved
+// lists are kept apart from the checkouts and are turned into a new
+// checkout when the shopper is ready to buy.
+type SavedList struct {
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	Products  []string  `json:"products"`
+	Owner     string    `json:"owner,omitempty"`
+	CreatedAt time.Time `json:"created-at"`
+}
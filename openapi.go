@@ -0,0 +1,390 @@
+package main
+
+import (
+	"encoding/json"
+	"lana/flagship-store/graph"
+	"lana/flagship-store/models"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/responses"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type apiParameter struct {
+	name        string
+	description string
+	schema      map[string]interface{}
+}
+
+type apiOperation struct {
+	summary    string
+	parameters []apiParameter
+	request    interface{}
+	responses  map[int]interface{}
+	public     bool
+	idempotent bool
+}
+
+// apiOperations documents every route of the router, keyed by version,
+// method and path template without version prefix. Routes without an
+// operation make TestEveryRouteIsDocumented fail.
+var apiOperations = map[string]map[string]apiOperation{
+	"v1": {
+		"POST /checkouts": {
+			summary:    "Create a checkout with a product",
+			request:    commands.Product{},
+			responses:  map[int]interface{}{http.StatusCreated: models.Checkout{}},
+			idempotent: true,
+		},
+		"PATCH /checkouts/{id}": {
+			summary:    "Add a product to a checkout",
+			request:    commands.AddProduct{},
+			responses:  map[int]interface{}{http.StatusNoContent: nil, http.StatusOK: responses.CheckoutDetail{}},
+			idempotent: true,
+		},
+	},
+	"v2": {
+		"POST /checkouts": {
+			summary:    "Create a checkout, empty or with some lines",
+			request:    commands.CreateCheckout{},
+			responses:  map[int]interface{}{http.StatusCreated: models.Checkout{}},
+			idempotent: true,
+		},
+		"PATCH /checkouts/{id}": {
+			summary:    "Add some units of a product to a checkout",
+			request:    commands.Line{},
+			responses:  map[int]interface{}{http.StatusNoContent: nil, http.StatusOK: responses.CheckoutDetail{}},
+			idempotent: true,
+		},
+	},
+	"": {
+		"GET /checkouts": {
+			summary: "List checkouts",
+			parameters: []apiParameter{
+				{"product", "Only checkouts containing the product code", stringSchema()},
+				{"status", "Only checkouts with the status", stringSchema()},
+				{"created-from", "Only checkouts created from the time", dateTimeSchema()},
+				{"created-to", "Only checkouts created before the time", dateTimeSchema()},
+				{"sort", "created-at or id, prefixed with - for descending order", stringSchema()},
+				{"cursor", "next-cursor of the previous page", stringSchema()},
+				{"limit", "Page size, up to 100", map[string]interface{}{"type": "integer"}},
+			},
+			responses: map[int]interface{}{http.StatusOK: responses.CheckoutsPage{}},
+		},
+		"POST /checkouts/{id}/merge": {
+			summary:   "Merge a source checkout into the checkout, deleting the source",
+			request:   commands.MergeCheckouts{},
+			responses: map[int]interface{}{http.StatusOK: responses.CheckoutDetail{}},
+		},
+		"POST /saved-lists": {
+			summary:   "Save a basket for later or a wishlist, from lines or from the products of a checkout",
+			request:   commands.CreateSavedList{},
+			responses: map[int]interface{}{http.StatusCreated: models.SavedList{}},
+		},
+		"GET /saved-lists": {
+			summary:   "List the saved lists",
+			responses: map[int]interface{}{http.StatusOK: responses.SavedLists{}},
+		},
+		"POST /saved-lists/{id}/checkouts": {
+			summary:    "Create a checkout with the products of a saved list still sold",
+			responses:  map[int]interface{}{http.StatusCreated: responses.SavedListCheckout{}},
+			idempotent: true,
+		},
+		"POST /price-lists": {
+			summary:   "Create the prices of a customer or of a customer group",
+			request:   commands.CreatePriceList{},
+			responses: map[int]interface{}{http.StatusCreated: models.PriceList{}},
+		},
+		"GET /price-lists": {
+			summary:   "List the price lists",
+			responses: map[int]interface{}{http.StatusOK: responses.PriceLists{}},
+		},
+		"POST /customers": {
+			summary:   "Register a customer",
+			request:   commands.RegisterCustomer{},
+			responses: map[int]interface{}{http.StatusCreated: models.Customer{}},
+		},
+		"GET /customers/{id}": {
+			summary:   "Retrieve a customer",
+			responses: map[int]interface{}{http.StatusOK: models.Customer{}},
+		},
+		"PUT /checkouts/{id}/customer": {
+			summary:   "Attach a checkout to a customer, merging it into the open basket of the customer",
+			request:   commands.AttachCustomer{},
+			responses: map[int]interface{}{http.StatusOK: responses.CheckoutDetail{}},
+		},
+		"POST /checkouts/{id}/complete": {
+			summary:    "Complete the order of a checkout, earning and spending the loyalty points of its customer",
+			responses:  map[int]interface{}{http.StatusOK: responses.CheckoutDetail{}},
+			idempotent: true,
+		},
+		"PUT /checkouts/{id}/loyalty-points": {
+			summary:   "Redeem loyalty points of the customer as a discount on the checkout",
+			request:   commands.RedeemPoints{},
+			responses: map[int]interface{}{http.StatusOK: responses.CheckoutDetail{}},
+		},
+		"GET /customers/{id}/loyalty": {
+			summary:   "Retrieve the loyalty points balance and ledger of a customer",
+			responses: map[int]interface{}{http.StatusOK: models.LoyaltyAccount{}},
+		},
+		"GET /checkouts/{id}": {
+			summary:   "Retrieve a checkout with its lines and totals",
+			responses: map[int]interface{}{http.StatusOK: responses.CheckoutDetail{}},
+		},
+		"DELETE /checkouts/{id}": {
+			summary:   "Delete a checkout",
+			responses: map[int]interface{}{http.StatusNoContent: nil},
+		},
+		"GET /checkouts/{id}/amount": {
+			summary:   "Retrieve the amount of a checkout",
+			responses: map[int]interface{}{http.StatusOK: responses.Checkout{}},
+		},
+		"POST /checkouts/amounts": {
+			summary:   "Retrieve the amount of many checkouts",
+			request:   commands.Checkouts{},
+			responses: map[int]interface{}{http.StatusOK: responses.CheckoutsAmount{}},
+		},
+		"POST /graphql": {
+			summary:   "Execute a GraphQL query or mutation",
+			request:   graph.Request{},
+			responses: map[int]interface{}{http.StatusOK: nil},
+		},
+		"GET /healthz": {
+			summary:   "Check the app is alive",
+			responses: map[int]interface{}{http.StatusOK: responses.Health{}},
+			public:    true,
+		},
+		"GET /readyz": {
+			summary:   "Check the app and its repositories are ready to serve",
+			responses: map[int]interface{}{http.StatusOK: responses.Health{}, http.StatusServiceUnavailable: responses.Health{}},
+			public:    true,
+		},
+		"GET /version": {
+			summary:   "Retrieve the version and commit of the build",
+			responses: map[int]interface{}{http.StatusOK: responses.Version{}},
+			public:    true,
+		},
+		"GET /metrics": {
+			summary:   "Retrieve the metrics in Prometheus text format",
+			responses: map[int]interface{}{http.StatusOK: nil},
+			public:    true,
+		},
+		"GET /openapi.json": {
+			summary:   "Retrieve this OpenAPI document",
+			responses: map[int]interface{}{http.StatusOK: nil},
+			public:    true,
+		},
+	},
+}
+
+var versionPrefix = regexp.MustCompile(`^/(v[0-9]+)(/.*)$`)
+var pathParameter = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+func findAPIOperation(method string, pathTemplate string) (apiOperation, bool) {
+	version, path := "v1", pathTemplate
+	if matches := versionPrefix.FindStringSubmatch(pathTemplate); matches != nil {
+		version, path = matches[1], matches[2]
+	}
+	if operation, found := apiOperations[version][method+" "+path]; found {
+		return operation, true
+	}
+	operation, found := apiOperations[""][method+" "+path]
+	return operation, found
+}
+
+// walkAPIRoutes calls walkFn with every method and path template served by
+// the router.
+func walkAPIRoutes(router *mux.Router, walkFn func(method string, pathTemplate string)) {
+	router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		pathTemplate, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			walkFn(method, pathTemplate)
+		}
+		return nil
+	})
+}
+
+func newOpenAPIDocument(router *mux.Router) map[string]interface{} {
+	schemas := map[string]interface{}{}
+	paths := map[string]map[string]interface{}{}
+	walkAPIRoutes(router, func(method string, pathTemplate string) {
+		operation, found := findAPIOperation(method, pathTemplate)
+		if !found {
+			return
+		}
+		if paths[pathTemplate] == nil {
+			paths[pathTemplate] = map[string]interface{}{}
+		}
+		paths[pathTemplate][strings.ToLower(method)] = newOpenAPIOperation(operation, pathTemplate, schemas)
+	})
+
+	schemas["responses.Problem"] = newSchema(reflect.TypeOf(responses.Problem{}), schemas)
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Lana Flagship Store",
+			"version": "2",
+		},
+		"paths":    paths,
+		"security": []interface{}{map[string]interface{}{"apiKey": []string{}}, map[string]interface{}{"bearer": []string{}}},
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"apiKey": map[string]interface{}{"type": "apiKey", "in": "header", "name": apiKeyHeader},
+				"bearer": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+}
+
+func newOpenAPIOperation(operation apiOperation, pathTemplate string, schemas map[string]interface{}) map[string]interface{} {
+	parameters := []interface{}{}
+	for _, match := range pathParameter.FindAllStringSubmatch(pathTemplate, -1) {
+		parameters = append(parameters, map[string]interface{}{"name": match[1], "in": "path", "required": true, "schema": stringSchema()})
+	}
+	for _, parameter := range operation.parameters {
+		parameters = append(parameters, map[string]interface{}{"name": parameter.name, "in": "query", "description": parameter.description, "schema": parameter.schema})
+	}
+	if operation.idempotent {
+		parameters = append(parameters, map[string]interface{}{"name": idempotencyKeyHeader, "in": "header", "description": "Key replaying the response of the first request with it", "schema": stringSchema()})
+	}
+
+	operationResponses := map[string]interface{}{
+		"default": map[string]interface{}{
+			"description": "Problem",
+			"content":     map[string]interface{}{problemContentType: map[string]interface{}{"schema": schemaRef("responses.Problem")}},
+		},
+	}
+	for status, body := range operation.responses {
+		operationResponse := map[string]interface{}{"description": http.StatusText(status)}
+		if body != nil {
+			operationResponse["content"] = map[string]interface{}{"application/json": map[string]interface{}{"schema": newSchema(reflect.TypeOf(body), schemas)}}
+		}
+		operationResponses[strconv.Itoa(status)] = operationResponse
+	}
+
+	openAPIOperation := map[string]interface{}{
+		"summary":    operation.summary,
+		"parameters": parameters,
+		"responses":  operationResponses,
+	}
+	if operation.public {
+		openAPIOperation["security"] = []interface{}{}
+	}
+	if operation.request != nil {
+		openAPIOperation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": newSchema(reflect.TypeOf(operation.request), schemas)}},
+		}
+	}
+	return openAPIOperation
+}
+
+// newSchema describes a Go type as a JSON schema from its JSON encoding.
+// Named structs are registered once in schemas and referenced.
+func newSchema(goType reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	switch {
+	case goType == reflect.TypeOf(time.Time{}):
+		return dateTimeSchema()
+	case goType.Kind() == reflect.Ptr:
+		return newSchema(goType.Elem(), schemas)
+	case goType.Kind() == reflect.String:
+		return stringSchema()
+	case goType.Kind() == reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case goType.Kind() >= reflect.Int && goType.Kind() <= reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case goType.Kind() == reflect.Float32 || goType.Kind() == reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case goType.Kind() == reflect.Slice || goType.Kind() == reflect.Array:
+		return map[string]interface{}{"type": "array", "items": newSchema(goType.Elem(), schemas)}
+	case goType.Kind() == reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": newSchema(goType.Elem(), schemas)}
+	case goType.Kind() == reflect.Struct:
+		name := goType.String()
+		if _, registered := schemas[name]; !registered {
+			schemas[name] = nil
+			schemas[name] = newObjectSchema(goType, schemas)
+		}
+		return schemaRef(name)
+	}
+	return map[string]interface{}{}
+}
+
+func newObjectSchema(goType reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	addObjectProperties(goType, schemas, properties, &required)
+	sort.Strings(required)
+
+	objectSchema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		objectSchema["required"] = required
+	}
+	return objectSchema
+}
+
+// addObjectProperties adds the fields of the struct to the properties,
+// required unless omitted when empty or tagged `openapi:"optional"`.
+func addObjectProperties(goType reflect.Type, schemas map[string]interface{}, properties map[string]interface{}, required *[]string) {
+	for position := 0; position < goType.NumField(); position++ {
+		field := goType.Field(position)
+		if field.Anonymous {
+			addObjectProperties(field.Type, schemas, properties, required)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name, options := field.Name, ""
+		if tag, tagged := field.Tag.Lookup("json"); tagged {
+			tagParts := strings.SplitN(tag, ",", 2)
+			if tagParts[0] == "-" {
+				continue
+			}
+			if tagParts[0] != "" {
+				name = tagParts[0]
+			}
+			if len(tagParts) == 2 {
+				options = tagParts[1]
+			}
+		}
+		properties[name] = newSchema(field.Type, schemas)
+		if !strings.Contains(options, "omitempty") && field.Tag.Get("openapi") != "optional" {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func schemaRef(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+func stringSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "string"}
+}
+
+func dateTimeSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "string", "format": "date-time"}
+}
+
+func (app *App) retrieveOpenAPI(response http.ResponseWriter, request *http.Request) {
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(newOpenAPIDocument(app.Router))
+}
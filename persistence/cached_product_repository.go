@@ -0,0 +1,33 @@
+package persistence
+
+import (
+	"context"
+	"lana/flagship-store/models"
+)
+
+type cachedProductSearch struct {
+	product models.Product
+	exists  bool
+}
+
+type CachedProductRepository struct {
+	repository ProductRepository
+	searches   map[string]cachedProductSearch
+}
+
+func NewCachedProductRepository(repository ProductRepository) *CachedProductRepository {
+	return &CachedProductRepository{repository, make(map[string]cachedProductSearch)}
+}
+
+func (repository *CachedProductRepository) SearchById(ctx context.Context, id string) (models.Product, bool) {
+	if search, cached := repository.searches[id]; cached {
+		return search.product, search.exists
+	}
+	product, exists := repository.repository.SearchById(ctx, id)
+	repository.searches[id] = cachedProductSearch{product, exists}
+	return product, exists
+}
+
+func (repository *CachedProductRepository) SearchAll(ctx context.Context) []models.Product {
+	return repository.repository.SearchAll(ctx)
+}
@@ -0,0 +1,42 @@
+package persistence_test
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchByIdReturnProductFromRepositoryOnlyOnce(t *testing.T) {
+	pen := models.Product{
+		Code:  "PEN",
+		Name:  "Lana Pen",
+		Price: 500,
+	}
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "PEN").Return(pen, true)
+	cachedProductRepository := persistence.NewCachedProductRepository(&theProductRepositoryMock)
+
+	cachedProductRepository.SearchById(context.Background(), "PEN")
+	product, exists := cachedProductRepository.SearchById(context.Background(), "PEN")
+
+	assert.EqualValues(t, true, exists)
+	assert.EqualValues(t, pen, product)
+	theProductRepositoryMock.AssertNumberOfCalls(t, "SearchById", 1)
+}
+
+func TestSearchByIdCacheProductNotFound(t *testing.T) {
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "FAKE").Return(models.Product{}, false)
+	cachedProductRepository := persistence.NewCachedProductRepository(&theProductRepositoryMock)
+
+	cachedProductRepository.SearchById(context.Background(), "FAKE")
+	product, exists := cachedProductRepository.SearchById(context.Background(), "FAKE")
+
+	assert.EqualValues(t, false, exists)
+	assert.EqualValues(t, "", product.Code)
+	theProductRepositoryMock.AssertNumberOfCalls(t, "SearchById", 1)
+}
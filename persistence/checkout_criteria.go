@@ -0,0 +1,26 @@
+package persistence
+
+import "time"
+
+const (
+	SortByCreatedAt = "created-at"
+	SortById        = "id"
+)
+
+type CheckoutCursor struct {
+	CreatedAt time.Time
+	Id        string
+}
+
+type CheckoutCriteria struct {
+	ProductCode string
+	Status      string
+	Owner       string
+	CustomerId  string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	SortBy      string
+	Descending  bool
+	After       *CheckoutCursor
+	Limit       int
+}
@@ -1,10 +1,22 @@
 package persistence
 
-import "lana/flagship-store/models"
+import (
+	"context"
+	"lana/flagship-store/models"
+)
 
 type CheckoutRepository interface {
-	SearchById(id string) (models.Checkout, bool)
-	Persist(checkout models.Checkout)
-	Delete(checkout models.Checkout)
-	Count() int
+	SearchById(ctx context.Context, id string) (models.Checkout, bool)
+	Search(ctx context.Context, criteria CheckoutCriteria) []models.Checkout
+	Persist(ctx context.Context, checkout models.Checkout)
+	// PersistIfStatus persists the checkout only while the stored one still
+	// has the status and the version of the checkout, telling whether it
+	// did, so concurrent changes of a checkout can not both win. The stored
+	// checkout gets the next version.
+	PersistIfStatus(ctx context.Context, checkout models.Checkout, status string) bool
+	Delete(ctx context.Context, checkout models.Checkout)
+	// DeleteIfStatus deletes the checkout under the same conditions as
+	// PersistIfStatus, telling whether it did.
+	DeleteIfStatus(ctx context.Context, checkout models.Checkout, status string) bool
+	Count(ctx context.Context) int
 }
@@ -0,0 +1,16 @@
+package persistence
+
+import (
+	"context"
+	"lana/flagship-store/models"
+)
+
+type CustomerRepository interface {
+	SearchById(ctx context.Context, id string) (models.Customer, bool)
+	SearchByEmail(ctx context.Context, email string) (models.Customer, bool)
+	Persist(ctx context.Context, customer models.Customer)
+	// PersistIfEmailAbsent persists the customer only when no other customer
+	// has its email, telling whether it did, so concurrent registrations of
+	// an email can not both win.
+	PersistIfEmailAbsent(ctx context.Context, customer models.Customer) bool
+}
@@ -0,0 +1,9 @@
+package persistence
+
+import "context"
+
+// Flusher is implemented by the repositories that buffer changes before
+// writing them to their backend. Flush is called on shutdown.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
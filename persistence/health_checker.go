@@ -0,0 +1,9 @@
+package persistence
+
+import "context"
+
+// HealthChecker is implemented by the repositories that can check whether
+// their backend is reachable.
+type HealthChecker interface {
+	Ping(ctx context.Context) error
+}
@@ -1,28 +1,151 @@
 package persistence
 
-import "lana/flagship-store/models"
+import (
+	"context"
+	"lana/flagship-store/models"
+	"sort"
+	"strings"
+	"sync"
+)
 
 type InMemoryCheckoutRepository struct {
+	mutex     sync.RWMutex
 	checkouts map[string]models.Checkout
 }
 
 func NewCheckoutRepository(checkouts map[string]models.Checkout) *InMemoryCheckoutRepository {
-	return &InMemoryCheckoutRepository{checkouts}
+	return &InMemoryCheckoutRepository{checkouts: checkouts}
 }
 
-func (repository *InMemoryCheckoutRepository) SearchById(id string) (models.Checkout, bool) {
+func (repository *InMemoryCheckoutRepository) SearchById(ctx context.Context, id string) (models.Checkout, bool) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
 	checkout, exists := repository.checkouts[id]
 	return checkout, exists
 }
 
-func (repository *InMemoryCheckoutRepository) Persist(checkout models.Checkout) {
+func (repository *InMemoryCheckoutRepository) Search(ctx context.Context, criteria CheckoutCriteria) []models.Checkout {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	checkouts := []models.Checkout{}
+	for _, checkout := range repository.checkouts {
+		if matchesCheckoutCriteria(checkout, criteria) {
+			checkouts = append(checkouts, checkout)
+		}
+	}
+
+	sort.Slice(checkouts, func(i, j int) bool {
+		return isCheckoutSortedBefore(checkouts[i].CreatedAt.UnixNano(), checkouts[i].Id, checkouts[j].CreatedAt.UnixNano(), checkouts[j].Id, criteria)
+	})
+
+	if criteria.After != nil {
+		position := sort.Search(len(checkouts), func(i int) bool {
+			return isCheckoutSortedBefore(criteria.After.CreatedAt.UnixNano(), criteria.After.Id, checkouts[i].CreatedAt.UnixNano(), checkouts[i].Id, criteria)
+		})
+		checkouts = checkouts[position:]
+	}
+
+	if criteria.Limit > 0 && len(checkouts) > criteria.Limit {
+		checkouts = checkouts[:criteria.Limit]
+	}
+	return checkouts
+}
+
+func (repository *InMemoryCheckoutRepository) Persist(ctx context.Context, checkout models.Checkout) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.checkouts[checkout.Id] = checkout
+}
+
+func (repository *InMemoryCheckoutRepository) PersistIfStatus(ctx context.Context, checkout models.Checkout, status string) bool {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if !repository.hasStatus(checkout, status) {
+		return false
+	}
+	checkout.Version++
 	repository.checkouts[checkout.Id] = checkout
+	return true
+}
+
+func (repository *InMemoryCheckoutRepository) Delete(ctx context.Context, checkout models.Checkout) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	delete(repository.checkouts, checkout.Id)
 }
 
-func (repository *InMemoryCheckoutRepository) Delete(checkout models.Checkout) {
+func (repository *InMemoryCheckoutRepository) DeleteIfStatus(ctx context.Context, checkout models.Checkout, status string) bool {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if !repository.hasStatus(checkout, status) {
+		return false
+	}
 	delete(repository.checkouts, checkout.Id)
+	return true
 }
 
-func (repository *InMemoryCheckoutRepository) Count() int {
+// hasStatus tells whether the stored checkout has the status and has not
+// been changed since the checkout was read. The mutex must be held.
+func (repository *InMemoryCheckoutRepository) hasStatus(checkout models.Checkout, status string) bool {
+	stored, exists := repository.checkouts[checkout.Id]
+	return exists && stored.Status == status && stored.Version == checkout.Version
+}
+
+func (repository *InMemoryCheckoutRepository) Count(ctx context.Context) int {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
 	return len(repository.checkouts)
 }
+
+func matchesCheckoutCriteria(checkout models.Checkout, criteria CheckoutCriteria) bool {
+	if criteria.Owner != "" && checkout.Owner != criteria.Owner {
+		return false
+	}
+	if criteria.CustomerId != "" && checkout.CustomerId != criteria.CustomerId {
+		return false
+	}
+	if criteria.Status != "" && checkout.Status != criteria.Status {
+		return false
+	}
+	if !criteria.CreatedFrom.IsZero() && checkout.CreatedAt.Before(criteria.CreatedFrom) {
+		return false
+	}
+	if !criteria.CreatedTo.IsZero() && !checkout.CreatedAt.Before(criteria.CreatedTo) {
+		return false
+	}
+	if criteria.ProductCode == "" {
+		return true
+	}
+	for _, productCode := range checkout.Products {
+		if productCode == criteria.ProductCode {
+			return true
+		}
+	}
+	return false
+}
+
+func isCheckoutSortedBefore(createdAt int64, id string, otherCreatedAt int64, otherId string, criteria CheckoutCriteria) bool {
+	comparison := strings.Compare(id, otherId)
+	if criteria.SortBy != SortById && createdAt != otherCreatedAt {
+		comparison = -1
+		if createdAt > otherCreatedAt {
+			comparison = 1
+		}
+	}
+	if criteria.Descending {
+		return comparison > 0
+	}
+	return comparison < 0
+}
+
+// Ping always succeeds, the checkouts are kept in memory.
+func (repository *InMemoryCheckoutRepository) Ping(ctx context.Context) error {
+	return nil
+}
@@ -1,8 +1,10 @@
 package persistence
 
 import (
+	"context"
 	"lana/flagship-store/models"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -16,9 +18,9 @@ func TestSearchByIdReturnCheckoutWhenCheckoutExists(t *testing.T) {
 	}
 	checkouts := map[string]models.Checkout{checkout.Id: checkout}
 
-	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts}
+	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts: checkouts}
 
-	checkoutRetrieved, exists := inMemoryCheckoutRepository.SearchById(checkout_id)
+	checkoutRetrieved, exists := inMemoryCheckoutRepository.SearchById(context.Background(), checkout_id)
 
 	assert.EqualValues(t, true, exists)
 	assert.EqualValues(t, checkout, checkoutRetrieved)
@@ -26,9 +28,9 @@ func TestSearchByIdReturnCheckoutWhenCheckoutExists(t *testing.T) {
 
 func TestSearchByIdReturnEmptyCheckoutWhenCheckoutDoesNotExist(t *testing.T) {
 	checkouts := make(map[string]models.Checkout)
-	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts}
+	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts: checkouts}
 
-	checkoutRetrieved, exists := inMemoryCheckoutRepository.SearchById("an_id")
+	checkoutRetrieved, exists := inMemoryCheckoutRepository.SearchById(context.Background(), "an_id")
 
 	assert.EqualValues(t, false, exists)
 	assert.EqualValues(t, "", checkoutRetrieved.Id)
@@ -41,9 +43,9 @@ func TestPersistCreateCheckoutWhenCheckoutDoesNotExist(t *testing.T) {
 		Products: []string{"PEN"},
 	}
 	checkouts := make(map[string]models.Checkout)
-	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts}
+	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts: checkouts}
 
-	inMemoryCheckoutRepository.Persist(checkout)
+	inMemoryCheckoutRepository.Persist(context.Background(), checkout)
 
 	assert.EqualValues(t, 1, len(checkouts))
 	assert.EqualValues(t, checkout, checkouts[checkout_id])
@@ -56,10 +58,10 @@ func TestPersistUpdateCheckoutWhenCheckoutExist(t *testing.T) {
 		Products: []string{"PEN"},
 	}
 	checkouts := map[string]models.Checkout{checkout.Id: checkout}
-	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts}
+	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts: checkouts}
 	checkout.Products = append(checkout.Products, "MUG")
 
-	inMemoryCheckoutRepository.Persist(checkout)
+	inMemoryCheckoutRepository.Persist(context.Background(), checkout)
 
 	modifiedCheckout := checkouts[checkout_id]
 	assert.EqualValues(t, 1, len(checkouts))
@@ -74,9 +76,9 @@ func TestDeleteRemoveCheckoutWhenCheckoutExist(t *testing.T) {
 		Products: []string{"PEN"},
 	}
 	checkouts := map[string]models.Checkout{checkout.Id: checkout}
-	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts}
+	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts: checkouts}
 
-	inMemoryCheckoutRepository.Delete(checkout)
+	inMemoryCheckoutRepository.Delete(context.Background(), checkout)
 
 	assert.EqualValues(t, 0, len(checkouts))
 }
@@ -87,9 +89,9 @@ func TestDeleteDoesNothingWhenCheckoutDoesNotExist(t *testing.T) {
 		Products: []string{"PEN"},
 	}
 	checkouts := make(map[string]models.Checkout)
-	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts}
+	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts: checkouts}
 
-	inMemoryCheckoutRepository.Delete(checkout)
+	inMemoryCheckoutRepository.Delete(context.Background(), checkout)
 
 	assert.EqualValues(t, 0, len(checkouts))
 }
@@ -100,18 +102,170 @@ func TestCountReturnNumberOfCheckoutsWhenCheckoutExist(t *testing.T) {
 		Products: []string{"PEN"},
 	}
 	checkouts := map[string]models.Checkout{checkout.Id: checkout}
-	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts}
+	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts: checkouts}
 
-	count := inMemoryCheckoutRepository.Count()
+	count := inMemoryCheckoutRepository.Count(context.Background())
 
 	assert.EqualValues(t, 1, count)
 }
 
 func TestCountReturnZeroWhenCheckoutDoesNotExist(t *testing.T) {
 	checkouts := make(map[string]models.Checkout)
-	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts}
+	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts: checkouts}
 
-	count := inMemoryCheckoutRepository.Count()
+	count := inMemoryCheckoutRepository.Count(context.Background())
 
 	assert.EqualValues(t, 0, count)
 }
+
+func checkoutsCreatedInSequence() map[string]models.Checkout {
+	createdAt := time.Date(2021, time.March, 1, 10, 0, 0, 0, time.UTC)
+	first := models.Checkout{Id: "c", Products: []string{"PEN"}, Status: models.CheckoutStatusOpen, CreatedAt: createdAt}
+	second := models.Checkout{Id: "a", Products: []string{"MUG", "PEN"}, Status: models.CheckoutStatusOpen, CreatedAt: createdAt.Add(time.Hour)}
+	third := models.Checkout{Id: "b", Products: []string{"TSHIRT"}, Status: models.CheckoutStatusOpen, CreatedAt: createdAt.Add(2 * time.Hour)}
+	return map[string]models.Checkout{first.Id: first, second.Id: second, third.Id: third}
+}
+
+func TestSearchReturnCheckoutsSortedByCreationTime(t *testing.T) {
+	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts: checkoutsCreatedInSequence()}
+
+	checkouts := inMemoryCheckoutRepository.Search(context.Background(), CheckoutCriteria{SortBy: SortByCreatedAt})
+
+	assert.EqualValues(t, 3, len(checkouts))
+	assert.EqualValues(t, "c", checkouts[0].Id)
+	assert.EqualValues(t, "a", checkouts[1].Id)
+	assert.EqualValues(t, "b", checkouts[2].Id)
+}
+
+func TestSearchReturnCheckoutsSortedByIdDescending(t *testing.T) {
+	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts: checkoutsCreatedInSequence()}
+
+	checkouts := inMemoryCheckoutRepository.Search(context.Background(), CheckoutCriteria{SortBy: SortById, Descending: true})
+
+	assert.EqualValues(t, "c", checkouts[0].Id)
+	assert.EqualValues(t, "b", checkouts[1].Id)
+	assert.EqualValues(t, "a", checkouts[2].Id)
+}
+
+func TestSearchReturnCheckoutsContainingProduct(t *testing.T) {
+	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts: checkoutsCreatedInSequence()}
+
+	checkouts := inMemoryCheckoutRepository.Search(context.Background(), CheckoutCriteria{ProductCode: "PEN", SortBy: SortByCreatedAt})
+
+	assert.EqualValues(t, 2, len(checkouts))
+	assert.EqualValues(t, "c", checkouts[0].Id)
+	assert.EqualValues(t, "a", checkouts[1].Id)
+}
+
+func TestSearchReturnCheckoutsCreatedInTimeRange(t *testing.T) {
+	checkouts := checkoutsCreatedInSequence()
+	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts: checkouts}
+	criteria := CheckoutCriteria{
+		CreatedFrom: checkouts["a"].CreatedAt,
+		CreatedTo:   checkouts["b"].CreatedAt,
+	}
+
+	checkoutsFound := inMemoryCheckoutRepository.Search(context.Background(), criteria)
+
+	assert.EqualValues(t, 1, len(checkoutsFound))
+	assert.EqualValues(t, "a", checkoutsFound[0].Id)
+}
+
+func TestSearchReturnNoCheckoutsWhenStatusDoesNotMatch(t *testing.T) {
+	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts: checkoutsCreatedInSequence()}
+
+	checkouts := inMemoryCheckoutRepository.Search(context.Background(), CheckoutCriteria{Status: "completed"})
+
+	assert.EqualValues(t, 0, len(checkouts))
+}
+
+func TestSearchReturnCheckoutsOfTheOwner(t *testing.T) {
+	checkouts := checkoutsCreatedInSequence()
+	owned := checkouts["a"]
+	owned.Owner = "jwt:a-shopper"
+	checkouts[owned.Id] = owned
+	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts: checkouts}
+
+	checkoutsFound := inMemoryCheckoutRepository.Search(context.Background(), CheckoutCriteria{Owner: "jwt:a-shopper"})
+
+	assert.EqualValues(t, 1, len(checkoutsFound))
+	assert.EqualValues(t, "a", checkoutsFound[0].Id)
+}
+
+func TestSearchReturnCheckoutsOfTheCustomer(t *testing.T) {
+	checkouts := checkoutsCreatedInSequence()
+	attached := checkouts["b"]
+	attached.CustomerId = "a-customer"
+	checkouts[attached.Id] = attached
+	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts: checkouts}
+
+	checkoutsFound := inMemoryCheckoutRepository.Search(context.Background(), CheckoutCriteria{CustomerId: "a-customer"})
+
+	assert.EqualValues(t, 1, len(checkoutsFound))
+	assert.EqualValues(t, "b", checkoutsFound[0].Id)
+}
+
+func TestSearchReturnCheckoutsAfterCursorUpToLimit(t *testing.T) {
+	checkouts := checkoutsCreatedInSequence()
+	inMemoryCheckoutRepository := &InMemoryCheckoutRepository{checkouts: checkouts}
+	criteria := CheckoutCriteria{
+		SortBy: SortByCreatedAt,
+		After:  &CheckoutCursor{CreatedAt: checkouts["c"].CreatedAt, Id: "c"},
+		Limit:  1,
+	}
+
+	checkoutsFound := inMemoryCheckoutRepository.Search(context.Background(), criteria)
+
+	assert.EqualValues(t, 1, len(checkoutsFound))
+	assert.EqualValues(t, "a", checkoutsFound[0].Id)
+}
+
+func TestPersistIfStatusOnlyWhileTheStoredCheckoutHasTheStatus(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", Status: models.CheckoutStatusOpen}
+	inMemoryCheckoutRepository := NewCheckoutRepository(map[string]models.Checkout{checkout.Id: checkout})
+	completed := checkout
+	completed.Status = models.CheckoutStatusCompleted
+
+	persisted := inMemoryCheckoutRepository.PersistIfStatus(context.Background(), completed, models.CheckoutStatusOpen)
+	persistedAgain := inMemoryCheckoutRepository.PersistIfStatus(context.Background(), completed, models.CheckoutStatusOpen)
+	persistedMissing := inMemoryCheckoutRepository.PersistIfStatus(context.Background(), models.Checkout{Id: "another-checkout"}, "")
+
+	stored, _ := inMemoryCheckoutRepository.SearchById(context.Background(), checkout.Id)
+	assert.EqualValues(t, true, persisted)
+	assert.EqualValues(t, false, persistedAgain)
+	assert.EqualValues(t, false, persistedMissing)
+	assert.EqualValues(t, models.CheckoutStatusCompleted, stored.Status)
+}
+
+func TestPersistIfStatusRefusesACheckoutChangedSinceItWasRead(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", Status: models.CheckoutStatusOpen}
+	inMemoryCheckoutRepository := NewCheckoutRepository(map[string]models.Checkout{checkout.Id: checkout})
+	withPen := checkout
+	withPen.Products = []string{"PEN"}
+	withMug := checkout
+	withMug.Products = []string{"MUG"}
+
+	persisted := inMemoryCheckoutRepository.PersistIfStatus(context.Background(), withPen, models.CheckoutStatusOpen)
+	persistedStale := inMemoryCheckoutRepository.PersistIfStatus(context.Background(), withMug, models.CheckoutStatusOpen)
+
+	stored, _ := inMemoryCheckoutRepository.SearchById(context.Background(), checkout.Id)
+	assert.EqualValues(t, true, persisted)
+	assert.EqualValues(t, false, persistedStale)
+	assert.EqualValues(t, []string{"PEN"}, stored.Products)
+	assert.EqualValues(t, 1, stored.Version)
+}
+
+func TestDeleteIfStatusOnlyWhileTheStoredCheckoutHasTheStatus(t *testing.T) {
+	open := models.Checkout{Id: "an-open-checkout", Status: models.CheckoutStatusOpen}
+	completed := models.Checkout{Id: "a-completed-checkout", Status: models.CheckoutStatusCompleted}
+	checkouts := map[string]models.Checkout{open.Id: open, completed.Id: completed}
+	inMemoryCheckoutRepository := NewCheckoutRepository(checkouts)
+
+	deletedOpen := inMemoryCheckoutRepository.DeleteIfStatus(context.Background(), open, models.CheckoutStatusOpen)
+	deletedCompleted := inMemoryCheckoutRepository.DeleteIfStatus(context.Background(), completed, models.CheckoutStatusOpen)
+
+	assert.EqualValues(t, true, deletedOpen)
+	assert.EqualValues(t, false, deletedCompleted)
+	assert.EqualValues(t, 1, len(checkouts))
+	assert.Contains(t, checkouts, completed.Id)
+}
@@ -0,0 +1,65 @@
+package persistence
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"sync"
+)
+
+type InMemoryCustomerRepository struct {
+	mutex     sync.RWMutex
+	customers map[string]models.Customer
+}
+
+func NewCustomerRepository(customers map[string]models.Customer) *InMemoryCustomerRepository {
+	return &InMemoryCustomerRepository{customers: customers}
+}
+
+func (repository *InMemoryCustomerRepository) SearchById(ctx context.Context, id string) (models.Customer, bool) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	customer, exists := repository.customers[id]
+	return customer, exists
+}
+
+func (repository *InMemoryCustomerRepository) SearchByEmail(ctx context.Context, email string) (models.Customer, bool) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return repository.searchByEmail(email)
+}
+
+func (repository *InMemoryCustomerRepository) Persist(ctx context.Context, customer models.Customer) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.customers[customer.Id] = customer
+}
+
+func (repository *InMemoryCustomerRepository) PersistIfEmailAbsent(ctx context.Context, customer models.Customer) bool {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if registered, exists := repository.searchByEmail(customer.Email); exists && registered.Id != customer.Id {
+		return false
+	}
+	repository.customers[customer.Id] = customer
+	return true
+}
+
+// searchByEmail returns the customer with the email. The mutex must be
+// held.
+func (repository *InMemoryCustomerRepository) searchByEmail(email string) (models.Customer, bool) {
+	for _, customer := range repository.customers {
+		if customer.Email == email {
+			return customer, true
+		}
+	}
+	return models.Customer{}, false
+}
+
+// Ping always succeeds, the customers are kept in memory.
+func (repository *InMemoryCustomerRepository) Ping(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,82 @@
+package persistence
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchCustomerByIdReturnCustomerWhenCustomerExists(t *testing.T) {
+	customer := models.Customer{Id: "a-customer", Email: "ada@example.com"}
+	inMemoryCustomerRepository := NewCustomerRepository(map[string]models.Customer{customer.Id: customer})
+
+	customerRetrieved, exists := inMemoryCustomerRepository.SearchById(context.Background(), "a-customer")
+
+	assert.EqualValues(t, true, exists)
+	assert.EqualValues(t, customer, customerRetrieved)
+}
+
+func TestSearchCustomerByEmail(t *testing.T) {
+	customer := models.Customer{Id: "a-customer", Email: "ada@example.com"}
+	inMemoryCustomerRepository := NewCustomerRepository(map[string]models.Customer{customer.Id: customer})
+
+	customerRetrieved, exists := inMemoryCustomerRepository.SearchByEmail(context.Background(), "ada@example.com")
+	_, existsOther := inMemoryCustomerRepository.SearchByEmail(context.Background(), "grace@example.com")
+
+	assert.EqualValues(t, true, exists)
+	assert.EqualValues(t, customer, customerRetrieved)
+	assert.EqualValues(t, false, existsOther)
+}
+
+func TestPersistCreateCustomer(t *testing.T) {
+	customers := make(map[string]models.Customer)
+	inMemoryCustomerRepository := NewCustomerRepository(customers)
+
+	inMemoryCustomerRepository.Persist(context.Background(), models.Customer{Id: "a-customer"})
+
+	assert.EqualValues(t, 1, len(customers))
+}
+
+func TestPersistCustomersConcurrently(t *testing.T) {
+	inMemoryCustomerRepository := NewCustomerRepository(make(map[string]models.Customer))
+	var group sync.WaitGroup
+	for position := 0; position < 10; position++ {
+		group.Add(1)
+		go func(id string) {
+			defer group.Done()
+			inMemoryCustomerRepository.Persist(context.Background(), models.Customer{Id: id, Email: id + "@example.com"})
+			inMemoryCustomerRepository.SearchByEmail(context.Background(), id+"@example.com")
+		}(strconv.Itoa(position))
+	}
+	group.Wait()
+
+	_, exists := inMemoryCustomerRepository.SearchById(context.Background(), "9")
+	assert.EqualValues(t, true, exists)
+}
+
+func TestPersistIfEmailAbsentRegistersAnEmailOnceWhenConcurrent(t *testing.T) {
+	customers := make(map[string]models.Customer)
+	inMemoryCustomerRepository := NewCustomerRepository(customers)
+	var group sync.WaitGroup
+	var mutex sync.Mutex
+	registrations := 0
+	for position := 0; position < 10; position++ {
+		group.Add(1)
+		go func(id string) {
+			defer group.Done()
+			if inMemoryCustomerRepository.PersistIfEmailAbsent(context.Background(), models.Customer{Id: id, Email: "ada@example.com"}) {
+				mutex.Lock()
+				registrations++
+				mutex.Unlock()
+			}
+		}(strconv.Itoa(position))
+	}
+	group.Wait()
+
+	assert.EqualValues(t, 1, registrations)
+	assert.EqualValues(t, 1, len(customers))
+}
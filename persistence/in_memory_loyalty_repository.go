@@ -0,0 +1,67 @@
+package persistence
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"sync"
+)
+
+type InMemoryLoyaltyRepository struct {
+	mutex    sync.RWMutex
+	balances map[string]int
+	ledgers  map[string][]models.LoyaltyTransaction
+}
+
+// NewLoyaltyRepository keeps the ledgers by customer, with the balances of
+// the customers of the given ledgers.
+func NewLoyaltyRepository(ledgers map[string][]models.LoyaltyTransaction) *InMemoryLoyaltyRepository {
+	balances := make(map[string]int)
+	for customerId, transactions := range ledgers {
+		for _, transaction := range transactions {
+			balances[customerId] += transaction.Points
+		}
+	}
+	return &InMemoryLoyaltyRepository{balances: balances, ledgers: ledgers}
+}
+
+func (repository *InMemoryLoyaltyRepository) Balance(ctx context.Context, customerId string) int {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return repository.balances[customerId]
+}
+
+func (repository *InMemoryLoyaltyRepository) Record(ctx context.Context, transaction models.LoyaltyTransaction) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.record(transaction)
+}
+
+func (repository *InMemoryLoyaltyRepository) Redeem(ctx context.Context, transaction models.LoyaltyTransaction) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if repository.balances[transaction.CustomerId]+transaction.Points < 0 {
+		return ErrInsufficientBalance
+	}
+	repository.record(transaction)
+	return nil
+}
+
+func (repository *InMemoryLoyaltyRepository) record(transaction models.LoyaltyTransaction) {
+	repository.ledgers[transaction.CustomerId] = append(repository.ledgers[transaction.CustomerId], transaction)
+	repository.balances[transaction.CustomerId] += transaction.Points
+}
+
+func (repository *InMemoryLoyaltyRepository) SearchByCustomer(ctx context.Context, customerId string) []models.LoyaltyTransaction {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return append([]models.LoyaltyTransaction{}, repository.ledgers[customerId]...)
+}
+
+// Ping always succeeds, the ledgers are kept in memory.
+func (repository *InMemoryLoyaltyRepository) Ping(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,47 @@
+package persistence
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBalanceOfTheCustomersOfTheGivenLedgers(t *testing.T) {
+	inMemoryLoyaltyRepository := NewLoyaltyRepository(map[string][]models.LoyaltyTransaction{
+		"a-customer": {{Id: "an-earn", CustomerId: "a-customer", Points: 12}, {Id: "a-redeem", CustomerId: "a-customer", Points: -5}},
+	})
+
+	assert.EqualValues(t, 7, inMemoryLoyaltyRepository.Balance(context.Background(), "a-customer"))
+	assert.EqualValues(t, 0, inMemoryLoyaltyRepository.Balance(context.Background(), "another-customer"))
+}
+
+func TestRecordAppendsTheTransactionAndUpdatesTheBalance(t *testing.T) {
+	inMemoryLoyaltyRepository := NewLoyaltyRepository(make(map[string][]models.LoyaltyTransaction))
+	earn := models.LoyaltyTransaction{Id: "an-earn", CustomerId: "a-customer", Kind: models.LoyaltyTransactionEarn, Points: 12}
+	redeem := models.LoyaltyTransaction{Id: "a-redeem", CustomerId: "a-customer", Kind: models.LoyaltyTransactionRedeem, Points: -5}
+
+	inMemoryLoyaltyRepository.Record(context.Background(), earn)
+	inMemoryLoyaltyRepository.Record(context.Background(), redeem)
+
+	assert.EqualValues(t, 7, inMemoryLoyaltyRepository.Balance(context.Background(), "a-customer"))
+	assert.EqualValues(t, []models.LoyaltyTransaction{earn, redeem}, inMemoryLoyaltyRepository.SearchByCustomer(context.Background(), "a-customer"))
+	assert.Empty(t, inMemoryLoyaltyRepository.SearchByCustomer(context.Background(), "another-customer"))
+}
+
+func TestRedeemOnlyWhileTheBalanceCoversThePoints(t *testing.T) {
+	inMemoryLoyaltyRepository := NewLoyaltyRepository(map[string][]models.LoyaltyTransaction{
+		"a-customer": {{Id: "an-earn", CustomerId: "a-customer", Points: 10}},
+	})
+	redeem := models.LoyaltyTransaction{Id: "a-redeem", CustomerId: "a-customer", Kind: models.LoyaltyTransactionRedeem, Points: -8}
+	redeemAgain := models.LoyaltyTransaction{Id: "another-redeem", CustomerId: "a-customer", Kind: models.LoyaltyTransactionRedeem, Points: -8}
+
+	err := inMemoryLoyaltyRepository.Redeem(context.Background(), redeem)
+	errAgain := inMemoryLoyaltyRepository.Redeem(context.Background(), redeemAgain)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, ErrInsufficientBalance, errAgain)
+	assert.EqualValues(t, 2, inMemoryLoyaltyRepository.Balance(context.Background(), "a-customer"))
+	assert.Len(t, inMemoryLoyaltyRepository.SearchByCustomer(context.Background(), "a-customer"), 2)
+}
@@ -0,0 +1,56 @@
+package persistence
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"sort"
+	"sync"
+)
+
+type InMemoryPriceListRepository struct {
+	mutex      sync.RWMutex
+	priceLists map[string]models.PriceList
+}
+
+func NewPriceListRepository(priceLists map[string]models.PriceList) *InMemoryPriceListRepository {
+	return &InMemoryPriceListRepository{priceLists: priceLists}
+}
+
+func (repository *InMemoryPriceListRepository) SearchFor(ctx context.Context, customerId string, group string) []models.PriceList {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	var priceLists []models.PriceList
+	for _, priceList := range repository.priceLists {
+		if (customerId != "" && priceList.CustomerId == customerId) || (group != "" && priceList.Group == group) {
+			priceLists = append(priceLists, priceList)
+		}
+	}
+	return priceLists
+}
+
+func (repository *InMemoryPriceListRepository) SearchAll(ctx context.Context) []models.PriceList {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	priceLists := make([]models.PriceList, 0, len(repository.priceLists))
+	for _, priceList := range repository.priceLists {
+		priceLists = append(priceLists, priceList)
+	}
+	sort.Slice(priceLists, func(i, j int) bool {
+		return priceLists[i].Name < priceLists[j].Name
+	})
+	return priceLists
+}
+
+func (repository *InMemoryPriceListRepository) Persist(ctx context.Context, priceList models.PriceList) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.priceLists[priceList.Id] = priceList
+}
+
+// Ping always succeeds, the price lists are kept in memory.
+func (repository *InMemoryPriceListRepository) Ping(ctx context.Context) error {
+	return nil
+}
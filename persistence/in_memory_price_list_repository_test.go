@@ -0,0 +1,63 @@
+package persistence
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchPriceListsForTheCustomerAndItsGroup(t *testing.T) {
+	ofTheCustomer := models.PriceList{Id: "a-price-list", CustomerId: "a-customer"}
+	ofTheGroup := models.PriceList{Id: "a-group-price-list", Group: "wholesale"}
+	ofAnotherCustomer := models.PriceList{Id: "another-price-list", CustomerId: "another-customer"}
+	inMemoryPriceListRepository := NewPriceListRepository(map[string]models.PriceList{
+		ofTheCustomer.Id:     ofTheCustomer,
+		ofTheGroup.Id:        ofTheGroup,
+		ofAnotherCustomer.Id: ofAnotherCustomer,
+	})
+
+	priceLists := inMemoryPriceListRepository.SearchFor(context.Background(), "a-customer", "wholesale")
+	priceListsWithoutGroup := inMemoryPriceListRepository.SearchFor(context.Background(), "a-customer", "")
+
+	assert.ElementsMatch(t, []models.PriceList{ofTheCustomer, ofTheGroup}, priceLists)
+	assert.EqualValues(t, []models.PriceList{ofTheCustomer}, priceListsWithoutGroup)
+}
+
+func TestSearchAllPriceListsSortedByName(t *testing.T) {
+	wholesale := models.PriceList{Id: "a-price-list", Name: "Wholesale"}
+	acme := models.PriceList{Id: "another-price-list", Name: "Acme"}
+	inMemoryPriceListRepository := NewPriceListRepository(map[string]models.PriceList{wholesale.Id: wholesale, acme.Id: acme})
+
+	priceLists := inMemoryPriceListRepository.SearchAll(context.Background())
+
+	assert.EqualValues(t, []models.PriceList{acme, wholesale}, priceLists)
+}
+
+func TestPersistCreatePriceList(t *testing.T) {
+	priceLists := make(map[string]models.PriceList)
+	inMemoryPriceListRepository := NewPriceListRepository(priceLists)
+
+	inMemoryPriceListRepository.Persist(context.Background(), models.PriceList{Id: "a-price-list"})
+
+	assert.EqualValues(t, 1, len(priceLists))
+}
+
+func TestPersistPriceListsWhileSearchingThem(t *testing.T) {
+	inMemoryPriceListRepository := NewPriceListRepository(make(map[string]models.PriceList))
+	var group sync.WaitGroup
+	for position := 0; position < 10; position++ {
+		group.Add(1)
+		go func(id string) {
+			defer group.Done()
+			inMemoryPriceListRepository.Persist(context.Background(), models.PriceList{Id: id, Group: "wholesale"})
+			inMemoryPriceListRepository.SearchFor(context.Background(), "a-customer", "wholesale")
+		}(strconv.Itoa(position))
+	}
+	group.Wait()
+
+	assert.Len(t, inMemoryPriceListRepository.SearchAll(context.Background()), 10)
+}
@@ -1,6 +1,10 @@
 package persistence
 
-import "lana/flagship-store/models"
+import (
+	"context"
+	"lana/flagship-store/models"
+	"sort"
+)
 
 type InMemoryProductsRepository struct {
 	products map[string]models.Product
@@ -10,7 +14,23 @@ func NewProductsRepository(products map[string]models.Product) *InMemoryProducts
 	return &InMemoryProductsRepository{products}
 }
 
-func (repository *InMemoryProductsRepository) SearchById(id string) (models.Product, bool) {
+func (repository *InMemoryProductsRepository) SearchById(ctx context.Context, id string) (models.Product, bool) {
 	product, exists := repository.products[id]
 	return product, exists
 }
+
+func (repository *InMemoryProductsRepository) SearchAll(ctx context.Context) []models.Product {
+	products := make([]models.Product, 0, len(repository.products))
+	for _, product := range repository.products {
+		products = append(products, product)
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].Code < products[j].Code
+	})
+	return products
+}
+
+// Ping always succeeds, the products are kept in memory.
+func (repository *InMemoryProductsRepository) Ping(ctx context.Context) error {
+	return nil
+}
@@ -1,6 +1,7 @@
 package persistence
 
 import (
+	"context"
 	"lana/flagship-store/models"
 	"testing"
 
@@ -17,7 +18,7 @@ func TestSearchByIdReturnProductWhenProductExists(t *testing.T) {
 	products[pen.Code] = pen
 	inMemoryProductsRepository := &InMemoryProductsRepository{products}
 
-	product, exists := inMemoryProductsRepository.SearchById("PEN")
+	product, exists := inMemoryProductsRepository.SearchById(context.Background(), "PEN")
 
 	assert.EqualValues(t, true, exists)
 	assert.EqualValues(t, pen, product)
@@ -27,10 +28,21 @@ func TestSearchByIdReturnEmptyProductWhenProductDoesNotExist(t *testing.T) {
 	products := make(map[string]models.Product)
 	inMemoryProductsRepository := &InMemoryProductsRepository{products}
 
-	product, exists := inMemoryProductsRepository.SearchById("PEN")
+	product, exists := inMemoryProductsRepository.SearchById(context.Background(), "PEN")
 
 	assert.EqualValues(t, false, exists)
 	assert.EqualValues(t, "", product.Code)
 	assert.EqualValues(t, "", product.Name)
 	assert.EqualValues(t, 0, product.Price)
 }
+
+func TestSearchAllReturnProductsSortedByCode(t *testing.T) {
+	pen := models.Product{Code: "PEN", Name: "Lana Pen", Price: 500}
+	mug := models.Product{Code: "MUG", Name: "Lana Coffee Mug", Price: 750}
+	products := map[string]models.Product{pen.Code: pen, mug.Code: mug}
+	inMemoryProductsRepository := &InMemoryProductsRepository{products}
+
+	allProducts := inMemoryProductsRepository.SearchAll(context.Background())
+
+	assert.EqualValues(t, []models.Product{mug, pen}, allProducts)
+}
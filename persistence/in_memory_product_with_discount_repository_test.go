@@ -1,6 +1,7 @@
 package persistence
 
 import (
+	"context"
 	"lana/flagship-store/models"
 	"testing"
 
@@ -17,7 +18,7 @@ func TestSearchByIdReturnProductWhenProductHasDiscount(t *testing.T) {
 	products[pen.Code] = pen
 	inMemoryProductWithDiscountRepository := &InMemoryProductWithDiscountRepository{products}
 
-	product, exists := inMemoryProductWithDiscountRepository.SearchById("PEN")
+	product, exists := inMemoryProductWithDiscountRepository.SearchById(context.Background(), "PEN")
 
 	assert.EqualValues(t, true, exists)
 	assert.EqualValues(t, pen, product)
@@ -27,7 +28,7 @@ func TestSearchByIdReturnEmptyProductWhenProductDoesNotHaveDiscount(t *testing.T
 	products := make(map[string]models.Product)
 	inMemoryProductWithDiscountRepository := &InMemoryProductWithDiscountRepository{products}
 
-	product, exists := inMemoryProductWithDiscountRepository.SearchById("PEN")
+	product, exists := inMemoryProductWithDiscountRepository.SearchById(context.Background(), "PEN")
 
 	assert.EqualValues(t, false, exists)
 	assert.EqualValues(t, "", product.Code)
@@ -1,6 +1,10 @@
 package persistence
 
-import "lana/flagship-store/models"
+import (
+	"context"
+	"lana/flagship-store/models"
+	"sort"
+)
 
 type InMemoryProductWithPromotionRepository struct {
 	products map[string]models.Product
@@ -10,7 +14,23 @@ func NewProductWithPromotionRepository(products map[string]models.Product) *InMe
 	return &InMemoryProductWithPromotionRepository{products}
 }
 
-func (repository *InMemoryProductWithPromotionRepository) SearchById(id string) (models.Product, bool) {
+func (repository *InMemoryProductWithPromotionRepository) SearchById(ctx context.Context, id string) (models.Product, bool) {
 	product, exists := repository.products[id]
 	return product, exists
 }
+
+func (repository *InMemoryProductWithPromotionRepository) SearchAll(ctx context.Context) []models.Product {
+	products := make([]models.Product, 0, len(repository.products))
+	for _, product := range repository.products {
+		products = append(products, product)
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].Code < products[j].Code
+	})
+	return products
+}
+
+// Ping always succeeds, the products are kept in memory.
+func (repository *InMemoryProductWithPromotionRepository) Ping(ctx context.Context) error {
+	return nil
+}
@@ -1,6 +1,7 @@
 package persistence
 
 import (
+	"context"
 	"lana/flagship-store/models"
 	"testing"
 
@@ -17,7 +18,7 @@ func TestSearchByIdReturnProductWhenProductHasPromotion(t *testing.T) {
 	products[pen.Code] = pen
 	inMemoryProductWithPromotionRepository := &InMemoryProductWithPromotionRepository{products}
 
-	product, exists := inMemoryProductWithPromotionRepository.SearchById("PEN")
+	product, exists := inMemoryProductWithPromotionRepository.SearchById(context.Background(), "PEN")
 
 	assert.EqualValues(t, true, exists)
 	assert.EqualValues(t, pen, product)
@@ -27,7 +28,7 @@ func TestSearchByIdReturnEmptyProductWhenProductDoesNotHavePromotion(t *testing.
 	products := make(map[string]models.Product)
 	inMemoryProductWithPromotionRepository := &InMemoryProductWithPromotionRepository{products}
 
-	product, exists := inMemoryProductWithPromotionRepository.SearchById("PEN")
+	product, exists := inMemoryProductWithPromotionRepository.SearchById(context.Background(), "PEN")
 
 	assert.EqualValues(t, false, exists)
 	assert.EqualValues(t, "", product.Code)
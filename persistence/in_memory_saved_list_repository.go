@@ -0,0 +1,56 @@
+package persistence
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"sort"
+	"sync"
+)
+
+type InMemorySavedListRepository struct {
+	mutex      sync.RWMutex
+	savedLists map[string]models.SavedList
+}
+
+func NewSavedListRepository(savedLists map[string]models.SavedList) *InMemorySavedListRepository {
+	return &InMemorySavedListRepository{savedLists: savedLists}
+}
+
+func (repository *InMemorySavedListRepository) SearchById(ctx context.Context, id string) (models.SavedList, bool) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	savedList, exists := repository.savedLists[id]
+	return savedList, exists
+}
+
+func (repository *InMemorySavedListRepository) SearchByOwner(ctx context.Context, owner string) []models.SavedList {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	savedLists := []models.SavedList{}
+	for _, savedList := range repository.savedLists {
+		if owner == "" || savedList.Owner == owner {
+			savedLists = append(savedLists, savedList)
+		}
+	}
+	sort.Slice(savedLists, func(i, j int) bool {
+		if savedLists[i].CreatedAt.Equal(savedLists[j].CreatedAt) {
+			return savedLists[i].Id < savedLists[j].Id
+		}
+		return savedLists[i].CreatedAt.Before(savedLists[j].CreatedAt)
+	})
+	return savedLists
+}
+
+func (repository *InMemorySavedListRepository) Persist(ctx context.Context, savedList models.SavedList) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.savedLists[savedList.Id] = savedList
+}
+
+// Ping always succeeds, the saved lists are kept in memory.
+func (repository *InMemorySavedListRepository) Ping(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,63 @@
+package persistence
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchSavedListByIdReturnSavedListWhenSavedListExists(t *testing.T) {
+	savedList := models.SavedList{Id: "a-list", Name: "Birthday"}
+	inMemorySavedListRepository := NewSavedListRepository(map[string]models.SavedList{savedList.Id: savedList})
+
+	savedListRetrieved, exists := inMemorySavedListRepository.SearchById(context.Background(), "a-list")
+	_, existsOther := inMemorySavedListRepository.SearchById(context.Background(), "another-list")
+
+	assert.EqualValues(t, true, exists)
+	assert.EqualValues(t, savedList, savedListRetrieved)
+	assert.EqualValues(t, false, existsOther)
+}
+
+func TestSearchSavedListsByOwnerOldestFirst(t *testing.T) {
+	now := time.Now()
+	newer := models.SavedList{Id: "a-newer-list", Owner: "jwt:a-shopper", CreatedAt: now}
+	older := models.SavedList{Id: "an-older-list", Owner: "jwt:a-shopper", CreatedAt: now.Add(-time.Hour)}
+	another := models.SavedList{Id: "another-list", Owner: "jwt:another-shopper", CreatedAt: now}
+	inMemorySavedListRepository := NewSavedListRepository(map[string]models.SavedList{newer.Id: newer, older.Id: older, another.Id: another})
+
+	savedListsOfTheOwner := inMemorySavedListRepository.SearchByOwner(context.Background(), "jwt:a-shopper")
+	everySavedList := inMemorySavedListRepository.SearchByOwner(context.Background(), "")
+
+	assert.EqualValues(t, []models.SavedList{older, newer}, savedListsOfTheOwner)
+	assert.Len(t, everySavedList, 3)
+}
+
+func TestPersistCreateSavedList(t *testing.T) {
+	savedLists := make(map[string]models.SavedList)
+	inMemorySavedListRepository := NewSavedListRepository(savedLists)
+
+	inMemorySavedListRepository.Persist(context.Background(), models.SavedList{Id: "a-list"})
+
+	assert.EqualValues(t, 1, len(savedLists))
+}
+
+func TestPersistSavedListsConcurrently(t *testing.T) {
+	inMemorySavedListRepository := NewSavedListRepository(make(map[string]models.SavedList))
+	var group sync.WaitGroup
+	for position := 0; position < 10; position++ {
+		group.Add(1)
+		go func(id string) {
+			defer group.Done()
+			inMemorySavedListRepository.Persist(context.Background(), models.SavedList{Id: id, Owner: "jwt:a-shopper"})
+			inMemorySavedListRepository.SearchByOwner(context.Background(), "jwt:a-shopper")
+		}(strconv.Itoa(position))
+	}
+	group.Wait()
+
+	assert.Len(t, inMemorySavedListRepository.SearchByOwner(context.Background(), "jwt:a-shopper"), 10)
+}
@@ -0,0 +1,26 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"lana/flagship-store/models"
+)
+
+// ErrInsufficientBalance is returned redeeming more points than the balance
+// of the customer.
+var ErrInsufficientBalance = errors.New("insufficient loyalty points balance")
+
+// LoyaltyRepository keeps the balance of loyalty points of every customer
+// and the ledger of the transactions that changed it.
+type LoyaltyRepository interface {
+	Balance(ctx context.Context, customerId string) int
+	// Record appends the transaction to the ledger of its customer and adds
+	// its points to the balance of the customer.
+	Record(ctx context.Context, transaction models.LoyaltyTransaction)
+	// Redeem records the redeem transaction, of negative points, only when
+	// the balance of its customer covers them, checking and debiting the
+	// balance at once. Otherwise it returns ErrInsufficientBalance.
+	Redeem(ctx context.Context, transaction models.LoyaltyTransaction) error
+	// SearchByCustomer returns the ledger of the customer, oldest first.
+	SearchByCustomer(ctx context.Context, customerId string) []models.LoyaltyTransaction
+}
@@ -0,0 +1,14 @@
+package persistence
+
+import (
+	"context"
+	"lana/flagship-store/models"
+)
+
+type PriceListRepository interface {
+	// SearchFor returns the price lists of the customer and the price lists
+	// of its group, if any.
+	SearchFor(ctx context.Context, customerId string, group string) []models.PriceList
+	SearchAll(ctx context.Context) []models.PriceList
+	Persist(ctx context.Context, priceList models.PriceList)
+}
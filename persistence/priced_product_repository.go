@@ -0,0 +1,37 @@
+package persistence
+
+import (
+	"context"
+	"lana/flagship-store/models"
+)
+
+// PricedProductRepository overrides the price of the products found in the
+// repository it wraps with the given prices, e.g. the prices of a customer.
+type PricedProductRepository struct {
+	repository ProductRepository
+	prices     map[string]int
+}
+
+func NewPricedProductRepository(repository ProductRepository, prices map[string]int) *PricedProductRepository {
+	return &PricedProductRepository{repository, prices}
+}
+
+func (repository *PricedProductRepository) SearchById(ctx context.Context, id string) (models.Product, bool) {
+	product, exists := repository.repository.SearchById(ctx, id)
+	if price, overridden := repository.prices[id]; exists && overridden {
+		product.Price = price
+	}
+	return product, exists
+}
+
+func (repository *PricedProductRepository) SearchAll(ctx context.Context) []models.Product {
+	products := repository.repository.SearchAll(ctx)
+	priced := make([]models.Product, 0, len(products))
+	for _, product := range products {
+		if price, overridden := repository.prices[product.Code]; overridden {
+			product.Price = price
+		}
+		priced = append(priced, product)
+	}
+	return priced
+}
@@ -0,0 +1,38 @@
+package persistence_test
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchByIdReturnProductWithTheOverriddenPrice(t *testing.T) {
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "MUG").Return(models.Product{Code: "MUG", Name: "Lana Coffee Mug", Price: 750}, true)
+	theProductRepositoryMock.On("SearchById", "PEN").Return(models.Product{Code: "PEN", Name: "Lana Pen", Price: 500}, true)
+	theProductRepositoryMock.On("SearchById", "FAKE").Return(models.Product{}, false)
+	pricedProductRepository := persistence.NewPricedProductRepository(&theProductRepositoryMock, map[string]int{"MUG": 600, "FAKE": 100})
+
+	mug, _ := pricedProductRepository.SearchById(context.Background(), "MUG")
+	pen, _ := pricedProductRepository.SearchById(context.Background(), "PEN")
+	fake, exists := pricedProductRepository.SearchById(context.Background(), "FAKE")
+
+	assert.EqualValues(t, models.Product{Code: "MUG", Name: "Lana Coffee Mug", Price: 600}, mug)
+	assert.EqualValues(t, 500, pen.Price)
+	assert.EqualValues(t, false, exists)
+	assert.EqualValues(t, 0, fake.Price)
+}
+
+func TestSearchAllReturnProductsWithTheOverriddenPrices(t *testing.T) {
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchAll").Return([]models.Product{{Code: "MUG", Price: 750}, {Code: "PEN", Price: 500}})
+	pricedProductRepository := persistence.NewPricedProductRepository(&theProductRepositoryMock, map[string]int{"MUG": 600})
+
+	products := pricedProductRepository.SearchAll(context.Background())
+
+	assert.EqualValues(t, []models.Product{{Code: "MUG", Price: 600}, {Code: "PEN", Price: 500}}, products)
+}
@@ -1,7 +1,11 @@
 package persistence
 
-import "lana/flagship-store/models"
+import (
+	"context"
+	"lana/flagship-store/models"
+)
 
 type ProductRepository interface {
-	SearchById(id string) (models.Product, bool)
+	SearchById(ctx context.Context, id string) (models.Product, bool)
+	SearchAll(ctx context.Context) []models.Product
 }
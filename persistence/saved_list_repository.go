@@ -0,0 +1,14 @@
+package persistence
+
+import (
+	"context"
+	"lana/flagship-store/models"
+)
+
+type SavedListRepository interface {
+	SearchById(ctx context.Context, id string) (models.SavedList, bool)
+	// SearchByOwner returns the saved lists of the owner, oldest first, or
+	// the saved lists of every owner when the owner is empty.
+	SearchByOwner(ctx context.Context, owner string) []models.SavedList
+	Persist(ctx context.Context, savedList models.SavedList)
+}
@@ -0,0 +1,68 @@
+package persistence
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TracedCheckoutRepository traces every call to the repository it wraps.
+type TracedCheckoutRepository struct {
+	repository CheckoutRepository
+}
+
+func NewTracedCheckoutRepository(repository CheckoutRepository) *TracedCheckoutRepository {
+	return &TracedCheckoutRepository{repository}
+}
+
+func (repository *TracedCheckoutRepository) SearchById(ctx context.Context, id string) (models.Checkout, bool) {
+	ctx, span := tracing.Start(ctx, "CheckoutRepository.SearchById", attribute.String("checkout.id", id))
+	defer span.End()
+	checkout, exists := repository.repository.SearchById(ctx, id)
+	span.SetAttributes(attribute.Bool("checkout.found", exists))
+	return checkout, exists
+}
+
+func (repository *TracedCheckoutRepository) Search(ctx context.Context, criteria CheckoutCriteria) []models.Checkout {
+	ctx, span := tracing.Start(ctx, "CheckoutRepository.Search")
+	defer span.End()
+	checkouts := repository.repository.Search(ctx, criteria)
+	span.SetAttributes(attribute.Int("checkouts.count", len(checkouts)))
+	return checkouts
+}
+
+func (repository *TracedCheckoutRepository) Persist(ctx context.Context, checkout models.Checkout) {
+	ctx, span := tracing.Start(ctx, "CheckoutRepository.Persist", attribute.String("checkout.id", checkout.Id))
+	defer span.End()
+	repository.repository.Persist(ctx, checkout)
+}
+
+func (repository *TracedCheckoutRepository) PersistIfStatus(ctx context.Context, checkout models.Checkout, status string) bool {
+	ctx, span := tracing.Start(ctx, "CheckoutRepository.PersistIfStatus", attribute.String("checkout.id", checkout.Id), attribute.String("checkout.status", status))
+	defer span.End()
+	persisted := repository.repository.PersistIfStatus(ctx, checkout, status)
+	span.SetAttributes(attribute.Bool("checkout.persisted", persisted))
+	return persisted
+}
+
+func (repository *TracedCheckoutRepository) Delete(ctx context.Context, checkout models.Checkout) {
+	ctx, span := tracing.Start(ctx, "CheckoutRepository.Delete", attribute.String("checkout.id", checkout.Id))
+	defer span.End()
+	repository.repository.Delete(ctx, checkout)
+}
+
+func (repository *TracedCheckoutRepository) DeleteIfStatus(ctx context.Context, checkout models.Checkout, status string) bool {
+	ctx, span := tracing.Start(ctx, "CheckoutRepository.DeleteIfStatus", attribute.String("checkout.id", checkout.Id), attribute.String("checkout.status", status))
+	defer span.End()
+	deleted := repository.repository.DeleteIfStatus(ctx, checkout, status)
+	span.SetAttributes(attribute.Bool("checkout.deleted", deleted))
+	return deleted
+}
+
+func (repository *TracedCheckoutRepository) Count(ctx context.Context) int {
+	ctx, span := tracing.Start(ctx, "CheckoutRepository.Count")
+	defer span.End()
+	return repository.repository.Count(ctx)
+}
@@ -0,0 +1,36 @@
+package persistence_test
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracedCheckoutRepositoryTracesSearchById(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previousProvider)
+	checkout := models.Checkout{Id: "c1"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", "c1").Return(checkout, true)
+	tracedCheckoutRepository := persistence.NewTracedCheckoutRepository(&theCheckoutRepositoryMock)
+
+	found, exists := tracedCheckoutRepository.SearchById(context.Background(), "c1")
+
+	assert.True(t, exists)
+	assert.EqualValues(t, checkout, found)
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "CheckoutRepository.SearchById", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("checkout.id", "c1"))
+	assert.Contains(t, spans[0].Attributes(), attribute.Bool("checkout.found", true))
+}
@@ -0,0 +1,48 @@
+package persistence
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TracedCustomerRepository traces every call to the repository it wraps.
+type TracedCustomerRepository struct {
+	repository CustomerRepository
+}
+
+func NewTracedCustomerRepository(repository CustomerRepository) *TracedCustomerRepository {
+	return &TracedCustomerRepository{repository}
+}
+
+func (repository *TracedCustomerRepository) SearchById(ctx context.Context, id string) (models.Customer, bool) {
+	ctx, span := tracing.Start(ctx, "CustomerRepository.SearchById", attribute.String("customer.id", id))
+	defer span.End()
+	customer, exists := repository.repository.SearchById(ctx, id)
+	span.SetAttributes(attribute.Bool("customer.found", exists))
+	return customer, exists
+}
+
+func (repository *TracedCustomerRepository) SearchByEmail(ctx context.Context, email string) (models.Customer, bool) {
+	ctx, span := tracing.Start(ctx, "CustomerRepository.SearchByEmail")
+	defer span.End()
+	customer, exists := repository.repository.SearchByEmail(ctx, email)
+	span.SetAttributes(attribute.Bool("customer.found", exists))
+	return customer, exists
+}
+
+func (repository *TracedCustomerRepository) Persist(ctx context.Context, customer models.Customer) {
+	ctx, span := tracing.Start(ctx, "CustomerRepository.Persist", attribute.String("customer.id", customer.Id))
+	defer span.End()
+	repository.repository.Persist(ctx, customer)
+}
+
+func (repository *TracedCustomerRepository) PersistIfEmailAbsent(ctx context.Context, customer models.Customer) bool {
+	ctx, span := tracing.Start(ctx, "CustomerRepository.PersistIfEmailAbsent", attribute.String("customer.id", customer.Id))
+	defer span.End()
+	persisted := repository.repository.PersistIfEmailAbsent(ctx, customer)
+	span.SetAttributes(attribute.Bool("customer.persisted", persisted))
+	return persisted
+}
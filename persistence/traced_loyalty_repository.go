@@ -0,0 +1,48 @@
+package persistence
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TracedLoyaltyRepository traces every call to the repository it wraps.
+type TracedLoyaltyRepository struct {
+	repository LoyaltyRepository
+}
+
+func NewTracedLoyaltyRepository(repository LoyaltyRepository) *TracedLoyaltyRepository {
+	return &TracedLoyaltyRepository{repository}
+}
+
+func (repository *TracedLoyaltyRepository) Balance(ctx context.Context, customerId string) int {
+	ctx, span := tracing.Start(ctx, "LoyaltyRepository.Balance", attribute.String("customer.id", customerId))
+	defer span.End()
+	balance := repository.repository.Balance(ctx, customerId)
+	span.SetAttributes(attribute.Int("loyalty.balance", balance))
+	return balance
+}
+
+func (repository *TracedLoyaltyRepository) Record(ctx context.Context, transaction models.LoyaltyTransaction) {
+	ctx, span := tracing.Start(ctx, "LoyaltyRepository.Record", attribute.String("customer.id", transaction.CustomerId), attribute.String("loyalty.kind", transaction.Kind), attribute.Int("loyalty.points", transaction.Points))
+	defer span.End()
+	repository.repository.Record(ctx, transaction)
+}
+
+func (repository *TracedLoyaltyRepository) Redeem(ctx context.Context, transaction models.LoyaltyTransaction) error {
+	ctx, span := tracing.Start(ctx, "LoyaltyRepository.Redeem", attribute.String("customer.id", transaction.CustomerId), attribute.Int("loyalty.points", transaction.Points))
+	defer span.End()
+	err := repository.repository.Redeem(ctx, transaction)
+	span.SetAttributes(attribute.Bool("loyalty.redeemed", err == nil))
+	return err
+}
+
+func (repository *TracedLoyaltyRepository) SearchByCustomer(ctx context.Context, customerId string) []models.LoyaltyTransaction {
+	ctx, span := tracing.Start(ctx, "LoyaltyRepository.SearchByCustomer", attribute.String("customer.id", customerId))
+	defer span.End()
+	transactions := repository.repository.SearchByCustomer(ctx, customerId)
+	span.SetAttributes(attribute.Int("loyalty.transactions", len(transactions)))
+	return transactions
+}
@@ -0,0 +1,40 @@
+package persistence
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TracedPriceListRepository traces every call to the repository it wraps.
+type TracedPriceListRepository struct {
+	repository PriceListRepository
+}
+
+func NewTracedPriceListRepository(repository PriceListRepository) *TracedPriceListRepository {
+	return &TracedPriceListRepository{repository}
+}
+
+func (repository *TracedPriceListRepository) SearchFor(ctx context.Context, customerId string, group string) []models.PriceList {
+	ctx, span := tracing.Start(ctx, "PriceListRepository.SearchFor", attribute.String("customer.id", customerId), attribute.String("customer.group", group))
+	defer span.End()
+	priceLists := repository.repository.SearchFor(ctx, customerId, group)
+	span.SetAttributes(attribute.Int("price_lists.count", len(priceLists)))
+	return priceLists
+}
+
+func (repository *TracedPriceListRepository) SearchAll(ctx context.Context) []models.PriceList {
+	ctx, span := tracing.Start(ctx, "PriceListRepository.SearchAll")
+	defer span.End()
+	priceLists := repository.repository.SearchAll(ctx)
+	span.SetAttributes(attribute.Int("price_lists.count", len(priceLists)))
+	return priceLists
+}
+
+func (repository *TracedPriceListRepository) Persist(ctx context.Context, priceList models.PriceList) {
+	ctx, span := tracing.Start(ctx, "PriceListRepository.Persist", attribute.String("price_list.id", priceList.Id))
+	defer span.End()
+	repository.repository.Persist(ctx, priceList)
+}
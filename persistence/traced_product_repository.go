@@ -0,0 +1,34 @@
+package persistence
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TracedProductRepository traces every call to the repository it wraps,
+// named to tell apart the catalog from the promotion and discount rules.
+type TracedProductRepository struct {
+	repository ProductRepository
+	name       string
+}
+
+func NewTracedProductRepository(repository ProductRepository, name string) *TracedProductRepository {
+	return &TracedProductRepository{repository, name}
+}
+
+func (repository *TracedProductRepository) SearchById(ctx context.Context, id string) (models.Product, bool) {
+	ctx, span := tracing.Start(ctx, "ProductRepository.SearchById", attribute.String("repository", repository.name), attribute.String("product.code", id))
+	defer span.End()
+	product, exists := repository.repository.SearchById(ctx, id)
+	span.SetAttributes(attribute.Bool("product.found", exists))
+	return product, exists
+}
+
+func (repository *TracedProductRepository) SearchAll(ctx context.Context) []models.Product {
+	ctx, span := tracing.Start(ctx, "ProductRepository.SearchAll", attribute.String("repository", repository.name))
+	defer span.End()
+	return repository.repository.SearchAll(ctx)
+}
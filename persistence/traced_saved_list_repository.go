@@ -0,0 +1,40 @@
+package persistence
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TracedSavedListRepository traces every call to the repository it wraps.
+type TracedSavedListRepository struct {
+	repository SavedListRepository
+}
+
+func NewTracedSavedListRepository(repository SavedListRepository) *TracedSavedListRepository {
+	return &TracedSavedListRepository{repository}
+}
+
+func (repository *TracedSavedListRepository) SearchById(ctx context.Context, id string) (models.SavedList, bool) {
+	ctx, span := tracing.Start(ctx, "SavedListRepository.SearchById", attribute.String("saved_list.id", id))
+	defer span.End()
+	savedList, exists := repository.repository.SearchById(ctx, id)
+	span.SetAttributes(attribute.Bool("saved_list.found", exists))
+	return savedList, exists
+}
+
+func (repository *TracedSavedListRepository) SearchByOwner(ctx context.Context, owner string) []models.SavedList {
+	ctx, span := tracing.Start(ctx, "SavedListRepository.SearchByOwner")
+	defer span.End()
+	savedLists := repository.repository.SearchByOwner(ctx, owner)
+	span.SetAttributes(attribute.Int("saved_lists.count", len(savedLists)))
+	return savedLists
+}
+
+func (repository *TracedSavedListRepository) Persist(ctx context.Context, savedList models.SavedList) {
+	ctx, span := tracing.Start(ctx, "SavedListRepository.Persist", attribute.String("saved_list.id", savedList.Id))
+	defer span.End()
+	repository.repository.Persist(ctx, savedList)
+}
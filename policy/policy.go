@@ -0,0 +1,129 @@
+// Package policy maps the commands of the store to the roles allowed to
+// invoke them, guarding the routes and the services.
+package policy
+
+import (
+	"context"
+	"lana/flagship-store/auth"
+	"lana/flagship-store/services/errors"
+)
+
+// Command is an operation of the store guarded by the policy.
+type Command string
+
+const (
+	CreateCheckout            Command = "create-checkout"
+	AddProductToCheckout      Command = "add-product-to-checkout"
+	RemoveProductFromCheckout Command = "remove-product-from-checkout"
+	DeleteCheckout            Command = "delete-checkout"
+	MergeCheckouts            Command = "merge-checkouts"
+	RetrieveCheckout          Command = "retrieve-checkout"
+	RetrieveCheckoutAmount    Command = "retrieve-checkout-amount"
+	RetrieveCheckoutsAmount   Command = "retrieve-checkouts-amount"
+	ListCheckouts             Command = "list-checkouts"
+	ListProducts              Command = "list-products"
+	RetrieveProduct           Command = "retrieve-product"
+	RegisterCustomer          Command = "register-customer"
+	RetrieveCustomer          Command = "retrieve-customer"
+	AttachCheckoutToCustomer  Command = "attach-checkout-to-customer"
+	CreateSavedList           Command = "create-saved-list"
+	ListSavedLists            Command = "list-saved-lists"
+	CheckoutSavedList         Command = "checkout-saved-list"
+	ManagePriceLists          Command = "manage-price-lists"
+	ListPriceLists            Command = "list-price-lists"
+	CompleteCheckout          Command = "complete-checkout"
+	RedeemLoyaltyPoints       Command = "redeem-loyalty-points"
+	RetrieveLoyaltyAccount    Command = "retrieve-loyalty-account"
+
+	// AccessAnyCheckout lets the other commands reach the checkouts of every
+	// owner, not only the checkouts of the principal.
+	AccessAnyCheckout Command = "access-any-checkout"
+	// AccessAnyCustomer lets the other commands reach the customers
+	// registered by every owner.
+	AccessAnyCustomer Command = "access-any-customer"
+)
+
+// Commands are every command guarded by the policy.
+var Commands = []Command{
+	CreateCheckout, AddProductToCheckout, RemoveProductFromCheckout, DeleteCheckout, MergeCheckouts,
+	RetrieveCheckout, RetrieveCheckoutAmount, RetrieveCheckoutsAmount, ListCheckouts,
+	ListProducts, RetrieveProduct, RegisterCustomer, RetrieveCustomer, AttachCheckoutToCustomer,
+	CreateSavedList, ListSavedLists, CheckoutSavedList, ManagePriceLists, ListPriceLists,
+	CompleteCheckout, RedeemLoyaltyPoints, RetrieveLoyaltyAccount,
+	AccessAnyCheckout, AccessAnyCustomer,
+}
+
+// permissions are the commands each role may invoke. Shoppers fill and
+// check out their own baskets, save them for later, register as customers
+// and redeem their loyalty points, merchandisers browse the catalog, every
+// basket, saved list, customer and loyalty account without modifying them
+// and admins may invoke every command, managing the price lists and the
+// groups of the customers.
+var permissions = map[auth.Role][]Command{
+	auth.RoleShopper: {
+		CreateCheckout, AddProductToCheckout, RemoveProductFromCheckout, DeleteCheckout, MergeCheckouts,
+		RetrieveCheckout, RetrieveCheckoutAmount, RetrieveCheckoutsAmount, ListCheckouts,
+		ListProducts, RetrieveProduct, RegisterCustomer, RetrieveCustomer, AttachCheckoutToCustomer,
+		CreateSavedList, ListSavedLists, CheckoutSavedList,
+		CompleteCheckout, RedeemLoyaltyPoints, RetrieveLoyaltyAccount,
+	},
+	auth.RoleMerchandiser: {
+		RetrieveCheckout, RetrieveCheckoutAmount, RetrieveCheckoutsAmount, ListCheckouts,
+		ListProducts, RetrieveProduct, RetrieveCustomer, ListSavedLists, ListPriceLists,
+		RetrieveLoyaltyAccount, AccessAnyCheckout, AccessAnyCustomer,
+	},
+	auth.RoleAdmin: Commands,
+}
+
+// Allows reports whether the role may invoke the command.
+func Allows(role auth.Role, command Command) bool {
+	for _, permitted := range permissions[role] {
+		if permitted == command {
+			return true
+		}
+	}
+	return false
+}
+
+type trustedCallerKey struct{}
+
+// WithTrustedCaller returns a copy of ctx marking an internal call, not
+// served to clients, which is authorized whatever the command.
+func WithTrustedCaller(ctx context.Context) context.Context {
+	return context.WithValue(ctx, trustedCallerKey{}, true)
+}
+
+func isTrustedCaller(ctx context.Context) bool {
+	trusted, _ := ctx.Value(trustedCallerKey{}).(bool)
+	return trusted
+}
+
+// Authorize rejects the command when the principal of the request may not
+// invoke it. Requests without principal are rejected too, unless they are
+// internal calls marked with WithTrustedCaller.
+func Authorize(ctx context.Context, command Command) error {
+	if isTrustedCaller(ctx) {
+		return nil
+	}
+	principal, authenticated := auth.FromContext(ctx)
+	if !authenticated {
+		return errors.NewUnauthorizedError("request without principal")
+	}
+	if Allows(principal.Role, command) {
+		return nil
+	}
+	return errors.NewCommandForbiddenError(string(principal.Role), string(command))
+}
+
+// AllowedAnyCheckout reports whether the request reaches the checkouts of
+// every owner: it is a trusted internal call or its principal may access
+// any checkout.
+func AllowedAnyCheckout(ctx context.Context) bool {
+	return Authorize(ctx, AccessAnyCheckout) == nil
+}
+
+// AllowedAnyCustomer reports whether the request reaches the customers
+// registered by every owner.
+func AllowedAnyCustomer(ctx context.Context) bool {
+	return Authorize(ctx, AccessAnyCustomer) == nil
+}
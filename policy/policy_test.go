@@ -0,0 +1,80 @@
+package policy
+
+import (
+	"context"
+	"lana/flagship-store/auth"
+	"lana/flagship-store/services/errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// deniedCommands are the commands every role may not invoke, every other
+// command is allowed.
+var deniedCommands = map[auth.Role][]Command{
+	auth.RoleShopper:      {ManagePriceLists, ListPriceLists, AccessAnyCheckout, AccessAnyCustomer},
+	auth.RoleMerchandiser: {CreateCheckout, AddProductToCheckout, RemoveProductFromCheckout, DeleteCheckout, MergeCheckouts, RegisterCustomer, AttachCheckoutToCustomer, CreateSavedList, CheckoutSavedList, ManagePriceLists, CompleteCheckout, RedeemLoyaltyPoints},
+	auth.RoleAdmin:        {},
+}
+
+func isDenied(role auth.Role, command Command) bool {
+	for _, denied := range deniedCommands[role] {
+		if denied == command {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAuthorizeEveryCommandOfEveryRole(t *testing.T) {
+	for _, role := range auth.Roles {
+		ctx := auth.NewContext(context.Background(), auth.Principal{Subject: "a-subject", Method: auth.MethodJWT, Role: role})
+		for _, command := range Commands {
+			err := Authorize(ctx, command)
+
+			if isDenied(role, command) {
+				assert.EqualValues(t, true, errors.Is(err, errors.ErrForbidden), "%s may not %s", role, command)
+				assert.False(t, Allows(role, command), "%s may not %s", role, command)
+			} else {
+				assert.Nil(t, err, "%s may %s", role, command)
+				assert.True(t, Allows(role, command), "%s may %s", role, command)
+			}
+		}
+	}
+}
+
+func TestAuthorizeDeniesEveryCommandToPrincipalsWithoutRole(t *testing.T) {
+	ctx := auth.NewContext(context.Background(), auth.Principal{Subject: "a-subject", Method: auth.MethodJWT})
+
+	for _, command := range Commands {
+		assert.EqualValues(t, true, errors.Is(Authorize(ctx, command), errors.ErrForbidden), command)
+	}
+}
+
+func TestAuthorizeDeniesEveryCommandToRequestsWithoutPrincipal(t *testing.T) {
+	for _, command := range Commands {
+		assert.EqualValues(t, true, errors.Is(Authorize(context.Background(), command), errors.ErrUnauthorized), command)
+	}
+	assert.False(t, AllowedAnyCheckout(context.Background()))
+	assert.False(t, AllowedAnyCustomer(context.Background()))
+}
+
+func TestAuthorizeTrustedInternalCalls(t *testing.T) {
+	ctx := WithTrustedCaller(context.Background())
+
+	for _, command := range Commands {
+		assert.Nil(t, Authorize(ctx, command), command)
+	}
+	assert.True(t, AllowedAnyCheckout(ctx))
+	assert.True(t, AllowedAnyCustomer(ctx))
+}
+
+func TestCommandForbiddenErrorDetails(t *testing.T) {
+	ctx := auth.NewContext(context.Background(), auth.Principal{Subject: "a-subject", Method: auth.MethodJWT, Role: auth.RoleMerchandiser})
+
+	var serviceError *errors.Error
+	errors.As(Authorize(ctx, DeleteCheckout), &serviceError)
+
+	assert.EqualValues(t, "Role merchandiser may not delete-checkout", serviceError.Message)
+	assert.EqualValues(t, map[string]interface{}{"role": "merchandiser", "command": "delete-checkout"}, serviceError.Details)
+}
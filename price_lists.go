@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"lana/flagship-store/logging"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/services/responses"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func (app *App) initializePriceListRoutes(router *mux.Router) {
+	router.HandleFunc("/price-lists", app.authorize(policy.ManagePriceLists, app.createPriceList)).Methods("POST")
+	router.HandleFunc("/price-lists", app.authorize(policy.ListPriceLists, app.listPriceLists)).Methods("GET")
+}
+
+func (app *App) createPriceList(response http.ResponseWriter, request *http.Request) {
+	var createCommand commands.CreatePriceList
+	if err := decodeBody(response, request, &createCommand); err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	priceList, err := app.CreatePriceListService.Do(request.Context(), createCommand)
+	if err != nil {
+		writeProblem(response, request, err, problemStatus{errors.CodeCustomerNotFound, http.StatusUnprocessableEntity}, problemStatus{errors.CodeProductNotFound, http.StatusUnprocessableEntity})
+		return
+	}
+
+	logging.Annotate(request.Context(), "price-list-id", priceList.Id)
+	response.WriteHeader(http.StatusCreated)
+	json.NewEncoder(response).Encode(priceList)
+}
+
+func (app *App) listPriceLists(response http.ResponseWriter, request *http.Request) {
+	priceLists, err := app.ListPriceListsService.Do(request.Context())
+	if err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(responses.PriceLists{PriceLists: priceLists})
+}
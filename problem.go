@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"lana/flagship-store/logging"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/services/responses"
+	"lana/flagship-store/tracing"
+	"net/http"
+)
+
+const problemContentType = "application/problem+json"
+
+// statusClientClosedRequest is the non standard status of the requests
+// canceled by the client before being served.
+const statusClientClosedRequest = 499
+
+type problemType struct {
+	status int
+	title  string
+}
+
+var problemTypes = map[errors.Code]problemType{
+	errors.CodeInternal:             {http.StatusInternalServerError, "Internal server error"},
+	errors.CodeNotFound:             {http.StatusNotFound, "Resource not found"},
+	errors.CodeMethodNotAllowed:     {http.StatusMethodNotAllowed, "Method not allowed"},
+	errors.CodeInvalidParameter:     {http.StatusBadRequest, "Invalid parameter"},
+	errors.CodeUnsupportedMedia:     {http.StatusUnsupportedMediaType, "Unsupported media type"},
+	errors.CodePayloadTooLarge:      {http.StatusRequestEntityTooLarge, "Payload too large"},
+	errors.CodeMalformedBody:        {http.StatusBadRequest, "Malformed body"},
+	errors.CodeValidation:           {http.StatusBadRequest, "Validation failed"},
+	errors.CodeCheckoutNotFound:     {http.StatusNotFound, "Checkout not found"},
+	errors.CodeProductNotFound:      {http.StatusNotFound, "Product not found"},
+	errors.CodeQuantityExceeded:     {http.StatusUnprocessableEntity, "Quantity limit exceeded"},
+	errors.CodeProductNotInCheckout: {http.StatusUnprocessableEntity, "Product not in checkout"},
+	errors.CodeCanceled:             {statusClientClosedRequest, "Request canceled"},
+	errors.CodeDeadlineExceeded:     {http.StatusGatewayTimeout, "Deadline exceeded"},
+	errors.CodeUnauthorized:         {http.StatusUnauthorized, "Unauthorized"},
+	errors.CodeForbidden:            {http.StatusForbidden, "Forbidden"},
+	errors.CodeRateLimited:          {http.StatusTooManyRequests, "Rate limit exceeded"},
+	errors.CodeOpenCheckoutsLimit:   {http.StatusTooManyRequests, "Open checkouts limit exceeded"},
+	errors.CodeIdempotencyKeyReused: {http.StatusUnprocessableEntity, "Idempotency key reused"},
+	errors.CodeIdempotencyKeyInUse:  {http.StatusConflict, "Idempotency key in use"},
+	errors.CodeCustomerNotFound:     {http.StatusNotFound, "Customer not found"},
+	errors.CodeCustomerExists:       {http.StatusConflict, "Customer already exists"},
+	errors.CodeCheckoutAttached:     {http.StatusConflict, "Checkout attached to another customer"},
+	errors.CodeSavedListNotFound:    {http.StatusNotFound, "Saved list not found"},
+	errors.CodeCheckoutNotOpen:      {http.StatusConflict, "Checkout not open"},
+	errors.CodeCheckoutModified:     {http.StatusConflict, "Checkout modified"},
+	errors.CodeInsufficientPoints:   {http.StatusUnprocessableEntity, "Insufficient loyalty points"},
+	errors.CodeGuestCheckout:        {http.StatusUnprocessableEntity, "Checkout without customer"},
+}
+
+// problemStatus overrides the status of an error code for a single route,
+// e.g. a product not found in the body of a request is not a missing
+// resource but an unprocessable request.
+type problemStatus struct {
+	code   errors.Code
+	status int
+}
+
+func newProblem(request *http.Request, err error, statuses ...problemStatus) responses.Problem {
+	code := errors.CodeOf(err)
+	problem := responses.Problem{
+		Type:     "/problems/" + string(code),
+		Title:    problemTypes[code].title,
+		Status:   problemTypes[code].status,
+		Instance: request.URL.Path,
+		Code:     string(code),
+	}
+	for _, status := range statuses {
+		if status.code == code {
+			problem.Status = status.status
+		}
+	}
+
+	if code == errors.CodeInternal {
+		logging.FromContext(request.Context()).Error("Internal error serving request", err, logging.Fields{"path": request.URL.Path})
+		return problem
+	}
+
+	var serviceError *errors.Error
+	errors.As(err, &serviceError)
+	problem.Detail = serviceError.Message
+	problem.Details = serviceError.Details
+	return problem
+}
+
+func writeProblem(response http.ResponseWriter, request *http.Request, err error, statuses ...problemStatus) {
+	problem := newProblem(request, err, statuses...)
+	tracing.RecordError(request.Context(), err)
+	response.Header().Set("Content-Type", problemContentType)
+	response.WriteHeader(problem.Status)
+	json.NewEncoder(response).Encode(problem)
+}
+
+func notFound(response http.ResponseWriter, request *http.Request) {
+	writeProblem(response, request, errors.New(errors.CodeNotFound, "Route "+request.URL.Path+" not found", nil))
+}
+
+func methodNotAllowed(response http.ResponseWriter, request *http.Request) {
+	writeProblem(response, request, errors.New(errors.CodeMethodNotAllowed, "Method "+request.Method+" not allowed on "+request.URL.Path, nil))
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"lana/flagship-store/auth"
+	"lana/flagship-store/logging"
+	"lana/flagship-store/services/errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// limitRequests rejects the requests of clients exceeding their rate limit.
+func (app *App) limitRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		if app.allowRequest(response, request) {
+			next.ServeHTTP(response, request)
+		}
+	})
+}
+
+// allowRequest takes a token of the client of the request. Clients over
+// their limit are answered with 429 and the seconds to wait in Retry-After.
+// Clients are the authenticated principals or, for requests failing the
+// authentication, their IP. When the limiter fails the request is allowed,
+// so the store is not down with it.
+func (app *App) allowRequest(response http.ResponseWriter, request *http.Request) bool {
+	if app.Limiter == nil {
+		return true
+	}
+
+	allowed, retryAfter, err := app.Limiter.Allow(request.Context(), clientOf(request))
+	if err != nil {
+		logging.Annotate(request.Context(), "rate-limit-error", err.Error())
+		return true
+	}
+	if !allowed {
+		response.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		writeProblem(response, request, errors.NewRateLimitedError(retryAfter))
+	}
+	return allowed
+}
+
+func clientOf(request *http.Request) string {
+	if principal, authenticated := auth.FromContext(request.Context()); authenticated {
+		return principal.Id()
+	}
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		host = request.RemoteAddr
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,14 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limiter decides whether the client identified by key may make one more
+// request. When it may not, it returns how long the client should wait
+// before retrying. Limiters sharing their state in a store, e.g. Redis, can
+// be plugged in instead of the in-process TokenBucket.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (allowed bool, retryAfter time.Duration, err error)
+}
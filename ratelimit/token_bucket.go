@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// TokenBucket is an in-process Limiter with a bucket of tokens per client.
+// Every bucket holds up to burst tokens and is refilled at rate tokens per
+// second; every request takes a token. Buckets idle long enough to be full
+// again are forgotten, so the memory is bound to the active clients.
+type TokenBucket struct {
+	rate      float64
+	burst     float64
+	now       func() time.Time
+	mutex     sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	return &TokenBucket{
+		rate:    rate,
+		burst:   float64(burst),
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (limiter *TokenBucket) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	now := limiter.now()
+	limiter.sweep(now)
+
+	clientBucket, found := limiter.buckets[key]
+	if !found {
+		clientBucket = &bucket{tokens: limiter.burst, updatedAt: now}
+		limiter.buckets[key] = clientBucket
+	}
+	clientBucket.tokens = math.Min(limiter.burst, clientBucket.tokens+now.Sub(clientBucket.updatedAt).Seconds()*limiter.rate)
+	clientBucket.updatedAt = now
+
+	if clientBucket.tokens < 1 {
+		missing := (1 - clientBucket.tokens) / limiter.rate
+		return false, time.Duration(missing * float64(time.Second)), nil
+	}
+	clientBucket.tokens--
+	return true, 0, nil
+}
+
+// sweep forgets the buckets refilled since their last request, at most
+// once per refill period.
+func (limiter *TokenBucket) sweep(now time.Time) {
+	refillPeriod := time.Duration(limiter.burst / limiter.rate * float64(time.Second))
+	if now.Sub(limiter.lastSweep) < refillPeriod {
+		return
+	}
+	for key, clientBucket := range limiter.buckets {
+		if now.Sub(clientBucket.updatedAt) >= refillPeriod {
+			delete(limiter.buckets, key)
+		}
+	}
+	limiter.lastSweep = now
+}
@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"context"
+	"lana/flagship-store/utils/fakeclock"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTokenBucket(rate float64, burst int) (*TokenBucket, *fakeclock.Clock) {
+	clock := fakeclock.New()
+	limiter := NewTokenBucket(rate, burst)
+	limiter.now = clock.Now
+	return limiter, clock
+}
+
+func TestAllowUpToBurstRequests(t *testing.T) {
+	limiter, _ := newTestTokenBucket(1, 3)
+
+	for request := 0; request < 3; request++ {
+		allowed, _, err := limiter.Allow(context.Background(), "a-client")
+		assert.Nil(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, retryAfter, _ := limiter.Allow(context.Background(), "a-client")
+
+	assert.False(t, allowed)
+	assert.EqualValues(t, time.Second, retryAfter)
+}
+
+func TestAllowAgainOnceRefilled(t *testing.T) {
+	limiter, clock := newTestTokenBucket(2, 1)
+	limiter.Allow(context.Background(), "a-client")
+
+	_, retryAfter, _ := limiter.Allow(context.Background(), "a-client")
+	clock.Advance(retryAfter)
+	allowed, _, _ := limiter.Allow(context.Background(), "a-client")
+
+	assert.EqualValues(t, 500*time.Millisecond, retryAfter)
+	assert.True(t, allowed)
+}
+
+func TestLimitEveryClientApart(t *testing.T) {
+	limiter, _ := newTestTokenBucket(1, 1)
+	limiter.Allow(context.Background(), "a-client")
+
+	allowed, _, _ := limiter.Allow(context.Background(), "another-client")
+
+	assert.True(t, allowed)
+}
+
+func TestForgetBucketsOnceRefilled(t *testing.T) {
+	limiter, clock := newTestTokenBucket(1, 2)
+	limiter.Allow(context.Background(), "a-client")
+
+	clock.Advance(2 * time.Second)
+	limiter.Allow(context.Background(), "another-client")
+
+	assert.Len(t, limiter.buckets, 1)
+	assert.Contains(t, limiter.buckets, "another-client")
+}
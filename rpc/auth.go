@@ -0,0 +1,42 @@
+package rpc
+
+import (
+	"context"
+	"lana/flagship-store/auth"
+	"lana/flagship-store/ratelimit"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Authenticate returns an interceptor serving every call with its
+// principal, authenticated from the x-api-key or authorization metadata, in
+// the context. Calls without valid credentials fail as unauthenticated and
+// take a token of the IP of the client from limiter, if any, so credentials
+// can not be guessed at will: over its limit, they fail as resource
+// exhausted.
+func Authenticate(authenticator *auth.Authenticator, limiter ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		incoming, _ := metadata.FromIncomingContext(ctx)
+		principal, err := authenticator.Authenticate(auth.Credentials{
+			APIKey:      metadataCarrier(incoming).Get("x-api-key"),
+			BearerToken: bearerToken(metadataCarrier(incoming).Get("authorization")),
+		})
+		if err != nil {
+			if limitErr := allowCall(ctx, limiter); limitErr != nil {
+				return nil, limitErr
+			}
+			return nil, statusError(err)
+		}
+		return handler(auth.NewContext(ctx, principal), request)
+	}
+}
+
+func bearerToken(authorization string) string {
+	parts := strings.SplitN(authorization, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(parts[1])
+}
@@ -0,0 +1,209 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package checkoutpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// CheckoutServiceClient is the client API for CheckoutService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CheckoutServiceClient interface {
+	CreateCheckout(ctx context.Context, in *CreateCheckoutRequest, opts ...grpc.CallOption) (*Checkout, error)
+	AddProduct(ctx context.Context, in *AddProductRequest, opts ...grpc.CallOption) (*Checkout, error)
+	GetAmount(ctx context.Context, in *GetAmountRequest, opts ...grpc.CallOption) (*Amount, error)
+	DeleteCheckout(ctx context.Context, in *DeleteCheckoutRequest, opts ...grpc.CallOption) (*DeleteCheckoutResponse, error)
+}
+
+type checkoutServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCheckoutServiceClient(cc grpc.ClientConnInterface) CheckoutServiceClient {
+	return &checkoutServiceClient{cc}
+}
+
+func (c *checkoutServiceClient) CreateCheckout(ctx context.Context, in *CreateCheckoutRequest, opts ...grpc.CallOption) (*Checkout, error) {
+	out := new(Checkout)
+	err := c.cc.Invoke(ctx, "/flagshipstore.v1.CheckoutService/CreateCheckout", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *checkoutServiceClient) AddProduct(ctx context.Context, in *AddProductRequest, opts ...grpc.CallOption) (*Checkout, error) {
+	out := new(Checkout)
+	err := c.cc.Invoke(ctx, "/flagshipstore.v1.CheckoutService/AddProduct", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *checkoutServiceClient) GetAmount(ctx context.Context, in *GetAmountRequest, opts ...grpc.CallOption) (*Amount, error) {
+	out := new(Amount)
+	err := c.cc.Invoke(ctx, "/flagshipstore.v1.CheckoutService/GetAmount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *checkoutServiceClient) DeleteCheckout(ctx context.Context, in *DeleteCheckoutRequest, opts ...grpc.CallOption) (*DeleteCheckoutResponse, error) {
+	out := new(DeleteCheckoutResponse)
+	err := c.cc.Invoke(ctx, "/flagshipstore.v1.CheckoutService/DeleteCheckout", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CheckoutServiceServer is the server API for CheckoutService service.
+// All implementations must embed UnimplementedCheckoutServiceServer
+// for forward compatibility
+type CheckoutServiceServer interface {
+	CreateCheckout(context.Context, *CreateCheckoutRequest) (*Checkout, error)
+	AddProduct(context.Context, *AddProductRequest) (*Checkout, error)
+	GetAmount(context.Context, *GetAmountRequest) (*Amount, error)
+	DeleteCheckout(context.Context, *DeleteCheckoutRequest) (*DeleteCheckoutResponse, error)
+	mustEmbedUnimplementedCheckoutServiceServer()
+}
+
+// UnimplementedCheckoutServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedCheckoutServiceServer struct {
+}
+
+func (UnimplementedCheckoutServiceServer) CreateCheckout(context.Context, *CreateCheckoutRequest) (*Checkout, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCheckout not implemented")
+}
+func (UnimplementedCheckoutServiceServer) AddProduct(context.Context, *AddProductRequest) (*Checkout, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddProduct not implemented")
+}
+func (UnimplementedCheckoutServiceServer) GetAmount(context.Context, *GetAmountRequest) (*Amount, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAmount not implemented")
+}
+func (UnimplementedCheckoutServiceServer) DeleteCheckout(context.Context, *DeleteCheckoutRequest) (*DeleteCheckoutResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCheckout not implemented")
+}
+func (UnimplementedCheckoutServiceServer) mustEmbedUnimplementedCheckoutServiceServer() {}
+
+// UnsafeCheckoutServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CheckoutServiceServer will
+// result in compilation errors.
+type UnsafeCheckoutServiceServer interface {
+	mustEmbedUnimplementedCheckoutServiceServer()
+}
+
+func RegisterCheckoutServiceServer(s grpc.ServiceRegistrar, srv CheckoutServiceServer) {
+	s.RegisterService(&CheckoutService_ServiceDesc, srv)
+}
+
+func _CheckoutService_CreateCheckout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCheckoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CheckoutServiceServer).CreateCheckout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/flagshipstore.v1.CheckoutService/CreateCheckout",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CheckoutServiceServer).CreateCheckout(ctx, req.(*CreateCheckoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CheckoutService_AddProduct_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddProductRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CheckoutServiceServer).AddProduct(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/flagshipstore.v1.CheckoutService/AddProduct",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CheckoutServiceServer).AddProduct(ctx, req.(*AddProductRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CheckoutService_GetAmount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAmountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CheckoutServiceServer).GetAmount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/flagshipstore.v1.CheckoutService/GetAmount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CheckoutServiceServer).GetAmount(ctx, req.(*GetAmountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CheckoutService_DeleteCheckout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCheckoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CheckoutServiceServer).DeleteCheckout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/flagshipstore.v1.CheckoutService/DeleteCheckout",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CheckoutServiceServer).DeleteCheckout(ctx, req.(*DeleteCheckoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CheckoutService_ServiceDesc is the grpc.ServiceDesc for CheckoutService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CheckoutService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "flagshipstore.v1.CheckoutService",
+	HandlerType: (*CheckoutServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateCheckout",
+			Handler:    _CheckoutService_CreateCheckout_Handler,
+		},
+		{
+			MethodName: "AddProduct",
+			Handler:    _CheckoutService_AddProduct_Handler,
+		},
+		{
+			MethodName: "GetAmount",
+			Handler:    _CheckoutService_GetAmount_Handler,
+		},
+		{
+			MethodName: "DeleteCheckout",
+			Handler:    _CheckoutService_DeleteCheckout_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "rpc/checkout.proto",
+}
@@ -0,0 +1,59 @@
+package rpc
+
+import (
+	"context"
+	"lana/flagship-store/auth"
+	"lana/flagship-store/ratelimit"
+	"lana/flagship-store/services/errors"
+	"math"
+	"net"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Limit returns an interceptor failing as resource exhausted the calls of
+// clients exceeding their rate limit, with the seconds to wait in the
+// retry-after header. Clients are the principals authenticated by a
+// previous interceptor or their IP. Calls are served when the limiter
+// fails.
+func Limit(limiter ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := allowCall(ctx, limiter); err != nil {
+			return nil, err
+		}
+		return handler(ctx, request)
+	}
+}
+
+// allowCall takes a token of the client of the call, failing as resource
+// exhausted when the client is over its limit. Without a limiter, or when
+// it fails, the call is allowed.
+func allowCall(ctx context.Context, limiter ratelimit.Limiter) error {
+	if limiter == nil {
+		return nil
+	}
+	allowed, retryAfter, err := limiter.Allow(ctx, clientOf(ctx))
+	if err == nil && !allowed {
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))))
+		return statusError(errors.NewRateLimitedError(retryAfter))
+	}
+	return nil
+}
+
+func clientOf(ctx context.Context) string {
+	if principal, authenticated := auth.FromContext(ctx); authenticated {
+		return principal.Id()
+	}
+	client, _ := peer.FromContext(ctx)
+	if client == nil || client.Addr == nil {
+		return "ip:unknown"
+	}
+	host, _, err := net.SplitHostPort(client.Addr.String())
+	if err != nil {
+		host = client.Addr.String()
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/rpc/checkoutpb"
+	"lana/flagship-store/services"
+	"lana/flagship-store/services/commands"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type CheckoutServer struct {
+	checkoutpb.UnimplementedCheckoutServiceServer
+	CreateCheckoutService         services.CreateCheckout
+	AddProductToCheckoutService   services.AddProductToCheckout
+	RetrieveCheckoutAmountService services.RetrieveCheckoutAmount
+	DeleteCheckoutService         services.DeleteCheckout
+}
+
+func NewCheckoutServer(createCheckoutService services.CreateCheckout, addProductToCheckoutService services.AddProductToCheckout, retrieveCheckoutAmountService services.RetrieveCheckoutAmount, deleteCheckoutService services.DeleteCheckout) *CheckoutServer {
+	return &CheckoutServer{
+		CreateCheckoutService:         createCheckoutService,
+		AddProductToCheckoutService:   addProductToCheckoutService,
+		RetrieveCheckoutAmountService: retrieveCheckoutAmountService,
+		DeleteCheckoutService:         deleteCheckoutService,
+	}
+}
+
+func NewServer(checkoutServer *CheckoutServer, options ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(append([]grpc.ServerOption{grpc.ChainUnaryInterceptor(traceCalls)}, options...)...)
+	checkoutpb.RegisterCheckoutServiceServer(server, checkoutServer)
+	return server
+}
+
+func (server *CheckoutServer) CreateCheckout(ctx context.Context, request *checkoutpb.CreateCheckoutRequest) (*checkoutpb.Checkout, error) {
+	createCommand := commands.CreateCheckout{}
+	for _, line := range request.GetLines() {
+		createCommand.Lines = append(createCommand.Lines, newLineCommand(line))
+	}
+
+	checkout, err := server.CreateCheckoutService.Do(ctx, createCommand)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return newCheckoutMessage(checkout), nil
+}
+
+func (server *CheckoutServer) AddProduct(ctx context.Context, request *checkoutpb.AddProductRequest) (*checkoutpb.Checkout, error) {
+	checkout, err := server.AddProductToCheckoutService.Do(ctx, newLineCommand(request.GetLine()), request.GetCheckoutId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return newCheckoutMessage(checkout), nil
+}
+
+func (server *CheckoutServer) GetAmount(ctx context.Context, request *checkoutpb.GetAmountRequest) (*checkoutpb.Amount, error) {
+	amount, err := server.RetrieveCheckoutAmountService.Do(ctx, request.GetCheckoutId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &checkoutpb.Amount{CheckoutId: request.GetCheckoutId(), Amount: int64(amount)}, nil
+}
+
+func (server *CheckoutServer) DeleteCheckout(ctx context.Context, request *checkoutpb.DeleteCheckoutRequest) (*checkoutpb.DeleteCheckoutResponse, error) {
+	if _, err := server.DeleteCheckoutService.Do(ctx, request.GetCheckoutId()); err != nil {
+		return nil, statusError(err)
+	}
+	return &checkoutpb.DeleteCheckoutResponse{}, nil
+}
+
+func newLineCommand(line *checkoutpb.Line) commands.Line {
+	return commands.Line{ProductCode: line.GetProductCode(), Quantity: int(line.GetQuantity())}
+}
+
+func newCheckoutMessage(checkout models.Checkout) *checkoutpb.Checkout {
+	return &checkoutpb.Checkout{
+		Id:        checkout.Id,
+		Products:  checkout.Products,
+		Status:    checkout.Status,
+		CreatedAt: timestamppb.New(checkout.CreatedAt),
+		UpdatedAt: timestamppb.New(checkout.UpdatedAt),
+	}
+}
@@ -0,0 +1,210 @@
+package rpc
+
+import (
+	"context"
+	"lana/flagship-store/auth"
+	"lana/flagship-store/models"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/ratelimit"
+	"lana/flagship-store/rpc/checkoutpb"
+	"lana/flagship-store/services"
+	"lana/flagship-store/utils/mocks"
+	"net"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newClient serves the checkout server to the client returned. Without
+// options the calls are not authenticated but trusted internal calls.
+func newClient(t *testing.T, checkoutServer *CheckoutServer, options ...grpc.ServerOption) checkoutpb.CheckoutServiceClient {
+	if len(options) == 0 {
+		options = []grpc.ServerOption{grpc.UnaryInterceptor(trustCalls)}
+	}
+	listener := bufconn.Listen(1024 * 1024)
+	server := NewServer(checkoutServer, options...)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	dialer := func(ctx context.Context, address string) (net.Conn, error) {
+		return listener.Dial()
+	}
+	connection, err := grpc.Dial("bufnet", grpc.WithContextDialer(dialer), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { connection.Close() })
+	return checkoutpb.NewCheckoutServiceClient(connection)
+}
+
+func trustCalls(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(policy.WithTrustedCaller(ctx), request)
+}
+
+func ProductRepositoryMockWithAllProducts() *mocks.ProductRepositoryMock {
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "PEN").Return(models.Product{Code: "PEN", Name: "Lana Pen", Price: 500}, true)
+	theProductRepositoryMock.On("SearchById", "TSHIRT").Return(models.Product{Code: "TSHIRT", Name: "Lana T-Shirt", Price: 2000}, true)
+	theProductRepositoryMock.On("SearchById", "MUG").Return(models.Product{Code: "MUG", Name: "Lana Coffee Mug", Price: 750}, true)
+	theProductRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	return &theProductRepositoryMock
+}
+
+func TestCreateCheckout(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	client := newClient(t, &CheckoutServer{
+		CreateCheckoutService: services.NewCreateCheckout(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts()),
+	})
+
+	checkout, err := client.CreateCheckout(context.Background(), &checkoutpb.CreateCheckoutRequest{
+		Lines: []*checkoutpb.Line{{ProductCode: "PEN", Quantity: 2}},
+	})
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, checkout.Id)
+	assert.EqualValues(t, []string{"PEN", "PEN"}, checkout.Products)
+	assert.EqualValues(t, models.CheckoutStatusOpen, checkout.Status)
+	theCheckoutRepositoryMock.AssertExpectations(t)
+}
+
+func TestCreateCheckoutReturnInvalidArgumentWhenLineIsNotValid(t *testing.T) {
+	client := newClient(t, &CheckoutServer{
+		CreateCheckoutService: services.NewCreateCheckout(&mocks.CheckoutRepositoryMock{}, &mocks.ProductRepositoryMock{}),
+	})
+
+	_, err := client.CreateCheckout(context.Background(), &checkoutpb.CreateCheckoutRequest{
+		Lines: []*checkoutpb.Line{{ProductCode: "PEN"}},
+	})
+
+	assert.EqualValues(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAddProductReturnFailedPreconditionWhenProductDoesNotExist(t *testing.T) {
+	checkout := models.Checkout{Id: uuid.NewString(), Products: []string{"MUG"}}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	client := newClient(t, &CheckoutServer{
+		AddProductToCheckoutService: services.NewAddProductToCheckout(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts()),
+	})
+
+	_, err := client.AddProduct(context.Background(), &checkoutpb.AddProductRequest{
+		CheckoutId: checkout.Id,
+		Line:       &checkoutpb.Line{ProductCode: "FAKE", Quantity: 1},
+	})
+
+	assert.EqualValues(t, codes.FailedPrecondition, status.Code(err))
+	assert.EqualValues(t, "Product FAKE not found", status.Convert(err).Message())
+}
+
+func TestGetAmount(t *testing.T) {
+	checkout := models.Checkout{Id: uuid.NewString(), Products: []string{"MUG", "MUG"}}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	noPromotions := mocks.ProductWithPromotionRepositoryMock{}
+	noPromotions.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	noDiscounts := mocks.ProductWithDiscountRepositoryMock{}
+	noDiscounts.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	client := newClient(t, &CheckoutServer{
+		RetrieveCheckoutAmountService: services.NewRetrieveCheckoutAmount(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts(), &noPromotions, &noDiscounts),
+	})
+
+	amount, err := client.GetAmount(context.Background(), &checkoutpb.GetAmountRequest{CheckoutId: checkout.Id})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, checkout.Id, amount.CheckoutId)
+	assert.EqualValues(t, 1500, amount.Amount)
+}
+
+func TestDeleteCheckoutReturnNotFoundWhenCheckoutDoesNotExist(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", "a_fake_checkout").Return(models.Checkout{}, false)
+	client := newClient(t, &CheckoutServer{
+		DeleteCheckoutService: services.NewDeleteCheckout(&theCheckoutRepositoryMock),
+	})
+
+	_, err := client.DeleteCheckout(context.Background(), &checkoutpb.DeleteCheckoutRequest{CheckoutId: "a_fake_checkout"})
+
+	assert.EqualValues(t, codes.NotFound, status.Code(err))
+	assert.EqualValues(t, "Checkout a_fake_checkout not found", status.Convert(err).Message())
+}
+
+func TestCallsWithoutCredentialsFailAsUnauthenticated(t *testing.T) {
+	authenticator := auth.NewAuthenticator(map[string]auth.APIKey{"billing": {Key: "a-key", Role: auth.RoleShopper}}, nil, "")
+	client := newClient(t, &CheckoutServer{}, grpc.ChainUnaryInterceptor(Authenticate(authenticator, nil)))
+
+	_, err := client.GetAmount(context.Background(), &checkoutpb.GetAmountRequest{CheckoutId: uuid.NewString()})
+
+	assert.EqualValues(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestCreateCheckoutBoundToTheAPIKey(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	authenticator := auth.NewAuthenticator(map[string]auth.APIKey{"billing": {Key: "a-key", Role: auth.RoleShopper}}, nil, "")
+	client := newClient(t, &CheckoutServer{
+		CreateCheckoutService: services.NewCreateCheckout(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts()),
+	}, grpc.ChainUnaryInterceptor(Authenticate(authenticator, nil)))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "a-key")
+	_, err := client.CreateCheckout(ctx, &checkoutpb.CreateCheckoutRequest{})
+
+	assert.Nil(t, err)
+	persisted := theCheckoutRepositoryMock.Calls[0].Arguments.Get(0).(models.Checkout)
+	assert.EqualValues(t, "api-key:billing", persisted.Owner)
+}
+
+func TestCreateCheckoutFailsAsPermissionDeniedForMerchandisers(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	authenticator := auth.NewAuthenticator(map[string]auth.APIKey{"crm": {Key: "a-key", Role: auth.RoleMerchandiser}}, nil, "")
+	client := newClient(t, &CheckoutServer{
+		CreateCheckoutService: services.NewCreateCheckout(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts()),
+	}, grpc.ChainUnaryInterceptor(Authenticate(authenticator, nil)))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "a-key")
+	_, err := client.CreateCheckout(ctx, &checkoutpb.CreateCheckoutRequest{})
+
+	assert.EqualValues(t, codes.PermissionDenied, status.Code(err))
+	assert.Empty(t, theCheckoutRepositoryMock.Calls)
+}
+
+func TestCallsOverTheRateLimitFailAsResourceExhausted(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	authenticator := auth.NewAuthenticator(map[string]auth.APIKey{"billing": {Key: "a-key", Role: auth.RoleShopper}}, nil, "")
+	client := newClient(t, &CheckoutServer{
+		CreateCheckoutService: services.NewCreateCheckout(&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts()),
+	}, grpc.ChainUnaryInterceptor(Authenticate(authenticator, nil), Limit(ratelimit.NewTokenBucket(0.5, 1))))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "a-key")
+	_, firstErr := client.CreateCheckout(ctx, &checkoutpb.CreateCheckoutRequest{})
+	var header metadata.MD
+	_, err := client.CreateCheckout(ctx, &checkoutpb.CreateCheckoutRequest{}, grpc.Header(&header))
+
+	assert.Nil(t, firstErr)
+	assert.EqualValues(t, codes.ResourceExhausted, status.Code(err))
+	assert.EqualValues(t, []string{"2"}, header.Get("retry-after"))
+	theCheckoutRepositoryMock.AssertNumberOfCalls(t, "Persist", 1)
+}
+
+func TestCallsFailingTheAuthenticationOverTheRateLimitOfTheirIPFailAsResourceExhausted(t *testing.T) {
+	authenticator := auth.NewAuthenticator(map[string]auth.APIKey{"billing": {Key: "a-key", Role: auth.RoleShopper}}, nil, "")
+	limiter := ratelimit.NewTokenBucket(0.5, 1)
+	client := newClient(t, &CheckoutServer{}, grpc.ChainUnaryInterceptor(Authenticate(authenticator, limiter), Limit(limiter)))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "a-wrong-key")
+	_, firstErr := client.GetAmount(ctx, &checkoutpb.GetAmountRequest{CheckoutId: uuid.NewString()})
+	var header metadata.MD
+	_, err := client.GetAmount(ctx, &checkoutpb.GetAmountRequest{CheckoutId: uuid.NewString()}, grpc.Header(&header))
+
+	assert.EqualValues(t, codes.Unauthenticated, status.Code(firstErr))
+	assert.EqualValues(t, codes.ResourceExhausted, status.Code(err))
+	assert.EqualValues(t, []string{"2"}, header.Get("retry-after"))
+}
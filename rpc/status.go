@@ -0,0 +1,38 @@
+package rpc
+
+import (
+	"lana/flagship-store/services/errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var statusCodes = map[errors.Code]codes.Code{
+	errors.CodeInvalidParameter:   codes.InvalidArgument,
+	errors.CodeValidation:         codes.InvalidArgument,
+	errors.CodeCheckoutNotFound:   codes.NotFound,
+	errors.CodeProductNotFound:    codes.FailedPrecondition,
+	errors.CodeQuantityExceeded:   codes.FailedPrecondition,
+	errors.CodeCheckoutNotOpen:    codes.FailedPrecondition,
+	errors.CodeCheckoutModified:   codes.Aborted,
+	errors.CodeCanceled:           codes.Canceled,
+	errors.CodeDeadlineExceeded:   codes.DeadlineExceeded,
+	errors.CodeUnauthorized:       codes.Unauthenticated,
+	errors.CodeForbidden:          codes.PermissionDenied,
+	errors.CodeRateLimited:        codes.ResourceExhausted,
+	errors.CodeOpenCheckoutsLimit: codes.ResourceExhausted,
+}
+
+// statusError maps a service error to a gRPC status. Errors unknown to the
+// services are reported as internal without exposing their message.
+func statusError(err error) error {
+	code := errors.CodeOf(err)
+	statusCode, mapped := statusCodes[code]
+	if !mapped {
+		return status.Error(codes.Internal, "internal error")
+	}
+
+	var serviceError *errors.Error
+	errors.As(err, &serviceError)
+	return status.Error(statusCode, serviceError.Message)
+}
@@ -0,0 +1,59 @@
+package rpc
+
+import (
+	"context"
+	"lana/flagship-store/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// traceCalls serves every call in a span named by its method, child of the
+// span propagated by the client in the traceparent metadata, if any.
+func traceCalls(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	incoming, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(incoming))
+	ctx, span := tracing.Tracer().Start(ctx, info.FullMethod,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("rpc.system", "grpc"), attribute.String("rpc.method", info.FullMethod)),
+	)
+	defer span.End()
+
+	response, err := handler(ctx, request)
+	if err != nil {
+		callStatus := status.Convert(err)
+		span.RecordError(err)
+		span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(callStatus.Code())))
+		span.SetStatus(codes.Error, callStatus.Message())
+	}
+	return response, err
+}
+
+// metadataCarrier reads and writes the propagated trace context in the
+// metadata of a call.
+type metadataCarrier metadata.MD
+
+func (carrier metadataCarrier) Get(key string) string {
+	values := metadata.MD(carrier).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (carrier metadataCarrier) Set(key string, value string) {
+	metadata.MD(carrier).Set(key, value)
+}
+
+func (carrier metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(carrier))
+	for key := range carrier {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"lana/flagship-store/logging"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/services/responses"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func (app *App) initializeSavedListRoutes(router *mux.Router) {
+	router.HandleFunc("/saved-lists", app.authorize(policy.CreateSavedList, app.createSavedList)).Methods("POST")
+	router.HandleFunc("/saved-lists", app.authorize(policy.ListSavedLists, app.listSavedLists)).Methods("GET")
+	router.HandleFunc("/saved-lists/{id}/checkouts", app.authorize(policy.CheckoutSavedList, app.idempotent(app.checkoutSavedList))).Methods("POST")
+}
+
+func (app *App) createSavedList(response http.ResponseWriter, request *http.Request) {
+	var createCommand commands.CreateSavedList
+	if err := decodeBody(response, request, &createCommand); err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	savedList, err := app.CreateSavedListService.Do(request.Context(), createCommand)
+	if err != nil {
+		writeProblem(response, request, err, problemStatus{errors.CodeProductNotFound, http.StatusUnprocessableEntity}, problemStatus{errors.CodeCheckoutNotFound, http.StatusUnprocessableEntity})
+		return
+	}
+
+	logging.Annotate(request.Context(), "saved-list-id", savedList.Id)
+	response.WriteHeader(http.StatusCreated)
+	json.NewEncoder(response).Encode(savedList)
+}
+
+func (app *App) listSavedLists(response http.ResponseWriter, request *http.Request) {
+	savedLists, err := app.ListSavedListsService.Do(request.Context())
+	if err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(responses.SavedLists{SavedLists: savedLists})
+}
+
+// checkoutSavedList answers with the created checkout and the products of
+// the saved list left out of it because they are no longer sold.
+func (app *App) checkoutSavedList(response http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	id := vars["id"]
+
+	checkout, unavailableProducts, err := app.CheckoutSavedListService.Do(request.Context(), id)
+	if err != nil {
+		writeProblem(response, request, err)
+		return
+	}
+
+	logging.Annotate(request.Context(), "checkout-id", checkout.Id)
+	response.WriteHeader(http.StatusCreated)
+	json.NewEncoder(response).Encode(responses.SavedListCheckout{Checkout: checkout, UnavailableProducts: unavailableProducts})
+}
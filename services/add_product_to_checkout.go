@@ -1,10 +1,15 @@
 package services
 
 import (
+	"context"
+	"lana/flagship-store/metrics"
 	"lana/flagship-store/models"
 	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
 	"lana/flagship-store/services/commands"
 	"lana/flagship-store/services/errors"
+	"lana/flagship-store/tracing"
+	"time"
 )
 
 type AddProductToCheckout struct {
@@ -16,18 +21,46 @@ func NewAddProductToCheckout(checkoutRepository persistence.CheckoutRepository,
 	return AddProductToCheckout{checkoutRepository, productRepository}
 }
 
-func (service *AddProductToCheckout) Do(addProductCommand commands.AddProduct, checkoutId string) (models.Checkout, error) {
-	checkout, existCheckout := service.CheckoutRepository.SearchById(checkoutId)
+func (service *AddProductToCheckout) Do(ctx context.Context, lineCommand commands.Line, checkoutId string) (models.Checkout, error) {
+	ctx, span := tracing.Start(ctx, "services.AddProductToCheckout")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.AddProductToCheckout); err != nil {
+		return models.Checkout{}, err
+	}
+
+	if err := lineCommand.Validate(); err != nil {
+		return models.Checkout{}, err
+	}
+
+	checkout, existCheckout := service.CheckoutRepository.SearchById(ctx, checkoutId)
 	if !existCheckout {
-		return models.Checkout{}, errors.NewCheckoutNotFoundError()
+		return models.Checkout{}, errors.NewCheckoutNotFoundError(checkoutId)
+	}
+	if err := checkOwner(ctx, checkout); err != nil {
+		return models.Checkout{}, err
+	}
+	if err := checkOpen(checkout); err != nil {
+		return models.Checkout{}, err
+	}
+
+	if _, existProduct := service.ProductRepository.SearchById(ctx, lineCommand.ProductCode); !existProduct {
+		return models.Checkout{}, errors.NewProductNotFoundError(lineCommand.ProductCode)
 	}
 
-	if _, existProduct := service.ProductRepository.SearchById(addProductCommand.Code); !existProduct {
-		return models.Checkout{}, errors.NewProductNotFoundError()
+	if countProductUnits(checkout.Products, lineCommand.ProductCode)+lineCommand.Quantity > models.MaxProductQuantity {
+		return models.Checkout{}, errors.NewQuantityLimitExceededError(lineCommand.ProductCode, models.MaxProductQuantity)
 	}
 
-	checkout.Products = append(checkout.Products, addProductCommand.Code)
-	service.CheckoutRepository.Persist(checkout)
+	checkout.Products = appendProductUnits(checkout.Products, lineCommand)
+	checkout.UpdatedAt = time.Now()
+	if err := checkContext(ctx); err != nil {
+		return models.Checkout{}, err
+	}
+	if !service.CheckoutRepository.PersistIfStatus(ctx, checkout, checkout.Status) {
+		return models.Checkout{}, refusedWriteError(ctx, service.CheckoutRepository, checkout.Id)
+	}
+	metrics.ProductsAdded.WithLabelValues(lineCommand.ProductCode).Add(float64(lineCommand.Quantity))
 
 	return checkout, nil
 
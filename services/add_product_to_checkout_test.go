@@ -1,6 +1,7 @@
 package services
 
 import (
+	"lana/flagship-store/auth"
 	"lana/flagship-store/models"
 	"lana/flagship-store/services/commands"
 	"lana/flagship-store/services/errors"
@@ -19,19 +20,19 @@ func TestAddProductToCheckout(t *testing.T) {
 	}
 	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
 	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
-	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), mock.AnythingOfType("string")).Return(true)
 	theProductRepositoryMock := mocks.ProductRepositoryMock{}
 	theProductRepositoryMock.On("SearchById", "PEN").Return(models.Product{}, true)
-	addProductCommand := commands.AddProduct{Code: "PEN"}
+	lineCommand := commands.Line{ProductCode: "PEN", Quantity: 1}
 	addProductToCheckout := AddProductToCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock}
 
-	modifiedCheckout, _ := addProductToCheckout.Do(addProductCommand, checkout.Id)
+	modifiedCheckout, _ := addProductToCheckout.Do(trustedContext(), lineCommand, checkout.Id)
 
 	assert.NotNil(t, modifiedCheckout.Id)
 	assert.EqualValues(t, "MUG", modifiedCheckout.Products[0])
 	assert.EqualValues(t, "PEN", modifiedCheckout.Products[1])
 	assert.EqualValues(t, 2, len(modifiedCheckout.Products))
-	theCheckoutRepositoryMock.AssertNumberOfCalls(t, "Persist", 1)
+	theCheckoutRepositoryMock.AssertNumberOfCalls(t, "PersistIfStatus", 1)
 	theCheckoutRepositoryMock.AssertExpectations(t)
 }
 
@@ -39,12 +40,12 @@ func TestAddProductReturnCheckoutNotFoundErrorWhenCheckoutDoesnotExists(t *testi
 	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
 	theCheckoutRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Checkout{}, false)
 	theProductRepositoryMock := mocks.ProductRepositoryMock{}
-	addProductCommand := commands.AddProduct{Code: "PEN"}
+	lineCommand := commands.Line{ProductCode: "PEN", Quantity: 1}
 	addProductToCheckout := AddProductToCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock}
 
-	_, err := addProductToCheckout.Do(addProductCommand, "a_fake_id")
+	_, err := addProductToCheckout.Do(trustedContext(), lineCommand, "a_fake_id")
 
-	_, isCheckoutNotFoundError := err.(*errors.CheckoutNotFoundError)
+	isCheckoutNotFoundError := errors.Is(err, errors.ErrCheckoutNotFound)
 	assert.EqualValues(t, true, isCheckoutNotFoundError)
 }
 
@@ -57,11 +58,112 @@ func TestAddProductReturnProductNotFoundErrorWhenProductDoesnotExists(t *testing
 	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
 	theProductRepositoryMock := mocks.ProductRepositoryMock{}
 	theProductRepositoryMock.On("SearchById", "PEN").Return(models.Product{}, false)
-	addProductCommand := commands.AddProduct{Code: "PEN"}
+	lineCommand := commands.Line{ProductCode: "PEN", Quantity: 1}
 	addProductToCheckout := AddProductToCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock}
 
-	_, err := addProductToCheckout.Do(addProductCommand, checkout.Id)
+	_, err := addProductToCheckout.Do(trustedContext(), lineCommand, checkout.Id)
 
-	_, isProductNotFoundError := err.(*errors.ProductNotFoundError)
+	isProductNotFoundError := errors.Is(err, errors.ErrProductNotFound)
 	assert.EqualValues(t, true, isProductNotFoundError)
 }
+
+func TestAddProductReturnValidationErrorWhenProductCodeIsEmpty(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	addProductToCheckout := AddProductToCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock}
+
+	_, err := addProductToCheckout.Do(trustedContext(), commands.Line{}, "an_id")
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrValidation))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "SearchById", mock.Anything)
+}
+
+func TestAddProductWithQuantityToCheckout(t *testing.T) {
+	checkout := models.Checkout{
+		Id:       uuid.NewString(),
+		Products: []string{"MUG"},
+	}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), mock.AnythingOfType("string")).Return(true)
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "PEN").Return(models.Product{}, true)
+	addProductToCheckout := AddProductToCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock}
+
+	modifiedCheckout, _ := addProductToCheckout.Do(trustedContext(), commands.Line{ProductCode: "PEN", Quantity: 3}, checkout.Id)
+
+	assert.EqualValues(t, []string{"MUG", "PEN", "PEN", "PEN"}, modifiedCheckout.Products)
+}
+
+func TestAddProductReturnQuantityLimitExceededErrorWhenCheckoutIsFull(t *testing.T) {
+	checkout := models.Checkout{
+		Id:       uuid.NewString(),
+		Products: []string{"PEN", "PEN"},
+	}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "PEN").Return(models.Product{}, true)
+	addProductToCheckout := AddProductToCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock}
+
+	_, err := addProductToCheckout.Do(trustedContext(), commands.Line{ProductCode: "PEN", Quantity: models.MaxProductQuantity - 1}, checkout.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrQuantityLimitExceeded))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "PersistIfStatus", mock.Anything, mock.Anything)
+}
+
+func TestAddProductReturnForbiddenErrorWhenCheckoutBelongsToAnotherOwner(t *testing.T) {
+	checkout := models.Checkout{
+		Id:       uuid.NewString(),
+		Products: []string{"MUG"},
+		Owner:    "jwt:another-shopper",
+	}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	lineCommand := commands.Line{ProductCode: "PEN", Quantity: 1}
+	addProductToCheckout := AddProductToCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock}
+	ctx := principalContext(auth.RoleShopper, "a-shopper")
+
+	_, err := addProductToCheckout.Do(ctx, lineCommand, checkout.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrForbidden))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "PersistIfStatus", mock.Anything, mock.Anything)
+}
+
+func TestAddProductToCheckoutOfTheSameOwner(t *testing.T) {
+	checkout := models.Checkout{
+		Id:       uuid.NewString(),
+		Products: []string{"MUG"},
+		Owner:    "jwt:a-shopper",
+	}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), mock.AnythingOfType("string")).Return(true)
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "PEN").Return(models.Product{}, true)
+	lineCommand := commands.Line{ProductCode: "PEN", Quantity: 1}
+	addProductToCheckout := AddProductToCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock}
+	ctx := principalContext(auth.RoleShopper, "a-shopper")
+
+	modifiedCheckout, err := addProductToCheckout.Do(ctx, lineCommand, checkout.Id)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, len(modifiedCheckout.Products))
+	theCheckoutRepositoryMock.AssertExpectations(t)
+}
+
+func TestAddProductReturnCheckoutNotOpenErrorWhenTheCheckoutIsCompletedWhileAdding(t *testing.T) {
+	checkout := models.Checkout{Id: uuid.NewString(), Products: []string{"MUG"}, Status: models.CheckoutStatusOpen}
+	completed := checkout
+	completed.Status = models.CheckoutStatusCompleted
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true).Once()
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(completed, true)
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), models.CheckoutStatusOpen).Return(false)
+	addProductToCheckout := AddProductToCheckout{&theCheckoutRepositoryMock, ProductRepositoryMockWithAllProducts()}
+
+	_, err := addProductToCheckout.Do(trustedContext(), commands.Line{ProductCode: "PEN", Quantity: 1}, checkout.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrCheckoutNotOpen))
+}
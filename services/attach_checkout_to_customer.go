@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/tracing"
+	"time"
+)
+
+type AttachCheckoutToCustomer struct {
+	CheckoutRepository persistence.CheckoutRepository
+	CustomerRepository persistence.CustomerRepository
+}
+
+func NewAttachCheckoutToCustomer(checkoutRepository persistence.CheckoutRepository, customerRepository persistence.CustomerRepository) AttachCheckoutToCustomer {
+	return AttachCheckoutToCustomer{checkoutRepository, customerRepository}
+}
+
+// Do attaches the checkout to the customer, e.g. when a guest logs in. When
+// the customer already has an open basket the products of the checkout are
+// merged into it and the checkout is deleted, so the customer keeps a
+// single basket. The basket of the customer is returned.
+func (service *AttachCheckoutToCustomer) Do(ctx context.Context, attachCommand commands.AttachCustomer, checkoutId string) (models.Checkout, error) {
+	ctx, span := tracing.Start(ctx, "services.AttachCheckoutToCustomer")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.AttachCheckoutToCustomer); err != nil {
+		return models.Checkout{}, err
+	}
+
+	if err := attachCommand.Validate(); err != nil {
+		return models.Checkout{}, err
+	}
+
+	checkout, existCheckout := service.CheckoutRepository.SearchById(ctx, checkoutId)
+	if !existCheckout {
+		return models.Checkout{}, errors.NewCheckoutNotFoundError(checkoutId)
+	}
+	if err := checkOwner(ctx, checkout); err != nil {
+		return models.Checkout{}, err
+	}
+	if err := checkOpen(checkout); err != nil {
+		return models.Checkout{}, err
+	}
+
+	customer, existCustomer := service.CustomerRepository.SearchById(ctx, attachCommand.CustomerId)
+	if !existCustomer {
+		return models.Checkout{}, errors.NewCustomerNotFoundError(attachCommand.CustomerId)
+	}
+	if err := checkCustomerOwner(ctx, customer); err != nil {
+		return models.Checkout{}, err
+	}
+
+	if checkout.CustomerId == customer.Id {
+		return checkout, nil
+	}
+	if checkout.CustomerId != "" {
+		return models.Checkout{}, errors.NewCheckoutAttachedError(checkout.Id)
+	}
+
+	if err := checkContext(ctx); err != nil {
+		return models.Checkout{}, err
+	}
+	basket, hasBasket := service.openBasketOf(ctx, customer)
+	if !hasBasket {
+		checkout.CustomerId = customer.Id
+		checkout.UpdatedAt = time.Now()
+		if !service.CheckoutRepository.PersistIfStatus(ctx, checkout, checkout.Status) {
+			return models.Checkout{}, refusedWriteError(ctx, service.CheckoutRepository, checkout.Id)
+		}
+		return checkout, nil
+	}
+
+	if err := checkOwner(ctx, basket); err != nil {
+		return models.Checkout{}, err
+	}
+	products, err := mergeProducts(basket.Products, checkout.Products)
+	if err != nil {
+		return models.Checkout{}, err
+	}
+	basket.Products = products
+	basket.UpdatedAt = time.Now()
+	if err := moveCheckout(ctx, service.CheckoutRepository, checkout, basket); err != nil {
+		return models.Checkout{}, err
+	}
+
+	return basket, nil
+}
+
+// openBasketOf returns the last open checkout attached to the customer.
+func (service *AttachCheckoutToCustomer) openBasketOf(ctx context.Context, customer models.Customer) (models.Checkout, bool) {
+	baskets := service.CheckoutRepository.Search(ctx, persistence.CheckoutCriteria{
+		CustomerId: customer.Id,
+		Status:     models.CheckoutStatusOpen,
+		SortBy:     persistence.SortByCreatedAt,
+		Descending: true,
+		Limit:      1,
+	})
+	if len(baskets) == 0 {
+		return models.Checkout{}, false
+	}
+	return baskets[0], true
+}
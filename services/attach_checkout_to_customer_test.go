@@ -0,0 +1,109 @@
+package services
+
+import (
+	"lana/flagship-store/auth"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func customerRepositoryMockWith(customer models.Customer) *mocks.CustomerRepositoryMock {
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("SearchById", customer.Id).Return(customer, true)
+	return &theCustomerRepositoryMock
+}
+
+func TestAttachCheckoutToCustomerWithoutOpenBasket(t *testing.T) {
+	checkout := models.Checkout{Id: "a-guest-checkout", Products: []string{"PEN"}, Status: models.CheckoutStatusOpen, Owner: "jwt:a-shopper"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCheckoutRepositoryMock.On("Search", mock.AnythingOfType("persistence.CheckoutCriteria")).Return([]models.Checkout{})
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), mock.AnythingOfType("string")).Return(true)
+	attachCheckout := AttachCheckoutToCustomer{&theCheckoutRepositoryMock, customerRepositoryMockWith(models.Customer{Id: "a-customer", Owner: "jwt:a-shopper"})}
+
+	attached, err := attachCheckout.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.AttachCustomer{CustomerId: "a-customer"}, checkout.Id)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "a-guest-checkout", attached.Id)
+	assert.EqualValues(t, "a-customer", attached.CustomerId)
+	criteria := theCheckoutRepositoryMock.Calls[1].Arguments.Get(0).(persistence.CheckoutCriteria)
+	assert.EqualValues(t, "a-customer", criteria.CustomerId)
+	assert.EqualValues(t, models.CheckoutStatusOpen, criteria.Status)
+	theCheckoutRepositoryMock.AssertNotCalled(t, "DeleteIfStatus", mock.Anything, mock.Anything)
+}
+
+func TestAttachCheckoutMergesItIntoTheOpenBasketOfTheCustomer(t *testing.T) {
+	guest := models.Checkout{Id: "a-guest-checkout", Products: []string{"PEN", "MUG"}, Status: models.CheckoutStatusOpen, Owner: "jwt:a-shopper"}
+	basket := models.Checkout{Id: "a-basket", Products: []string{"PEN"}, Status: models.CheckoutStatusOpen, Owner: "jwt:a-shopper", CustomerId: "a-customer"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", guest.Id).Return(guest, true)
+	theCheckoutRepositoryMock.On("Search", mock.AnythingOfType("persistence.CheckoutCriteria")).Return([]models.Checkout{basket})
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), mock.AnythingOfType("string")).Return(true)
+	theCheckoutRepositoryMock.On("DeleteIfStatus", guest, mock.AnythingOfType("string")).Return(true)
+	attachCheckout := AttachCheckoutToCustomer{&theCheckoutRepositoryMock, customerRepositoryMockWith(models.Customer{Id: "a-customer", Owner: "jwt:a-shopper"})}
+
+	merged, err := attachCheckout.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.AttachCustomer{CustomerId: "a-customer"}, guest.Id)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "a-basket", merged.Id)
+	assert.EqualValues(t, []string{"PEN", "PEN", "MUG"}, merged.Products)
+	theCheckoutRepositoryMock.AssertCalled(t, "PersistIfStatus", merged, mock.Anything)
+	theCheckoutRepositoryMock.AssertCalled(t, "DeleteIfStatus", guest, mock.Anything)
+}
+
+func TestReturnQuantityLimitExceededErrorWhenMergingExceedsTheLimit(t *testing.T) {
+	guest := models.Checkout{Id: "a-guest-checkout", Products: []string{"PEN"}, Status: models.CheckoutStatusOpen}
+	basket := models.Checkout{Id: "a-basket", Products: appendProductUnits(nil, commands.Line{ProductCode: "PEN", Quantity: models.MaxProductQuantity}), Status: models.CheckoutStatusOpen, CustomerId: "a-customer"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", guest.Id).Return(guest, true)
+	theCheckoutRepositoryMock.On("Search", mock.AnythingOfType("persistence.CheckoutCriteria")).Return([]models.Checkout{basket})
+	attachCheckout := AttachCheckoutToCustomer{&theCheckoutRepositoryMock, customerRepositoryMockWith(models.Customer{Id: "a-customer"})}
+
+	_, err := attachCheckout.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.AttachCustomer{CustomerId: "a-customer"}, guest.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrQuantityLimitExceeded))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "PersistIfStatus", mock.Anything, mock.Anything)
+	theCheckoutRepositoryMock.AssertNotCalled(t, "DeleteIfStatus", mock.Anything, mock.Anything)
+}
+
+func TestReturnCheckoutAttachedErrorWhenCheckoutIsAttachedToAnotherCustomer(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", CustomerId: "another-customer"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	attachCheckout := AttachCheckoutToCustomer{&theCheckoutRepositoryMock, customerRepositoryMockWith(models.Customer{Id: "a-customer"})}
+
+	_, err := attachCheckout.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.AttachCustomer{CustomerId: "a-customer"}, checkout.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrCheckoutAttached))
+}
+
+func TestReturnForbiddenErrorWhenAttachingToCustomerOfAnotherOwner(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", Owner: "jwt:a-shopper"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	attachCheckout := AttachCheckoutToCustomer{&theCheckoutRepositoryMock, customerRepositoryMockWith(models.Customer{Id: "a-customer", Owner: "jwt:another-shopper"})}
+
+	_, err := attachCheckout.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.AttachCustomer{CustomerId: "a-customer"}, checkout.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrForbidden))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "PersistIfStatus", mock.Anything, mock.Anything)
+}
+
+func TestReturnCustomerNotFoundErrorWhenAttachingToMissingCustomer(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("SearchById", "a-customer").Return(models.Customer{}, false)
+	attachCheckout := AttachCheckoutToCustomer{&theCheckoutRepositoryMock, &theCustomerRepositoryMock}
+
+	_, err := attachCheckout.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.AttachCustomer{CustomerId: "a-customer"}, checkout.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrCustomerNotFound))
+}
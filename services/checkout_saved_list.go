@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/tracing"
+)
+
+// CheckoutSavedList turns a saved list into a new checkout, created by the
+// CreateCheckout service so the checkout is limited as any other one.
+type CheckoutSavedList struct {
+	SavedListRepository persistence.SavedListRepository
+	ProductRepository   persistence.ProductRepository
+	CreateCheckout      CreateCheckout
+}
+
+func NewCheckoutSavedList(savedListRepository persistence.SavedListRepository, productRepository persistence.ProductRepository, createCheckout CreateCheckout) CheckoutSavedList {
+	return CheckoutSavedList{savedListRepository, productRepository, createCheckout}
+}
+
+// Do creates a checkout with the products of the saved list that are still
+// sold, returning the codes of the products left out. The saved list is
+// kept, so it can be checked out again.
+func (service *CheckoutSavedList) Do(ctx context.Context, savedListId string) (models.Checkout, []string, error) {
+	ctx, span := tracing.Start(ctx, "services.CheckoutSavedList")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.CheckoutSavedList); err != nil {
+		return models.Checkout{}, nil, err
+	}
+
+	savedList, existSavedList := service.SavedListRepository.SearchById(ctx, savedListId)
+	if !existSavedList {
+		return models.Checkout{}, nil, errors.NewSavedListNotFoundError(savedListId)
+	}
+	if err := checkSavedListOwner(ctx, savedList); err != nil {
+		return models.Checkout{}, nil, err
+	}
+
+	createCommand := commands.CreateCheckout{Lines: []commands.Line{}}
+	unavailableProducts := []string{}
+	for _, line := range linesOf(savedList.Products) {
+		if _, existProduct := service.ProductRepository.SearchById(ctx, line.ProductCode); !existProduct {
+			unavailableProducts = append(unavailableProducts, line.ProductCode)
+			continue
+		}
+		createCommand.Lines = append(createCommand.Lines, line)
+	}
+
+	checkout, err := service.CreateCheckout.Do(ctx, createCommand)
+	if err != nil {
+		return models.Checkout{}, nil, err
+	}
+
+	return checkout, unavailableProducts, nil
+}
+
+// linesOf groups the units of the products into lines, in the order the
+// products were first added.
+func linesOf(products []string) []commands.Line {
+	var lines []commands.Line
+	positions := map[string]int{}
+	for _, productCode := range products {
+		if position, seen := positions[productCode]; seen {
+			lines[position].Quantity++
+			continue
+		}
+		positions[productCode] = len(lines)
+		lines = append(lines, commands.Line{ProductCode: productCode, Quantity: 1})
+	}
+	return lines
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"lana/flagship-store/auth"
+	"lana/flagship-store/models"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCheckoutSavedListReportingTheProductsNoLongerSold(t *testing.T) {
+	savedList := models.SavedList{Id: "a-list", Products: []string{"PEN", "RETIRED", "PEN", "MUG"}, Owner: "jwt:a-shopper"}
+	theSavedListRepositoryMock := mocks.SavedListRepositoryMock{}
+	theSavedListRepositoryMock.On("SearchById", savedList.Id).Return(savedList, true)
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "PEN").Return(models.Product{}, true)
+	theProductRepositoryMock.On("SearchById", "MUG").Return(models.Product{}, true)
+	theProductRepositoryMock.On("SearchById", "RETIRED").Return(models.Product{}, false)
+	createCheckout := CreateCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock, 0}
+	checkoutSavedList := CheckoutSavedList{&theSavedListRepositoryMock, &theProductRepositoryMock, createCheckout}
+
+	checkout, unavailableProducts, err := checkoutSavedList.Do(principalContext(auth.RoleShopper, "a-shopper"), savedList.Id)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"PEN", "PEN", "MUG"}, checkout.Products)
+	assert.EqualValues(t, "jwt:a-shopper", checkout.Owner)
+	assert.EqualValues(t, []string{"RETIRED"}, unavailableProducts)
+	theCheckoutRepositoryMock.AssertCalled(t, "Persist", checkout)
+}
+
+func TestReturnOpenCheckoutsLimitExceededErrorWhenCheckingOutSavedListOverTheLimit(t *testing.T) {
+	savedList := models.SavedList{Id: "a-list", Products: []string{"PEN"}, Owner: "jwt:a-shopper"}
+	theSavedListRepositoryMock := mocks.SavedListRepositoryMock{}
+	theSavedListRepositoryMock.On("SearchById", savedList.Id).Return(savedList, true)
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Search", mock.AnythingOfType("persistence.CheckoutCriteria")).Return([]models.Checkout{{Id: "an-open-checkout"}})
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "PEN").Return(models.Product{}, true)
+	createCheckout := CreateCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock, 1}
+	checkoutSavedList := CheckoutSavedList{&theSavedListRepositoryMock, &theProductRepositoryMock, createCheckout}
+
+	_, _, err := checkoutSavedList.Do(principalContext(auth.RoleShopper, "a-shopper"), savedList.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrOpenCheckoutsLimitExceeded))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "Persist", mock.Anything)
+}
+
+func TestReturnSavedListNotFoundErrorWhenSavedListDoesNotExist(t *testing.T) {
+	theSavedListRepositoryMock := mocks.SavedListRepositoryMock{}
+	theSavedListRepositoryMock.On("SearchById", "a-list").Return(models.SavedList{}, false)
+	checkoutSavedList := CheckoutSavedList{&theSavedListRepositoryMock, &mocks.ProductRepositoryMock{}, CreateCheckout{}}
+
+	_, _, err := checkoutSavedList.Do(principalContext(auth.RoleShopper, "a-shopper"), "a-list")
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrSavedListNotFound))
+}
+
+func TestReturnForbiddenErrorWhenCheckingOutSavedListOfAnotherOwner(t *testing.T) {
+	savedList := models.SavedList{Id: "a-list", Products: []string{"PEN"}, Owner: "jwt:another-shopper"}
+	theSavedListRepositoryMock := mocks.SavedListRepositoryMock{}
+	theSavedListRepositoryMock.On("SearchById", savedList.Id).Return(savedList, true)
+	checkoutSavedList := CheckoutSavedList{&theSavedListRepositoryMock, &mocks.ProductRepositoryMock{}, CreateCheckout{}}
+
+	_, _, err := checkoutSavedList.Do(principalContext(auth.RoleShopper, "a-shopper"), savedList.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrForbidden))
+}
@@ -0,0 +1,15 @@
+package commands
+
+type Line struct {
+	ProductCode string `json:"product-code"`
+	Quantity    int    `json:"quantity"`
+}
+
+type CreateCheckout struct {
+	Lines []Line `json:"lines" openapi:"optional"`
+}
+
+// MergeCheckouts is the command to merge a source checkout into another.
+type MergeCheckouts struct {
+	SourceId string `json:"source-id"`
+}
@@ -0,0 +1,5 @@
+package commands
+
+type Checkouts struct {
+	Ids []string `json:"ids"`
+}
@@ -0,0 +1,12 @@
+package commands
+
+type RegisterCustomer struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+	Group string `json:"group,omitempty"`
+}
+
+// AttachCustomer is the command to attach a checkout to a customer.
+type AttachCustomer struct {
+	CustomerId string `json:"customer-id"`
+}
@@ -0,0 +1,13 @@
+package commands
+
+import "time"
+
+type ListCheckouts struct {
+	ProductCode string
+	Status      string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	Sort        string
+	Cursor      string
+	Limit       int
+}
@@ -0,0 +1,7 @@
+package commands
+
+// RedeemPoints is the command to redeem loyalty points on a checkout,
+// replacing the points redeemed before. Zero points cancel the redemption.
+type RedeemPoints struct {
+	Points int `json:"points"`
+}
@@ -0,0 +1,10 @@
+package commands
+
+// CreatePriceList is the command to create the prices, in cents, of a
+// customer or of a customer group.
+type CreatePriceList struct {
+	Name       string         `json:"name"`
+	CustomerId string         `json:"customer-id,omitempty"`
+	Group      string         `json:"group,omitempty"`
+	Prices     map[string]int `json:"prices"`
+}
@@ -1,9 +1,19 @@
 package commands
 
+// Product is the v1 command to create a checkout with a single product.
 type Product struct {
 	Code string `json:"product-code"`
 }
 
+func (command Product) CreateCheckout() CreateCheckout {
+	return CreateCheckout{Lines: []Line{{ProductCode: command.Code, Quantity: 1}}}
+}
+
+// AddProduct is the v1 command to add a single product to a checkout.
 type AddProduct struct {
 	Code string `json:"product"`
 }
+
+func (command AddProduct) Line() Line {
+	return Line{ProductCode: command.Code, Quantity: 1}
+}
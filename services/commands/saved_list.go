@@ -0,0 +1,10 @@
+package commands
+
+// CreateSavedList is the command to save a list of products, given as lines
+// or copied from the products of a checkout.
+type CreateSavedList struct {
+	Name       string `json:"name"`
+	Kind       string `json:"kind"`
+	CheckoutId string `json:"checkout-id,omitempty"`
+	Lines      []Line `json:"lines,omitempty"`
+}
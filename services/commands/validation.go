@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"lana/flagship-store/models"
+	"lana/flagship-store/services/errors"
+	"net/mail"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxProductCodeLength  = 64
+	maxCheckoutIds        = 100
+	maxCheckoutLines      = 50
+	maxEmailLength        = 254
+	maxCustomerNameLength = 100
+	maxListNameLength     = 100
+	maxGroupLength        = 64
+	maxPrices             = 100
+)
+
+type fieldErrors map[string]string
+
+func (fields fieldErrors) check(valid bool, field string, message string) {
+	if _, alreadyInvalid := fields[field]; !valid && !alreadyInvalid {
+		fields[field] = message
+	}
+}
+
+func (fields fieldErrors) checkProductCode(code string, field string) {
+	fields.check(code != "", field, "is required")
+	fields.check(len(code) <= maxProductCodeLength, field, "must not be longer than 64 characters")
+}
+
+func (fields fieldErrors) checkLine(line Line, fieldPrefix string) {
+	fields.checkProductCode(line.ProductCode, fieldPrefix+"product-code")
+	fields.check(line.Quantity >= 1 && line.Quantity <= models.MaxProductQuantity, fieldPrefix+"quantity", "must be between 1 and 99")
+}
+
+func (fields fieldErrors) err() error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return errors.NewValidationError(fields)
+}
+
+func (command Product) Validate() error {
+	fields := fieldErrors{}
+	fields.checkProductCode(command.Code, "product-code")
+	return fields.err()
+}
+
+func (command AddProduct) Validate() error {
+	fields := fieldErrors{}
+	fields.checkProductCode(command.Code, "product")
+	return fields.err()
+}
+
+func (command Line) Validate() error {
+	fields := fieldErrors{}
+	fields.checkLine(command, "")
+	return fields.err()
+}
+
+func (command CreateCheckout) Validate() error {
+	fields := fieldErrors{}
+	fields.check(len(command.Lines) <= maxCheckoutLines, "lines", "must not contain more than 50 lines")
+	for position, line := range command.Lines {
+		fields.checkLine(line, "lines["+strconv.Itoa(position)+"].")
+	}
+	return fields.err()
+}
+
+func (command Checkouts) Validate() error {
+	fields := fieldErrors{}
+	fields.check(len(command.Ids) > 0, "ids", "is required")
+	fields.check(len(command.Ids) <= maxCheckoutIds, "ids", "must not contain more than 100 ids")
+	for _, id := range command.Ids {
+		fields.check(id != "", "ids", "must not contain empty ids")
+	}
+	return fields.err()
+}
+
+func (command RegisterCustomer) Validate() error {
+	fields := fieldErrors{}
+	fields.check(command.Email != "", "email", "is required")
+	fields.check(len(command.Email) <= maxEmailLength, "email", "must not be longer than 254 characters")
+	address, err := mail.ParseAddress(command.Email)
+	fields.check(err == nil && address.Address == strings.TrimSpace(command.Email), "email", "must be an email address")
+	fields.check(strings.TrimSpace(command.Name) != "", "name", "is required")
+	fields.check(len(command.Name) <= maxCustomerNameLength, "name", "must not be longer than 100 characters")
+	fields.check(len(command.Group) <= maxGroupLength, "group", "must not be longer than 64 characters")
+	return fields.err()
+}
+
+func (command AttachCustomer) Validate() error {
+	fields := fieldErrors{}
+	fields.check(command.CustomerId != "", "customer-id", "is required")
+	return fields.err()
+}
+
+func (command MergeCheckouts) Validate() error {
+	fields := fieldErrors{}
+	fields.check(command.SourceId != "", "source-id", "is required")
+	return fields.err()
+}
+
+func (command CreateSavedList) Validate() error {
+	fields := fieldErrors{}
+	fields.check(strings.TrimSpace(command.Name) != "", "name", "is required")
+	fields.check(len(command.Name) <= maxListNameLength, "name", "must not be longer than 100 characters")
+	fields.check(command.Kind == models.SavedListKindCart || command.Kind == models.SavedListKindWishlist, "kind", "must be cart or wishlist")
+	fields.check(command.CheckoutId == "" || len(command.Lines) == 0, "lines", "are not allowed with checkout-id")
+	fields.check(len(command.Lines) <= maxCheckoutLines, "lines", "must not contain more than 50 lines")
+	for position, line := range command.Lines {
+		fields.checkLine(line, "lines["+strconv.Itoa(position)+"].")
+	}
+	return fields.err()
+}
+
+func (command CreatePriceList) Validate() error {
+	fields := fieldErrors{}
+	fields.check(strings.TrimSpace(command.Name) != "", "name", "is required")
+	fields.check(len(command.Name) <= maxListNameLength, "name", "must not be longer than 100 characters")
+	fields.check(command.CustomerId != "" || command.Group != "", "customer-id", "or group is required")
+	fields.check(command.CustomerId == "" || command.Group == "", "group", "is not allowed with customer-id")
+	fields.check(len(command.Group) <= maxGroupLength, "group", "must not be longer than 64 characters")
+	fields.check(len(command.Prices) > 0, "prices", "is required")
+	fields.check(len(command.Prices) <= maxPrices, "prices", "must not contain more than 100 prices")
+	for productCode, price := range command.Prices {
+		fields.checkProductCode(productCode, "prices")
+		fields.check(price >= 0, "prices", "must not be negative")
+	}
+	return fields.err()
+}
+
+func (command RedeemPoints) Validate() error {
+	fields := fieldErrors{}
+	fields.check(command.Points >= 0, "points", "must not be negative")
+	return fields.err()
+}
@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"lana/flagship-store/services/errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func invalidFields(err error) map[string]string {
+	var validationError *errors.Error
+	if !errors.As(err, &validationError) {
+		return nil
+	}
+	return validationError.Details["fields"].(map[string]string)
+}
+
+func TestProductIsValidWithCode(t *testing.T) {
+	assert.Nil(t, Product{Code: "PEN"}.Validate())
+}
+
+func TestProductIsNotValidWithoutCode(t *testing.T) {
+	err := Product{}.Validate()
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrValidation))
+	assert.EqualValues(t, map[string]string{"product-code": "is required"}, invalidFields(err))
+}
+
+func TestAddProductIsNotValidWithTooLongCode(t *testing.T) {
+	err := AddProduct{Code: strings.Repeat("A", 65)}.Validate()
+
+	assert.EqualValues(t, map[string]string{"product": "must not be longer than 64 characters"}, invalidFields(err))
+}
+
+func TestLineIsNotValidWithoutQuantity(t *testing.T) {
+	err := Line{ProductCode: "PEN"}.Validate()
+
+	assert.EqualValues(t, map[string]string{"quantity": "must be between 1 and 99"}, invalidFields(err))
+}
+
+func TestCreateCheckoutIsValidWithoutLines(t *testing.T) {
+	assert.Nil(t, CreateCheckout{}.Validate())
+}
+
+func TestCreateCheckoutIsNotValidWithInvalidLines(t *testing.T) {
+	err := CreateCheckout{Lines: []Line{{ProductCode: "PEN", Quantity: 1}, {Quantity: 100}}}.Validate()
+
+	assert.EqualValues(t, map[string]string{
+		"lines[1].product-code": "is required",
+		"lines[1].quantity":     "must be between 1 and 99",
+	}, invalidFields(err))
+}
+
+func TestCheckoutsIsNotValidWithoutIds(t *testing.T) {
+	err := Checkouts{}.Validate()
+
+	assert.EqualValues(t, map[string]string{"ids": "is required"}, invalidFields(err))
+}
+
+func TestCheckoutsIsNotValidWithEmptyIds(t *testing.T) {
+	err := Checkouts{Ids: []string{"an_id", ""}}.Validate()
+
+	assert.EqualValues(t, map[string]string{"ids": "must not contain empty ids"}, invalidFields(err))
+}
+
+func TestCheckoutsIsNotValidWithTooManyIds(t *testing.T) {
+	err := Checkouts{Ids: make([]string, 101)}.Validate()
+
+	assert.EqualValues(t, map[string]string{"ids": "must not contain more than 100 ids"}, invalidFields(err))
+}
+
+func TestRegisterCustomerIsValidWithEmailAndName(t *testing.T) {
+	assert.Nil(t, RegisterCustomer{Email: "ada@example.com", Name: "Ada"}.Validate())
+}
+
+func TestRegisterCustomerIsNotValidWithoutEmailNorName(t *testing.T) {
+	err := RegisterCustomer{}.Validate()
+
+	assert.EqualValues(t, map[string]string{"email": "is required", "name": "is required"}, invalidFields(err))
+}
+
+func TestRegisterCustomerIsNotValidWithNotAnEmailAddress(t *testing.T) {
+	for _, email := range []string{"ada", "Ada <ada@example.com>", "ada@"} {
+		err := RegisterCustomer{Email: email, Name: "Ada"}.Validate()
+
+		assert.EqualValues(t, map[string]string{"email": "must be an email address"}, invalidFields(err), email)
+	}
+}
+
+func TestAttachCustomerIsNotValidWithoutCustomerId(t *testing.T) {
+	err := AttachCustomer{}.Validate()
+
+	assert.EqualValues(t, map[string]string{"customer-id": "is required"}, invalidFields(err))
+}
+
+func TestCreateSavedListIsValidWithNameKindAndLines(t *testing.T) {
+	assert.Nil(t, CreateSavedList{Name: "Birthday", Kind: "wishlist", Lines: []Line{{ProductCode: "PEN", Quantity: 2}}}.Validate())
+	assert.Nil(t, CreateSavedList{Name: "For later", Kind: "cart", CheckoutId: "a-checkout"}.Validate())
+}
+
+func TestCreateSavedListIsNotValidWithoutNameNorKnownKind(t *testing.T) {
+	err := CreateSavedList{Kind: "favourites"}.Validate()
+
+	assert.EqualValues(t, map[string]string{"name": "is required", "kind": "must be cart or wishlist"}, invalidFields(err))
+}
+
+func TestCreateSavedListIsNotValidWithLinesAndCheckoutId(t *testing.T) {
+	err := CreateSavedList{Name: "For later", Kind: "cart", CheckoutId: "a-checkout", Lines: []Line{{ProductCode: "PEN", Quantity: 1}}}.Validate()
+
+	assert.EqualValues(t, map[string]string{"lines": "are not allowed with checkout-id"}, invalidFields(err))
+}
+
+func TestCreatePriceListIsNotValidWithoutCustomerNorGroup(t *testing.T) {
+	err := CreatePriceList{Name: "Acme", Prices: map[string]int{"MUG": 600}}.Validate()
+
+	assert.EqualValues(t, map[string]string{"customer-id": "or group is required"}, invalidFields(err))
+}
+
+func TestCreatePriceListIsNotValidWithCustomerAndGroupAndNegativePrice(t *testing.T) {
+	err := CreatePriceList{Name: "Acme", CustomerId: "a-customer", Group: "wholesale", Prices: map[string]int{"MUG": -1}}.Validate()
+
+	assert.EqualValues(t, map[string]string{"group": "is not allowed with customer-id", "prices": "must not be negative"}, invalidFields(err))
+}
+
+func TestRedeemPointsIsNotValidWithNegativePoints(t *testing.T) {
+	err := RedeemPoints{Points: -1}.Validate()
+
+	assert.EqualValues(t, map[string]string{"points": "must not be negative"}, invalidFields(err))
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/metrics"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/tracing"
+	"time"
+)
+
+type CompleteCheckout struct {
+	CheckoutRepository             persistence.CheckoutRepository
+	LoyaltyRepository              persistence.LoyaltyRepository
+	ProductRepository              persistence.ProductRepository
+	ProductWithPromotionRepository persistence.ProductRepository
+	ProductWithDiscountRepository  persistence.ProductRepository
+	Pricing                        Pricing
+}
+
+func NewCompleteCheckout(checkoutRepository persistence.CheckoutRepository, loyaltyRepository persistence.LoyaltyRepository, productRepository persistence.ProductRepository, productWithPromotionRepository persistence.ProductRepository, productWithDiscountRepository persistence.ProductRepository) CompleteCheckout {
+	return CompleteCheckout{CheckoutRepository: checkoutRepository, LoyaltyRepository: loyaltyRepository, ProductRepository: productRepository, ProductWithPromotionRepository: productWithPromotionRepository, ProductWithDiscountRepository: productWithDiscountRepository}
+}
+
+// Do completes the order of the checkout, which can not be changed anymore,
+// returning its final totals. The customer of the checkout, if any, spends
+// the points redeemed on it and earns points for the amount paid. Only one
+// of concurrent changes of a checkout wins, so the order completed is the
+// one summarized, and the checkout is open again when the balance no longer
+// covers the points redeemed.
+func (service *CompleteCheckout) Do(ctx context.Context, checkoutId string) (models.CheckoutSummary, error) {
+	ctx, span := tracing.Start(ctx, "services.CompleteCheckout")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.CompleteCheckout); err != nil {
+		return models.CheckoutSummary{}, err
+	}
+
+	checkout, existCheckout := service.CheckoutRepository.SearchById(ctx, checkoutId)
+	if !existCheckout {
+		return models.CheckoutSummary{}, errors.NewCheckoutNotFoundError(checkoutId)
+	}
+	if err := checkOwner(ctx, checkout); err != nil {
+		return models.CheckoutSummary{}, err
+	}
+	if err := checkOpen(checkout); err != nil {
+		return models.CheckoutSummary{}, err
+	}
+
+	checkoutSummary := summarizeCheckout(ctx, checkout, service.Pricing.productRepositoryOf(ctx, checkout, service.ProductRepository), service.ProductWithPromotionRepository, service.ProductWithDiscountRepository)
+	if checkout.CustomerId != "" {
+		if err := checkBalance(ctx, service.LoyaltyRepository, checkout.CustomerId, checkoutSummary.RedeemedPoints); err != nil {
+			return models.CheckoutSummary{}, err
+		}
+	}
+
+	openCheckout := checkout
+	checkout.Status = models.CheckoutStatusCompleted
+	checkout.RedeemedPoints = checkoutSummary.RedeemedPoints
+	checkout.UpdatedAt = time.Now()
+	checkoutSummary.Checkout = checkout
+	if err := checkContext(ctx); err != nil {
+		return models.CheckoutSummary{}, err
+	}
+	if !service.CheckoutRepository.PersistIfStatus(ctx, checkout, openCheckout.Status) {
+		return models.CheckoutSummary{}, refusedWriteError(ctx, service.CheckoutRepository, checkout.Id)
+	}
+
+	if checkout.CustomerId != "" {
+		if checkoutSummary.RedeemedPoints > 0 {
+			if err := redeemPoints(ctx, service.LoyaltyRepository, checkout, checkoutSummary.RedeemedPoints); err != nil {
+				// The completed checkout was stored with the next version and
+				// can not have been changed since, so it is reopened as it was.
+				reopened := openCheckout
+				reopened.Version = checkout.Version + 1
+				service.CheckoutRepository.PersistIfStatus(ctx, reopened, models.CheckoutStatusCompleted)
+				return models.CheckoutSummary{}, err
+			}
+		}
+		if points := earnedPoints(checkoutSummary.Amount); points > 0 {
+			service.LoyaltyRepository.Record(ctx, newLoyaltyTransaction(checkout, models.LoyaltyTransactionEarn, points))
+		}
+	}
+	for _, checkoutLine := range checkoutSummary.Lines {
+		if savings := checkoutLine.Subtotal - checkoutLine.Amount; savings > 0 {
+			metrics.PromotionSavings.WithLabelValues(checkoutLine.Product.Code).Add(float64(savings))
+		}
+	}
+
+	return checkoutSummary, nil
+}
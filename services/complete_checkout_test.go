@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/auth"
+	"lana/flagship-store/metrics"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/utils/mocks"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func completeCheckoutWith(checkoutRepository persistence.CheckoutRepository, loyaltyRepository persistence.LoyaltyRepository) CompleteCheckout {
+	return CompleteCheckout{
+		checkoutRepository,
+		loyaltyRepository,
+		ProductRepositoryMockWithAllProducts(),
+		ProductWithPromotionRepositoryMockWithProducts(),
+		ProductWithDiscountRepositoryMockWithProducts(),
+		Pricing{}}
+}
+
+func TestCompleteCheckoutEarnsAndRedeemsThePointsOfTheCustomer(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", Products: []string{"TSHIRT", "PEN", "TSHIRT", "PEN", "TSHIRT"}, Status: models.CheckoutStatusOpen, Owner: "jwt:a-shopper", CustomerId: "a-customer", RedeemedPoints: 1000}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), models.CheckoutStatusOpen).Return(true)
+	theLoyaltyRepositoryMock := mocks.LoyaltyRepositoryMock{}
+	theLoyaltyRepositoryMock.On("Balance", "a-customer").Return(1500)
+	theLoyaltyRepositoryMock.On("Redeem", mock.AnythingOfType("models.LoyaltyTransaction")).Return(nil)
+	theLoyaltyRepositoryMock.On("Record", mock.AnythingOfType("models.LoyaltyTransaction"))
+	completeCheckout := completeCheckoutWith(&theCheckoutRepositoryMock, &theLoyaltyRepositoryMock)
+	tshirtSavings := testutil.ToFloat64(metrics.PromotionSavings.WithLabelValues("TSHIRT"))
+
+	completed, err := completeCheckout.Do(principalContext(auth.RoleShopper, "a-shopper"), checkout.Id)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, models.CheckoutStatusCompleted, completed.Checkout.Status)
+	assert.EqualValues(t, tshirtSavings+1500, testutil.ToFloat64(metrics.PromotionSavings.WithLabelValues("TSHIRT")))
+	assert.EqualValues(t, 1000, completed.PointsDiscount)
+	assert.EqualValues(t, 4000, completed.Amount)
+	theCheckoutRepositoryMock.AssertCalled(t, "PersistIfStatus", completed.Checkout, models.CheckoutStatusOpen)
+	redeem := theLoyaltyRepositoryMock.Calls[1].Arguments.Get(0).(models.LoyaltyTransaction)
+	assert.EqualValues(t, models.LoyaltyTransactionRedeem, redeem.Kind)
+	assert.EqualValues(t, -1000, redeem.Points)
+	earn := theLoyaltyRepositoryMock.Calls[2].Arguments.Get(0).(models.LoyaltyTransaction)
+	assert.EqualValues(t, models.LoyaltyTransactionEarn, earn.Kind)
+	assert.EqualValues(t, 40, earn.Points)
+	assert.EqualValues(t, "a-checkout", earn.CheckoutId)
+}
+
+func TestCompleteCheckoutWithoutCustomerEarnsNoPoints(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", Products: []string{"MUG"}, Status: models.CheckoutStatusOpen, Owner: "jwt:a-shopper"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), models.CheckoutStatusOpen).Return(true)
+	theLoyaltyRepositoryMock := mocks.LoyaltyRepositoryMock{}
+	completeCheckout := completeCheckoutWith(&theCheckoutRepositoryMock, &theLoyaltyRepositoryMock)
+
+	completed, err := completeCheckout.Do(principalContext(auth.RoleShopper, "a-shopper"), checkout.Id)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, models.CheckoutStatusCompleted, completed.Checkout.Status)
+	theLoyaltyRepositoryMock.AssertNotCalled(t, "Record", mock.Anything)
+}
+
+func TestReturnInsufficientPointsErrorWhenTheBalanceWasSpentBeforeCompleting(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", Products: []string{"MUG"}, Status: models.CheckoutStatusOpen, Owner: "jwt:a-shopper", CustomerId: "a-customer", RedeemedPoints: 500}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theLoyaltyRepositoryMock := mocks.LoyaltyRepositoryMock{}
+	theLoyaltyRepositoryMock.On("Balance", "a-customer").Return(100)
+	completeCheckout := completeCheckoutWith(&theCheckoutRepositoryMock, &theLoyaltyRepositoryMock)
+
+	_, err := completeCheckout.Do(principalContext(auth.RoleShopper, "a-shopper"), checkout.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrInsufficientPoints))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "PersistIfStatus", mock.Anything, mock.Anything)
+	theLoyaltyRepositoryMock.AssertNotCalled(t, "Record", mock.Anything)
+}
+
+func TestReopenCheckoutWhenTheBalanceIsSpentWhileCompleting(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", Products: []string{"MUG"}, Status: models.CheckoutStatusOpen, Owner: "jwt:a-shopper", CustomerId: "a-customer", RedeemedPoints: 500}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), mock.AnythingOfType("string")).Return(true)
+	theLoyaltyRepositoryMock := mocks.LoyaltyRepositoryMock{}
+	theLoyaltyRepositoryMock.On("Balance", "a-customer").Return(500)
+	theLoyaltyRepositoryMock.On("Redeem", mock.AnythingOfType("models.LoyaltyTransaction")).Return(persistence.ErrInsufficientBalance)
+	completeCheckout := completeCheckoutWith(&theCheckoutRepositoryMock, &theLoyaltyRepositoryMock)
+
+	_, err := completeCheckout.Do(principalContext(auth.RoleShopper, "a-shopper"), checkout.Id)
+
+	reopened := checkout
+	reopened.Version++
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrInsufficientPoints))
+	theCheckoutRepositoryMock.AssertCalled(t, "PersistIfStatus", reopened, models.CheckoutStatusCompleted)
+	theLoyaltyRepositoryMock.AssertNotCalled(t, "Record", mock.Anything)
+}
+
+func TestReturnCheckoutNotOpenErrorWhenCompletingACompletedCheckout(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", Status: models.CheckoutStatusCompleted, Owner: "jwt:a-shopper"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	completeCheckout := CompleteCheckout{CheckoutRepository: &theCheckoutRepositoryMock}
+
+	_, err := completeCheckout.Do(principalContext(auth.RoleShopper, "a-shopper"), checkout.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrCheckoutNotOpen))
+}
+
+func TestCompleteCheckoutConcurrentlyEarnsAndRedeemsOnce(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", Products: []string{"MUG", "MUG"}, Status: models.CheckoutStatusOpen, Owner: "jwt:a-shopper", CustomerId: "a-customer", RedeemedPoints: 300}
+	checkoutRepository := persistence.NewCheckoutRepository(map[string]models.Checkout{checkout.Id: checkout})
+	loyaltyRepository := persistence.NewLoyaltyRepository(map[string][]models.LoyaltyTransaction{
+		"a-customer": {{Id: "an-earn", CustomerId: "a-customer", Kind: models.LoyaltyTransactionEarn, Points: 300}},
+	})
+	completeCheckout := completeCheckoutWith(checkoutRepository, loyaltyRepository)
+
+	completions := completeConcurrently(completeCheckout, checkout.Id, checkout.Id, checkout.Id, checkout.Id, checkout.Id)
+
+	assert.EqualValues(t, 1, completions)
+	assert.EqualValues(t, 12, loyaltyRepository.Balance(context.Background(), "a-customer"))
+	assert.Len(t, loyaltyRepository.SearchByCustomer(context.Background(), "a-customer"), 3)
+}
+
+func TestCompleteCheckoutsConcurrentlyRedeemsTheBalanceOnce(t *testing.T) {
+	checkouts := make(map[string]models.Checkout)
+	var checkoutIds []string
+	for position := 0; position < 5; position++ {
+		checkout := models.Checkout{Id: "a-checkout-" + strconv.Itoa(position), Products: []string{"PEN"}, Status: models.CheckoutStatusOpen, Owner: "jwt:a-shopper", CustomerId: "a-customer", RedeemedPoints: 500}
+		checkouts[checkout.Id] = checkout
+		checkoutIds = append(checkoutIds, checkout.Id)
+	}
+	checkoutRepository := persistence.NewCheckoutRepository(checkouts)
+	loyaltyRepository := persistence.NewLoyaltyRepository(map[string][]models.LoyaltyTransaction{
+		"a-customer": {{Id: "an-earn", CustomerId: "a-customer", Kind: models.LoyaltyTransactionEarn, Points: 500}},
+	})
+	completeCheckout := completeCheckoutWith(checkoutRepository, loyaltyRepository)
+
+	completions := completeConcurrently(completeCheckout, checkoutIds...)
+
+	assert.EqualValues(t, 1, completions)
+	assert.EqualValues(t, 0, loyaltyRepository.Balance(context.Background(), "a-customer"))
+	open := checkoutRepository.Search(context.Background(), persistence.CheckoutCriteria{Status: models.CheckoutStatusOpen})
+	assert.Len(t, open, 4)
+}
+
+func TestCompleteCheckoutWhileAddingAProductCompletesTheOrderSummarized(t *testing.T) {
+	for attempt := 0; attempt < 50; attempt++ {
+		checkout := models.Checkout{Id: "a-checkout", Products: []string{"PEN"}, Status: models.CheckoutStatusOpen, Owner: "jwt:a-shopper"}
+		checkoutRepository := persistence.NewCheckoutRepository(map[string]models.Checkout{checkout.Id: checkout})
+		completeCheckout := completeCheckoutWith(checkoutRepository, &mocks.LoyaltyRepositoryMock{})
+		addProduct := NewAddProductToCheckout(checkoutRepository, ProductRepositoryMockWithAllProducts())
+		var group sync.WaitGroup
+		var completed models.CheckoutSummary
+		var completeErr, addErr error
+
+		group.Add(2)
+		go func() {
+			defer group.Done()
+			completed, completeErr = completeCheckout.Do(principalContext(auth.RoleShopper, "a-shopper"), checkout.Id)
+		}()
+		go func() {
+			defer group.Done()
+			_, addErr = addProduct.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.Line{ProductCode: "MUG", Quantity: 1}, checkout.Id)
+		}()
+		group.Wait()
+
+		stored, _ := checkoutRepository.SearchById(context.Background(), checkout.Id)
+		if completeErr == nil {
+			assert.EqualValues(t, models.CheckoutStatusCompleted, stored.Status)
+			assert.EqualValues(t, stored.Products, completed.Checkout.Products)
+		} else {
+			assert.EqualValues(t, true, errors.Is(completeErr, errors.ErrCheckoutModified))
+			assert.EqualValues(t, []string{"PEN", "MUG"}, stored.Products)
+		}
+		if addErr == nil {
+			assert.EqualValues(t, []string{"PEN", "MUG"}, stored.Products)
+		} else {
+			assert.EqualValues(t, true, errors.Is(addErr, errors.ErrCheckoutNotOpen) || errors.Is(addErr, errors.ErrCheckoutModified))
+			assert.EqualValues(t, []string{"PEN"}, stored.Products)
+		}
+	}
+}
+
+// completeConcurrently completes every checkout at once, returning how many
+// completions succeeded.
+func completeConcurrently(completeCheckout CompleteCheckout, checkoutIds ...string) int {
+	var group sync.WaitGroup
+	var mutex sync.Mutex
+	completions := 0
+	for _, checkoutId := range checkoutIds {
+		group.Add(1)
+		go func(checkoutId string) {
+			defer group.Done()
+			if _, err := completeCheckout.Do(principalContext(auth.RoleShopper, "a-shopper"), checkoutId); err == nil {
+				mutex.Lock()
+				completions++
+				mutex.Unlock()
+			}
+		}(checkoutId)
+	}
+	group.Wait()
+	return completions
+}
@@ -0,0 +1,16 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/services/errors"
+)
+
+// checkContext returns an error once the context of the request is done, so
+// the services stop serving requests nobody waits for and do not persist
+// their changes.
+func checkContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return errors.NewContextError(err)
+	}
+	return nil
+}
@@ -1,34 +1,108 @@
 package services
 
 import (
+	"context"
+	"lana/flagship-store/metrics"
 	"lana/flagship-store/models"
 	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
 	"lana/flagship-store/services/commands"
 	"lana/flagship-store/services/errors"
+	"lana/flagship-store/tracing"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// CreateCheckout creates checkouts of the caller. When MaxOpenCheckouts is
+// set, an owner can not have more open checkouts than it.
 type CreateCheckout struct {
 	CheckoutRepository persistence.CheckoutRepository
 	ProductRepository  persistence.ProductRepository
+	MaxOpenCheckouts   int
 }
 
 func NewCreateCheckout(checkoutRepository persistence.CheckoutRepository, productRepository persistence.ProductRepository) CreateCheckout {
-	return CreateCheckout{checkoutRepository, productRepository}
+	return CreateCheckout{CheckoutRepository: checkoutRepository, ProductRepository: productRepository}
 }
 
-func (service *CreateCheckout) Do(productCommand commands.Product) (models.Checkout, error) {
-	if _, existProduct := service.ProductRepository.SearchById(productCommand.Code); !existProduct {
-		emptyCheckout := models.Checkout{}
-		return emptyCheckout, errors.NewProductNotFoundError()
+func (service *CreateCheckout) Do(ctx context.Context, createCommand commands.CreateCheckout) (models.Checkout, error) {
+	ctx, span := tracing.Start(ctx, "services.CreateCheckout")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.CreateCheckout); err != nil {
+		return models.Checkout{}, err
+	}
+
+	if err := createCommand.Validate(); err != nil {
+		return models.Checkout{}, err
+	}
+
+	owner := ownerOf(ctx)
+	if service.exceedsOpenCheckouts(ctx, owner) {
+		return models.Checkout{}, errors.NewOpenCheckoutsLimitExceededError(service.MaxOpenCheckouts)
 	}
 
+	products := []string{}
+	for _, line := range createCommand.Lines {
+		if _, existProduct := service.ProductRepository.SearchById(ctx, line.ProductCode); !existProduct {
+			return models.Checkout{}, errors.NewProductNotFoundError(line.ProductCode)
+		}
+		if countProductUnits(products, line.ProductCode)+line.Quantity > models.MaxProductQuantity {
+			return models.Checkout{}, errors.NewQuantityLimitExceededError(line.ProductCode, models.MaxProductQuantity)
+		}
+		products = appendProductUnits(products, line)
+	}
+
+	now := time.Now()
 	checkout := models.Checkout{
-		Id:       uuid.NewString(),
-		Products: []string{productCommand.Code},
+		Id:        uuid.NewString(),
+		Products:  products,
+		Status:    models.CheckoutStatusOpen,
+		Owner:     owner,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := checkContext(ctx); err != nil {
+		return models.Checkout{}, err
+	}
+	service.CheckoutRepository.Persist(ctx, checkout)
+
+	metrics.CheckoutsCreated.Inc()
+	for _, line := range createCommand.Lines {
+		metrics.ProductsAdded.WithLabelValues(line.ProductCode).Add(float64(line.Quantity))
 	}
-	service.CheckoutRepository.Persist(checkout)
 
 	return checkout, nil
 }
+
+// exceedsOpenCheckouts tells whether the owner already has the maximum open
+// checkouts. Checkouts without owner are not limited.
+func (service *CreateCheckout) exceedsOpenCheckouts(ctx context.Context, owner string) bool {
+	if service.MaxOpenCheckouts <= 0 || owner == "" {
+		return false
+	}
+	openCheckouts := service.CheckoutRepository.Search(ctx, persistence.CheckoutCriteria{
+		Owner:  owner,
+		Status: models.CheckoutStatusOpen,
+		Limit:  service.MaxOpenCheckouts,
+	})
+	return len(openCheckouts) >= service.MaxOpenCheckouts
+}
+
+func countProductUnits(products []string, productCode string) int {
+	var units int
+	for _, product := range products {
+		if product == productCode {
+			units++
+		}
+	}
+	return units
+}
+
+func appendProductUnits(products []string, line commands.Line) []string {
+	for unit := 0; unit < line.Quantity; unit++ {
+		products = append(products, line.ProductCode)
+	}
+	return products
+}
@@ -1,12 +1,17 @@
 package services
 
 import (
+	"context"
+	"lana/flagship-store/auth"
+	"lana/flagship-store/metrics"
 	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
 	"lana/flagship-store/services/commands"
 	"lana/flagship-store/services/errors"
 	"lana/flagship-store/utils/mocks"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -16,10 +21,10 @@ func TestCreateCheckout(t *testing.T) {
 	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
 	theProductRepositoryMock := mocks.ProductRepositoryMock{}
 	theProductRepositoryMock.On("SearchById", "PEN").Return(models.Product{}, true)
-	productCommand := commands.Product{Code: "PEN"}
-	createCheckout := CreateCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock}
+	createCommand := commands.CreateCheckout{Lines: []commands.Line{{ProductCode: "PEN", Quantity: 1}}}
+	createCheckout := CreateCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock, 0}
 
-	createdCheckout, _ := createCheckout.Do(productCommand)
+	createdCheckout, _ := createCheckout.Do(trustedContext(), createCommand)
 
 	assert.NotNil(t, createdCheckout.Id)
 	assert.EqualValues(t, "PEN", createdCheckout.Products[0])
@@ -32,11 +37,131 @@ func TestReturnProductNotFoundErrorWhenProductDoesnotExists(t *testing.T) {
 	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
 	theProductRepositoryMock := mocks.ProductRepositoryMock{}
 	theProductRepositoryMock.On("SearchById", "PEN").Return(models.Product{}, false)
-	productCommand := commands.Product{Code: "PEN"}
-	createCheckout := CreateCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock}
+	createCommand := commands.CreateCheckout{Lines: []commands.Line{{ProductCode: "PEN", Quantity: 1}}}
+	createCheckout := CreateCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock, 0}
 
-	_, err := createCheckout.Do(productCommand)
+	_, err := createCheckout.Do(trustedContext(), createCommand)
 
-	_, isProductNotFoundError := err.(*errors.ProductNotFoundError)
+	isProductNotFoundError := errors.Is(err, errors.ErrProductNotFound)
 	assert.EqualValues(t, true, isProductNotFoundError)
 }
+
+func TestReturnValidationErrorWhenProductCodeIsEmpty(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	createCheckout := CreateCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock, 0}
+
+	_, err := createCheckout.Do(trustedContext(), commands.CreateCheckout{Lines: []commands.Line{{Quantity: 1}}})
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrValidation))
+	theProductRepositoryMock.AssertNotCalled(t, "SearchById", mock.Anything)
+}
+
+func TestCreateCheckoutWithManyLines(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "PEN").Return(models.Product{}, true)
+	theProductRepositoryMock.On("SearchById", "MUG").Return(models.Product{}, true)
+	createCommand := commands.CreateCheckout{Lines: []commands.Line{{ProductCode: "PEN", Quantity: 2}, {ProductCode: "MUG", Quantity: 1}}}
+	createCheckout := CreateCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock, 0}
+
+	createdCheckout, _ := createCheckout.Do(trustedContext(), createCommand)
+
+	assert.EqualValues(t, []string{"PEN", "PEN", "MUG"}, createdCheckout.Products)
+	assert.EqualValues(t, models.CheckoutStatusOpen, createdCheckout.Status)
+}
+
+func TestCreateEmptyCheckoutWithoutLines(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	createCheckout := CreateCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock, 0}
+
+	createdCheckout, err := createCheckout.Do(trustedContext(), commands.CreateCheckout{})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{}, createdCheckout.Products)
+	theCheckoutRepositoryMock.AssertNumberOfCalls(t, "Persist", 1)
+}
+
+func TestReturnQuantityLimitExceededErrorWhenLinesExceedTheLimit(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "PEN").Return(models.Product{}, true)
+	createCommand := commands.CreateCheckout{Lines: []commands.Line{{ProductCode: "PEN", Quantity: 50}, {ProductCode: "PEN", Quantity: 50}}}
+	createCheckout := CreateCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock, 0}
+
+	_, err := createCheckout.Do(trustedContext(), createCommand)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrQuantityLimitExceeded))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "Persist", mock.Anything)
+}
+
+func TestCreateCheckoutCountsCheckoutAndProductsAdded(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "MUG").Return(models.Product{}, true)
+	createCommand := commands.CreateCheckout{Lines: []commands.Line{{ProductCode: "MUG", Quantity: 3}}}
+	createCheckout := CreateCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock, 0}
+	checkoutsCreated := testutil.ToFloat64(metrics.CheckoutsCreated)
+	mugsAdded := testutil.ToFloat64(metrics.ProductsAdded.WithLabelValues("MUG"))
+
+	createCheckout.Do(trustedContext(), createCommand)
+
+	assert.EqualValues(t, checkoutsCreated+1, testutil.ToFloat64(metrics.CheckoutsCreated))
+	assert.EqualValues(t, mugsAdded+3, testutil.ToFloat64(metrics.ProductsAdded.WithLabelValues("MUG")))
+}
+
+func TestCreateCheckoutReturnCanceledErrorAndDoesNotPersistWhenRequestIsCanceled(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "PEN").Return(models.Product{}, true)
+	createCommand := commands.CreateCheckout{Lines: []commands.Line{{ProductCode: "PEN", Quantity: 1}}}
+	createCheckout := CreateCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock, 0}
+	ctx, cancel := context.WithCancel(trustedContext())
+	cancel()
+
+	_, err := createCheckout.Do(ctx, createCommand)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrCanceled))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "Persist", mock.Anything)
+}
+
+func TestCreateCheckoutBoundToTheAuthenticatedOwner(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	createCheckout := CreateCheckout{&theCheckoutRepositoryMock, &theProductRepositoryMock, 0}
+	ctx := principalContext(auth.RoleShopper, "a-shopper")
+
+	createdCheckout, _ := createCheckout.Do(ctx, commands.CreateCheckout{})
+
+	assert.EqualValues(t, "jwt:a-shopper", createdCheckout.Owner)
+}
+
+func TestReturnOpenCheckoutsLimitExceededErrorWhenOwnerHasTheMaximumOpenCheckouts(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Search", mock.AnythingOfType("persistence.CheckoutCriteria")).Return([]models.Checkout{{Id: "a"}, {Id: "b"}})
+	createCheckout := CreateCheckout{&theCheckoutRepositoryMock, &mocks.ProductRepositoryMock{}, 2}
+
+	_, err := createCheckout.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.CreateCheckout{})
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrOpenCheckoutsLimitExceeded))
+	criteria := theCheckoutRepositoryMock.Calls[0].Arguments.Get(0).(persistence.CheckoutCriteria)
+	assert.EqualValues(t, persistence.CheckoutCriteria{Owner: "jwt:a-shopper", Status: models.CheckoutStatusOpen, Limit: 2}, criteria)
+	theCheckoutRepositoryMock.AssertNotCalled(t, "Persist", mock.Anything)
+}
+
+func TestCreateCheckoutWhenOwnerHasLessThanTheMaximumOpenCheckouts(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Search", mock.AnythingOfType("persistence.CheckoutCriteria")).Return([]models.Checkout{{Id: "a"}})
+	theCheckoutRepositoryMock.On("Persist", mock.AnythingOfType("models.Checkout"))
+	createCheckout := CreateCheckout{&theCheckoutRepositoryMock, &mocks.ProductRepositoryMock{}, 2}
+
+	_, err := createCheckout.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.CreateCheckout{})
+
+	assert.Nil(t, err)
+	theCheckoutRepositoryMock.AssertNumberOfCalls(t, "Persist", 1)
+}
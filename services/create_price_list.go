@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/tracing"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type CreatePriceList struct {
+	PriceListRepository persistence.PriceListRepository
+	CustomerRepository  persistence.CustomerRepository
+	ProductRepository   persistence.ProductRepository
+}
+
+func NewCreatePriceList(priceListRepository persistence.PriceListRepository, customerRepository persistence.CustomerRepository, productRepository persistence.ProductRepository) CreatePriceList {
+	return CreatePriceList{priceListRepository, customerRepository, productRepository}
+}
+
+// Do creates the price list of a registered customer or of a group, whose
+// customers need not be registered yet. Every priced product must be sold.
+func (service *CreatePriceList) Do(ctx context.Context, createCommand commands.CreatePriceList) (models.PriceList, error) {
+	ctx, span := tracing.Start(ctx, "services.CreatePriceList")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.ManagePriceLists); err != nil {
+		return models.PriceList{}, err
+	}
+
+	if err := createCommand.Validate(); err != nil {
+		return models.PriceList{}, err
+	}
+
+	if createCommand.CustomerId != "" {
+		if _, existCustomer := service.CustomerRepository.SearchById(ctx, createCommand.CustomerId); !existCustomer {
+			return models.PriceList{}, errors.NewCustomerNotFoundError(createCommand.CustomerId)
+		}
+	}
+
+	productCodes := make([]string, 0, len(createCommand.Prices))
+	for productCode := range createCommand.Prices {
+		productCodes = append(productCodes, productCode)
+	}
+	sort.Strings(productCodes)
+	for _, productCode := range productCodes {
+		if _, existProduct := service.ProductRepository.SearchById(ctx, productCode); !existProduct {
+			return models.PriceList{}, errors.NewProductNotFoundError(productCode)
+		}
+	}
+
+	priceList := models.PriceList{
+		Id:         uuid.NewString(),
+		Name:       strings.TrimSpace(createCommand.Name),
+		CustomerId: createCommand.CustomerId,
+		Group:      createCommand.Group,
+		Prices:     createCommand.Prices,
+	}
+	if err := checkContext(ctx); err != nil {
+		return models.PriceList{}, err
+	}
+	service.PriceListRepository.Persist(ctx, priceList)
+
+	return priceList, nil
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"lana/flagship-store/auth"
+	"lana/flagship-store/models"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreatePriceListOfACustomer(t *testing.T) {
+	thePriceListRepositoryMock := mocks.PriceListRepositoryMock{}
+	thePriceListRepositoryMock.On("Persist", mock.AnythingOfType("models.PriceList"))
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("SearchById", "a-customer").Return(models.Customer{Id: "a-customer"}, true)
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "MUG").Return(models.Product{}, true)
+	createCommand := commands.CreatePriceList{Name: "Acme ", CustomerId: "a-customer", Prices: map[string]int{"MUG": 600}}
+	createPriceList := CreatePriceList{&thePriceListRepositoryMock, &theCustomerRepositoryMock, &theProductRepositoryMock}
+
+	priceList, err := createPriceList.Do(principalContext(auth.RoleAdmin, "an-admin"), createCommand)
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, priceList.Id)
+	assert.EqualValues(t, "Acme", priceList.Name)
+	assert.EqualValues(t, map[string]int{"MUG": 600}, priceList.Prices)
+	thePriceListRepositoryMock.AssertCalled(t, "Persist", priceList)
+}
+
+func TestReturnCustomerNotFoundErrorWhenCreatingPriceListOfMissingCustomer(t *testing.T) {
+	thePriceListRepositoryMock := mocks.PriceListRepositoryMock{}
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("SearchById", "a-customer").Return(models.Customer{}, false)
+	createCommand := commands.CreatePriceList{Name: "Acme", CustomerId: "a-customer", Prices: map[string]int{"MUG": 600}}
+	createPriceList := CreatePriceList{&thePriceListRepositoryMock, &theCustomerRepositoryMock, &mocks.ProductRepositoryMock{}}
+
+	_, err := createPriceList.Do(principalContext(auth.RoleAdmin, "an-admin"), createCommand)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrCustomerNotFound))
+	thePriceListRepositoryMock.AssertNotCalled(t, "Persist", mock.Anything)
+}
+
+func TestReturnProductNotFoundErrorWhenPricingProductThatDoesNotExist(t *testing.T) {
+	thePriceListRepositoryMock := mocks.PriceListRepositoryMock{}
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "FAKE").Return(models.Product{}, false)
+	createCommand := commands.CreatePriceList{Name: "Wholesale", Group: "wholesale", Prices: map[string]int{"FAKE": 100}}
+	createPriceList := CreatePriceList{&thePriceListRepositoryMock, &mocks.CustomerRepositoryMock{}, &theProductRepositoryMock}
+
+	_, err := createPriceList.Do(principalContext(auth.RoleAdmin, "an-admin"), createCommand)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrProductNotFound))
+}
+
+func TestReturnForbiddenErrorWhenMerchandiserCreatesPriceList(t *testing.T) {
+	createCommand := commands.CreatePriceList{Name: "Wholesale", Group: "wholesale", Prices: map[string]int{"MUG": 600}}
+	createPriceList := CreatePriceList{&mocks.PriceListRepositoryMock{}, &mocks.CustomerRepositoryMock{}, &mocks.ProductRepositoryMock{}}
+
+	_, err := createPriceList.Do(principalContext(auth.RoleMerchandiser, "a-merchandiser"), createCommand)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrForbidden))
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/tracing"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateSavedList struct {
+	SavedListRepository persistence.SavedListRepository
+	CheckoutRepository  persistence.CheckoutRepository
+	ProductRepository   persistence.ProductRepository
+}
+
+func NewCreateSavedList(savedListRepository persistence.SavedListRepository, checkoutRepository persistence.CheckoutRepository, productRepository persistence.ProductRepository) CreateSavedList {
+	return CreateSavedList{savedListRepository, checkoutRepository, productRepository}
+}
+
+// Do saves a list of the caller with the products of the lines or, when a
+// checkout is given, a copy of the products of the checkout, which is left
+// untouched.
+func (service *CreateSavedList) Do(ctx context.Context, createCommand commands.CreateSavedList) (models.SavedList, error) {
+	ctx, span := tracing.Start(ctx, "services.CreateSavedList")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.CreateSavedList); err != nil {
+		return models.SavedList{}, err
+	}
+
+	if err := createCommand.Validate(); err != nil {
+		return models.SavedList{}, err
+	}
+
+	products := []string{}
+	if createCommand.CheckoutId != "" {
+		checkout, existCheckout := service.CheckoutRepository.SearchById(ctx, createCommand.CheckoutId)
+		if !existCheckout {
+			return models.SavedList{}, errors.NewCheckoutNotFoundError(createCommand.CheckoutId)
+		}
+		if err := checkOwner(ctx, checkout); err != nil {
+			return models.SavedList{}, err
+		}
+		products = append(products, checkout.Products...)
+	}
+	for _, line := range createCommand.Lines {
+		if _, existProduct := service.ProductRepository.SearchById(ctx, line.ProductCode); !existProduct {
+			return models.SavedList{}, errors.NewProductNotFoundError(line.ProductCode)
+		}
+		products = appendProductUnits(products, line)
+	}
+
+	savedList := models.SavedList{
+		Id:        uuid.NewString(),
+		Name:      strings.TrimSpace(createCommand.Name),
+		Kind:      createCommand.Kind,
+		Products:  products,
+		Owner:     ownerOf(ctx),
+		CreatedAt: time.Now(),
+	}
+	if err := checkContext(ctx); err != nil {
+		return models.SavedList{}, err
+	}
+	service.SavedListRepository.Persist(ctx, savedList)
+
+	return savedList, nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"lana/flagship-store/auth"
+	"lana/flagship-store/models"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateSavedListWithTheProductsOfTheLines(t *testing.T) {
+	theSavedListRepositoryMock := mocks.SavedListRepositoryMock{}
+	theSavedListRepositoryMock.On("Persist", mock.AnythingOfType("models.SavedList"))
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "PEN").Return(models.Product{}, true)
+	createCommand := commands.CreateSavedList{Name: " Birthday ", Kind: models.SavedListKindWishlist, Lines: []commands.Line{{ProductCode: "PEN", Quantity: 2}}}
+	createSavedList := CreateSavedList{&theSavedListRepositoryMock, &mocks.CheckoutRepositoryMock{}, &theProductRepositoryMock}
+
+	savedList, err := createSavedList.Do(principalContext(auth.RoleShopper, "a-shopper"), createCommand)
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, savedList.Id)
+	assert.EqualValues(t, "Birthday", savedList.Name)
+	assert.EqualValues(t, []string{"PEN", "PEN"}, savedList.Products)
+	assert.EqualValues(t, "jwt:a-shopper", savedList.Owner)
+	theSavedListRepositoryMock.AssertCalled(t, "Persist", savedList)
+}
+
+func TestCreateSavedListCopyingTheProductsOfACheckout(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", Products: []string{"MUG", "PEN"}, Owner: "jwt:a-shopper"}
+	theSavedListRepositoryMock := mocks.SavedListRepositoryMock{}
+	theSavedListRepositoryMock.On("Persist", mock.AnythingOfType("models.SavedList"))
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	createCommand := commands.CreateSavedList{Name: "For later", Kind: models.SavedListKindCart, CheckoutId: checkout.Id}
+	createSavedList := CreateSavedList{&theSavedListRepositoryMock, &theCheckoutRepositoryMock, &mocks.ProductRepositoryMock{}}
+
+	savedList, err := createSavedList.Do(principalContext(auth.RoleShopper, "a-shopper"), createCommand)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"MUG", "PEN"}, savedList.Products)
+	theCheckoutRepositoryMock.AssertNotCalled(t, "Persist", mock.Anything)
+	theCheckoutRepositoryMock.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
+func TestReturnForbiddenErrorWhenSavingCheckoutOfAnotherOwner(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", Products: []string{"MUG"}, Owner: "jwt:another-shopper"}
+	theSavedListRepositoryMock := mocks.SavedListRepositoryMock{}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	createCommand := commands.CreateSavedList{Name: "For later", Kind: models.SavedListKindCart, CheckoutId: checkout.Id}
+	createSavedList := CreateSavedList{&theSavedListRepositoryMock, &theCheckoutRepositoryMock, &mocks.ProductRepositoryMock{}}
+
+	_, err := createSavedList.Do(principalContext(auth.RoleShopper, "a-shopper"), createCommand)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrForbidden))
+	theSavedListRepositoryMock.AssertNotCalled(t, "Persist", mock.Anything)
+}
+
+func TestReturnProductNotFoundErrorWhenSavingProductThatDoesNotExist(t *testing.T) {
+	theSavedListRepositoryMock := mocks.SavedListRepositoryMock{}
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "FAKE").Return(models.Product{}, false)
+	createCommand := commands.CreateSavedList{Name: "Birthday", Kind: models.SavedListKindWishlist, Lines: []commands.Line{{ProductCode: "FAKE", Quantity: 1}}}
+	createSavedList := CreateSavedList{&theSavedListRepositoryMock, &mocks.CheckoutRepositoryMock{}, &theProductRepositoryMock}
+
+	_, err := createSavedList.Do(trustedContext(), createCommand)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrProductNotFound))
+	theSavedListRepositoryMock.AssertNotCalled(t, "Persist", mock.Anything)
+}
@@ -1,9 +1,12 @@
 package services
 
 import (
+	"context"
 	"lana/flagship-store/models"
 	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
 	"lana/flagship-store/services/errors"
+	"lana/flagship-store/tracing"
 )
 
 type DeleteCheckout struct {
@@ -14,13 +17,31 @@ func NewDeleteCheckout(checkoutRepository persistence.CheckoutRepository) Delete
 	return DeleteCheckout{checkoutRepository}
 }
 
-func (service *DeleteCheckout) Do(checkoutId string) (models.Checkout, error) {
-	checkout, existCheckout := service.CheckoutRepository.SearchById(checkoutId)
+func (service *DeleteCheckout) Do(ctx context.Context, checkoutId string) (models.Checkout, error) {
+	ctx, span := tracing.Start(ctx, "services.DeleteCheckout")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.DeleteCheckout); err != nil {
+		return models.Checkout{}, err
+	}
+
+	checkout, existCheckout := service.CheckoutRepository.SearchById(ctx, checkoutId)
 	if !existCheckout {
-		return models.Checkout{}, errors.NewCheckoutNotFoundError()
+		return models.Checkout{}, errors.NewCheckoutNotFoundError(checkoutId)
+	}
+	if err := checkOwner(ctx, checkout); err != nil {
+		return models.Checkout{}, err
+	}
+	if err := checkOpen(checkout); err != nil {
+		return models.Checkout{}, err
 	}
 
-	service.CheckoutRepository.Delete(checkout)
+	if err := checkContext(ctx); err != nil {
+		return models.Checkout{}, err
+	}
+	if !service.CheckoutRepository.DeleteIfStatus(ctx, checkout, checkout.Status) {
+		return models.Checkout{}, refusedWriteError(ctx, service.CheckoutRepository, checkout.Id)
+	}
 
 	return checkout, nil
 }
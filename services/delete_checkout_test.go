@@ -1,6 +1,7 @@
 package services
 
 import (
+	"lana/flagship-store/auth"
 	"lana/flagship-store/models"
 	"lana/flagship-store/services/errors"
 	"lana/flagship-store/utils/mocks"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestDeleteCheckout(t *testing.T) {
@@ -17,13 +19,13 @@ func TestDeleteCheckout(t *testing.T) {
 	}
 	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
 	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
-	theCheckoutRepositoryMock.On("Delete", checkout)
+	theCheckoutRepositoryMock.On("DeleteIfStatus", checkout, mock.AnythingOfType("string")).Return(true)
 	deleteCheckout := DeleteCheckout{&theCheckoutRepositoryMock}
 
-	_, err := deleteCheckout.Do(checkout.Id)
+	_, err := deleteCheckout.Do(trustedContext(), checkout.Id)
 
 	assert.Nil(t, err)
-	theCheckoutRepositoryMock.AssertNumberOfCalls(t, "Delete", 1)
+	theCheckoutRepositoryMock.AssertNumberOfCalls(t, "DeleteIfStatus", 1)
 	theCheckoutRepositoryMock.AssertExpectations(t)
 }
 
@@ -32,9 +34,59 @@ func TestDeleteReturnCheckoutNotFoundErrorWhenCheckoutDoesnotExists(t *testing.T
 	theCheckoutRepositoryMock.On("SearchById", "a_fake_id").Return(models.Checkout{}, false)
 	deleteCheckout := DeleteCheckout{&theCheckoutRepositoryMock}
 
-	_, err := deleteCheckout.Do("a_fake_id")
+	_, err := deleteCheckout.Do(trustedContext(), "a_fake_id")
 
-	_, isCheckoutNotFoundError := err.(*errors.CheckoutNotFoundError)
+	isCheckoutNotFoundError := errors.Is(err, errors.ErrCheckoutNotFound)
 	assert.EqualValues(t, true, isCheckoutNotFoundError)
 	theCheckoutRepositoryMock.AssertExpectations(t)
 }
+
+func TestDeleteReturnForbiddenErrorWhenCheckoutBelongsToAnotherOwner(t *testing.T) {
+	checkout := models.Checkout{
+		Id:       uuid.NewString(),
+		Products: []string{"MUG"},
+		Owner:    "jwt:another-shopper",
+	}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	deleteCheckout := DeleteCheckout{&theCheckoutRepositoryMock}
+	ctx := principalContext(auth.RoleShopper, "a-shopper")
+
+	_, err := deleteCheckout.Do(ctx, checkout.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrForbidden))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "DeleteIfStatus", checkout, mock.Anything)
+}
+
+func TestDeleteReturnCheckoutNotOpenErrorWhenCheckoutIsCompleted(t *testing.T) {
+	checkout := models.Checkout{
+		Id:       uuid.NewString(),
+		Products: []string{"MUG"},
+		Status:   models.CheckoutStatusCompleted,
+		Owner:    "jwt:a-shopper",
+	}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	deleteCheckout := DeleteCheckout{&theCheckoutRepositoryMock}
+	ctx := principalContext(auth.RoleShopper, "a-shopper")
+
+	_, err := deleteCheckout.Do(ctx, checkout.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrCheckoutNotOpen))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "DeleteIfStatus", checkout, mock.Anything)
+}
+
+func TestDeleteReturnCheckoutNotOpenErrorWhenTheCheckoutIsCompletedWhileDeleting(t *testing.T) {
+	checkout := models.Checkout{Id: uuid.NewString(), Products: []string{"MUG"}, Status: models.CheckoutStatusOpen}
+	completed := checkout
+	completed.Status = models.CheckoutStatusCompleted
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true).Once()
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(completed, true)
+	theCheckoutRepositoryMock.On("DeleteIfStatus", checkout, models.CheckoutStatusOpen).Return(false)
+	deleteCheckout := DeleteCheckout{&theCheckoutRepositoryMock}
+
+	_, err := deleteCheckout.Do(trustedContext(), checkout.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrCheckoutNotOpen))
+}
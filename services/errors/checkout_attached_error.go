@@ -0,0 +1,7 @@
+package errors
+
+var ErrCheckoutAttached = &Error{Code: CodeCheckoutAttached, Message: "Checkout attached to another customer"}
+
+func NewCheckoutAttachedError(checkoutId string) error {
+	return New(CodeCheckoutAttached, "Checkout "+checkoutId+" is attached to another customer", map[string]interface{}{"checkout-id": checkoutId})
+}
@@ -0,0 +1,7 @@
+package errors
+
+var ErrCheckoutModified = &Error{Code: CodeCheckoutModified, Message: "Checkout modified"}
+
+func NewCheckoutModifiedError(checkoutId string) error {
+	return New(CodeCheckoutModified, "Checkout "+checkoutId+" was modified by another request", map[string]interface{}{"checkout-id": checkoutId})
+}
@@ -1,13 +1,7 @@
 package errors
 
-type CheckoutNotFoundError struct {
-	data string
-}
-
-func NewCheckoutNotFoundError() error {
-	return &CheckoutNotFoundError{}
-}
+var ErrCheckoutNotFound = &Error{Code: CodeCheckoutNotFound, Message: "Checkout not found"}
 
-func (e *CheckoutNotFoundError) Error() string {
-	return ""
+func NewCheckoutNotFoundError(checkoutId string) error {
+	return New(CodeCheckoutNotFound, "Checkout "+checkoutId+" not found", map[string]interface{}{"checkout-id": checkoutId})
 }
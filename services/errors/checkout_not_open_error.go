@@ -0,0 +1,7 @@
+package errors
+
+var ErrCheckoutNotOpen = &Error{Code: CodeCheckoutNotOpen, Message: "Checkout not open"}
+
+func NewCheckoutNotOpenError(checkoutId string, status string) error {
+	return New(CodeCheckoutNotOpen, "Checkout "+checkoutId+" is "+status, map[string]interface{}{"checkout-id": checkoutId, "status": status})
+}
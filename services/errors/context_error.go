@@ -0,0 +1,19 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+)
+
+var ErrCanceled = &Error{Code: CodeCanceled, Message: "Request canceled"}
+
+var ErrDeadlineExceeded = &Error{Code: CodeDeadlineExceeded, Message: "Deadline exceeded"}
+
+// NewContextError wraps the error of a done context: a deadline exceeded or
+// a request canceled by the client.
+func NewContextError(err error) error {
+	if stderrors.Is(err, context.DeadlineExceeded) {
+		return Wrap(err, CodeDeadlineExceeded, "Deadline exceeded serving the request")
+	}
+	return Wrap(err, CodeCanceled, "Request canceled by the client")
+}
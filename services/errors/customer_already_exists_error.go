@@ -0,0 +1,7 @@
+package errors
+
+var ErrCustomerAlreadyExists = &Error{Code: CodeCustomerExists, Message: "Customer already exists"}
+
+func NewCustomerAlreadyExistsError(email string) error {
+	return New(CodeCustomerExists, "Customer with email "+email+" already exists", map[string]interface{}{"email": email})
+}
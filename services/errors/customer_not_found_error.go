@@ -0,0 +1,7 @@
+package errors
+
+var ErrCustomerNotFound = &Error{Code: CodeCustomerNotFound, Message: "Customer not found"}
+
+func NewCustomerNotFoundError(customerId string) error {
+	return New(CodeCustomerNotFound, "Customer "+customerId+" not found", map[string]interface{}{"customer-id": customerId})
+}
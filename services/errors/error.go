@@ -0,0 +1,88 @@
+package errors
+
+import stderrors "errors"
+
+type Code string
+
+const (
+	CodeInternal             Code = "internal"
+	CodeNotFound             Code = "not-found"
+	CodeMethodNotAllowed     Code = "method-not-allowed"
+	CodeInvalidParameter     Code = "invalid-parameter"
+	CodeUnsupportedMedia     Code = "unsupported-media-type"
+	CodePayloadTooLarge      Code = "payload-too-large"
+	CodeMalformedBody        Code = "malformed-body"
+	CodeValidation           Code = "validation-failed"
+	CodeCheckoutNotFound     Code = "checkout-not-found"
+	CodeProductNotFound      Code = "product-not-found"
+	CodeQuantityExceeded     Code = "quantity-limit-exceeded"
+	CodeProductNotInCheckout Code = "product-not-in-checkout"
+	CodeCanceled             Code = "request-canceled"
+	CodeDeadlineExceeded     Code = "deadline-exceeded"
+	CodeUnauthorized         Code = "unauthorized"
+	CodeForbidden            Code = "forbidden"
+	CodeRateLimited          Code = "rate-limited"
+	CodeOpenCheckoutsLimit   Code = "open-checkouts-limit-exceeded"
+	CodeIdempotencyKeyReused Code = "idempotency-key-reused"
+	CodeIdempotencyKeyInUse  Code = "idempotency-key-in-use"
+	CodeCustomerNotFound     Code = "customer-not-found"
+	CodeCustomerExists       Code = "customer-already-exists"
+	CodeCheckoutAttached     Code = "checkout-attached-to-another-customer"
+	CodeSavedListNotFound    Code = "saved-list-not-found"
+	CodeCheckoutNotOpen      Code = "checkout-not-open"
+	CodeCheckoutModified     Code = "checkout-modified"
+	CodeInsufficientPoints   Code = "insufficient-loyalty-points"
+	CodeGuestCheckout        Code = "checkout-without-customer"
+)
+
+// Error is the single error type returned by the services. Errors are
+// compared by Code, so errors.Is(err, ErrCheckoutNotFound) matches any
+// checkout not found error whatever its message or details.
+type Error struct {
+	Code    Code
+	Message string
+	Details map[string]interface{}
+	Err     error
+}
+
+func New(code Code, message string, details map[string]interface{}) error {
+	return &Error{Code: code, Message: message, Details: details}
+}
+
+func Wrap(err error, code Code, message string) error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	targetError, ok := target.(*Error)
+	return ok && targetError.Code == e.Code
+}
+
+func Is(err error, target error) bool {
+	return stderrors.Is(err, target)
+}
+
+func As(err error, target interface{}) bool {
+	return stderrors.As(err, target)
+}
+
+// CodeOf returns the code of the first Error in the chain of err, or
+// CodeInternal when err was not raised by the services.
+func CodeOf(err error) Code {
+	var serviceError *Error
+	if As(err, &serviceError) {
+		return serviceError.Code
+	}
+	return CodeInternal
+}
@@ -0,0 +1,56 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsMatchErrorsWithTheSameCode(t *testing.T) {
+	err := NewCheckoutNotFoundError("an_id")
+
+	assert.EqualValues(t, true, Is(err, ErrCheckoutNotFound))
+	assert.EqualValues(t, false, Is(err, ErrProductNotFound))
+}
+
+func TestIsMatchWrappedErrors(t *testing.T) {
+	err := fmt.Errorf("retrieving amount: %w", NewProductNotFoundError("FAKE"))
+
+	assert.EqualValues(t, true, Is(err, ErrProductNotFound))
+	assert.EqualValues(t, CodeProductNotFound, CodeOf(err))
+}
+
+func TestWrapKeepTheCause(t *testing.T) {
+	cause := stderrors.New("connection refused")
+
+	err := Wrap(cause, CodeInternal, "Checkout repository unavailable")
+
+	assert.EqualValues(t, true, Is(err, cause))
+	assert.EqualValues(t, "Checkout repository unavailable: connection refused", err.Error())
+}
+
+func TestCodeOfReturnInternalForUnknownErrors(t *testing.T) {
+	assert.EqualValues(t, CodeInternal, CodeOf(stderrors.New("boom")))
+}
+
+func TestErrorMessageAndDetails(t *testing.T) {
+	var serviceError *Error
+
+	As(NewCheckoutNotFoundError("an_id"), &serviceError)
+
+	assert.EqualValues(t, "Checkout an_id not found", serviceError.Error())
+	assert.EqualValues(t, "an_id", serviceError.Details["checkout-id"])
+}
+
+func TestNewContextErrorDistinguishDeadlinesFromCancellations(t *testing.T) {
+	deadlineExceeded := NewContextError(context.DeadlineExceeded)
+	canceled := NewContextError(context.Canceled)
+
+	assert.EqualValues(t, true, Is(deadlineExceeded, ErrDeadlineExceeded))
+	assert.EqualValues(t, true, Is(deadlineExceeded, context.DeadlineExceeded))
+	assert.EqualValues(t, true, Is(canceled, ErrCanceled))
+	assert.EqualValues(t, true, Is(canceled, context.Canceled))
+}
@@ -0,0 +1,19 @@
+package errors
+
+var ErrForbidden = &Error{Code: CodeForbidden, Message: "Forbidden"}
+
+func NewCheckoutForbiddenError(checkoutId string) error {
+	return New(CodeForbidden, "Checkout "+checkoutId+" belongs to another owner", map[string]interface{}{"checkout-id": checkoutId})
+}
+
+func NewCommandForbiddenError(role string, command string) error {
+	return New(CodeForbidden, "Role "+role+" may not "+command, map[string]interface{}{"role": role, "command": command})
+}
+
+func NewCustomerForbiddenError(customerId string) error {
+	return New(CodeForbidden, "Customer "+customerId+" belongs to another owner", map[string]interface{}{"customer-id": customerId})
+}
+
+func NewSavedListForbiddenError(savedListId string) error {
+	return New(CodeForbidden, "Saved list "+savedListId+" belongs to another owner", map[string]interface{}{"saved-list-id": savedListId})
+}
@@ -0,0 +1,13 @@
+package errors
+
+var ErrIdempotencyKeyReused = &Error{Code: CodeIdempotencyKeyReused, Message: "Idempotency key reused"}
+
+var ErrIdempotencyKeyInUse = &Error{Code: CodeIdempotencyKeyInUse, Message: "Idempotency key in use"}
+
+func NewIdempotencyKeyReusedError(key string) error {
+	return New(CodeIdempotencyKeyReused, "Idempotency key "+key+" was used by a different request", map[string]interface{}{"idempotency-key": key})
+}
+
+func NewIdempotencyKeyInUseError(key string) error {
+	return New(CodeIdempotencyKeyInUse, "Request with idempotency key "+key+" is still being served", map[string]interface{}{"idempotency-key": key})
+}
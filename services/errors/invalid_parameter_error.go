@@ -0,0 +1,7 @@
+package errors
+
+var ErrInvalidParameter = &Error{Code: CodeInvalidParameter, Message: "Invalid parameter"}
+
+func NewInvalidParameterError(parameter string) error {
+	return New(CodeInvalidParameter, "Invalid parameter "+parameter, map[string]interface{}{"parameter": parameter})
+}
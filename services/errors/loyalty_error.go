@@ -0,0 +1,15 @@
+package errors
+
+import "strconv"
+
+var ErrInsufficientPoints = &Error{Code: CodeInsufficientPoints, Message: "Insufficient loyalty points"}
+
+var ErrGuestCheckout = &Error{Code: CodeGuestCheckout, Message: "Checkout without customer"}
+
+func NewInsufficientPointsError(points int, balance int) error {
+	return New(CodeInsufficientPoints, "Can not redeem "+strconv.Itoa(points)+" points of a balance of "+strconv.Itoa(balance), map[string]interface{}{"points": points, "balance": balance})
+}
+
+func NewGuestCheckoutError(checkoutId string) error {
+	return New(CodeGuestCheckout, "Checkout "+checkoutId+" is not attached to a customer", map[string]interface{}{"checkout-id": checkoutId})
+}
@@ -0,0 +1,7 @@
+package errors
+
+var ErrMalformedBody = &Error{Code: CodeMalformedBody, Message: "Malformed body"}
+
+func NewMalformedBodyError(reason string, details map[string]interface{}) error {
+	return New(CodeMalformedBody, "Request body is not valid JSON: "+reason, details)
+}
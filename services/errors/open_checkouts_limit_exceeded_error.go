@@ -0,0 +1,9 @@
+package errors
+
+import "strconv"
+
+var ErrOpenCheckoutsLimitExceeded = &Error{Code: CodeOpenCheckoutsLimit, Message: "Open checkouts limit exceeded"}
+
+func NewOpenCheckoutsLimitExceededError(maxOpenCheckouts int) error {
+	return New(CodeOpenCheckoutsLimit, "Can not have more than "+strconv.Itoa(maxOpenCheckouts)+" open checkouts", map[string]interface{}{"max-open-checkouts": maxOpenCheckouts})
+}
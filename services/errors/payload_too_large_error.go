@@ -0,0 +1,9 @@
+package errors
+
+import "strconv"
+
+var ErrPayloadTooLarge = &Error{Code: CodePayloadTooLarge, Message: "Payload too large"}
+
+func NewPayloadTooLargeError(maxBytes int64) error {
+	return New(CodePayloadTooLarge, "Request body must not be larger than "+strconv.FormatInt(maxBytes, 10)+" bytes", map[string]interface{}{"max-bytes": maxBytes})
+}
@@ -1,13 +1,7 @@
 package errors
 
-type ProductNotFoundError struct {
-	data string
-}
-
-func NewProductNotFoundError() error {
-	return &ProductNotFoundError{}
-}
+var ErrProductNotFound = &Error{Code: CodeProductNotFound, Message: "Product not found"}
 
-func (e *ProductNotFoundError) Error() string {
-	return ""
+func NewProductNotFoundError(productCode string) error {
+	return New(CodeProductNotFound, "Product "+productCode+" not found", map[string]interface{}{"product-code": productCode})
 }
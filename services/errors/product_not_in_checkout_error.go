@@ -0,0 +1,7 @@
+package errors
+
+var ErrProductNotInCheckout = &Error{Code: CodeProductNotInCheckout, Message: "Product not in checkout"}
+
+func NewProductNotInCheckoutError(checkoutId string, productCode string) error {
+	return New(CodeProductNotInCheckout, "Checkout "+checkoutId+" has no units of product "+productCode, map[string]interface{}{"checkout-id": checkoutId, "product-code": productCode})
+}
@@ -0,0 +1,9 @@
+package errors
+
+import "strconv"
+
+var ErrQuantityLimitExceeded = &Error{Code: CodeQuantityExceeded, Message: "Quantity limit exceeded"}
+
+func NewQuantityLimitExceededError(productCode string, maxQuantity int) error {
+	return New(CodeQuantityExceeded, "Checkout can not contain more than "+strconv.Itoa(maxQuantity)+" units of product "+productCode, map[string]interface{}{"product-code": productCode, "max-quantity": maxQuantity})
+}
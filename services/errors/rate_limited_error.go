@@ -0,0 +1,16 @@
+package errors
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+var ErrRateLimited = &Error{Code: CodeRateLimited, Message: "Rate limit exceeded"}
+
+// NewRateLimitedError details the seconds to wait before retrying, rounded
+// up so retrying then is allowed.
+func NewRateLimitedError(retryAfter time.Duration) error {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	return New(CodeRateLimited, "Rate limit exceeded, retry in "+strconv.Itoa(seconds)+"s", map[string]interface{}{"retry-after": seconds})
+}
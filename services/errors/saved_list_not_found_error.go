@@ -0,0 +1,7 @@
+package errors
+
+var ErrSavedListNotFound = &Error{Code: CodeSavedListNotFound, Message: "Saved list not found"}
+
+func NewSavedListNotFoundError(savedListId string) error {
+	return New(CodeSavedListNotFound, "Saved list "+savedListId+" not found", map[string]interface{}{"saved-list-id": savedListId})
+}
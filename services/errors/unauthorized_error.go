@@ -0,0 +1,7 @@
+package errors
+
+var ErrUnauthorized = &Error{Code: CodeUnauthorized, Message: "Unauthorized"}
+
+func NewUnauthorizedError(reason string) error {
+	return New(CodeUnauthorized, reason, nil)
+}
@@ -0,0 +1,7 @@
+package errors
+
+var ErrUnsupportedMediaType = &Error{Code: CodeUnsupportedMedia, Message: "Unsupported media type"}
+
+func NewUnsupportedMediaTypeError(mediaType string) error {
+	return New(CodeUnsupportedMedia, "Content type "+mediaType+" is not supported, use application/json", map[string]interface{}{"content-type": mediaType})
+}
@@ -0,0 +1,9 @@
+package errors
+
+var ErrValidation = &Error{Code: CodeValidation, Message: "Validation failed"}
+
+// NewValidationError reports the invalid fields of a command, keyed by
+// their JSON name.
+func NewValidationError(fields map[string]string) error {
+	return New(CodeValidation, "Request has invalid fields", map[string]interface{}{"fields": fields})
+}
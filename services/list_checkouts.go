@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/tracing"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCheckoutsPageSize = 20
+	maxCheckoutsPageSize     = 100
+)
+
+type ListCheckouts struct {
+	CheckoutRepository persistence.CheckoutRepository
+}
+
+func NewListCheckouts(checkoutRepository persistence.CheckoutRepository) ListCheckouts {
+	return ListCheckouts{checkoutRepository}
+}
+
+func (service *ListCheckouts) Do(ctx context.Context, listCommand commands.ListCheckouts) ([]models.Checkout, string, error) {
+	ctx, span := tracing.Start(ctx, "services.ListCheckouts")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.ListCheckouts); err != nil {
+		return nil, "", err
+	}
+
+	criteria := persistence.CheckoutCriteria{
+		ProductCode: listCommand.ProductCode,
+		Status:      listCommand.Status,
+		CreatedFrom: listCommand.CreatedFrom,
+		CreatedTo:   listCommand.CreatedTo,
+		SortBy:      persistence.SortByCreatedAt,
+		Limit:       defaultCheckoutsPageSize,
+	}
+
+	if !policy.AllowedAnyCheckout(ctx) {
+		criteria.Owner = ownerOf(ctx)
+	}
+
+	if listCommand.Sort != "" {
+		criteria.Descending = strings.HasPrefix(listCommand.Sort, "-")
+		criteria.SortBy = strings.TrimPrefix(listCommand.Sort, "-")
+		if criteria.SortBy != persistence.SortByCreatedAt && criteria.SortBy != persistence.SortById {
+			return nil, "", errors.NewInvalidParameterError("sort")
+		}
+	}
+
+	if listCommand.Limit < 0 || listCommand.Limit > maxCheckoutsPageSize {
+		return nil, "", errors.NewInvalidParameterError("limit")
+	}
+	if listCommand.Limit > 0 {
+		criteria.Limit = listCommand.Limit
+	}
+
+	if listCommand.Cursor != "" {
+		cursor, err := decodeCheckoutCursor(listCommand.Cursor)
+		if err != nil {
+			return nil, "", errors.NewInvalidParameterError("cursor")
+		}
+		criteria.After = &cursor
+	}
+
+	if err := checkContext(ctx); err != nil {
+		return nil, "", err
+	}
+
+	pageSize := criteria.Limit
+	criteria.Limit = pageSize + 1
+	checkouts := service.CheckoutRepository.Search(ctx, criteria)
+	if len(checkouts) <= pageSize {
+		return checkouts, "", nil
+	}
+
+	checkouts = checkouts[:pageSize]
+	lastCheckout := checkouts[pageSize-1]
+	return checkouts, encodeCheckoutCursor(persistence.CheckoutCursor{CreatedAt: lastCheckout.CreatedAt, Id: lastCheckout.Id}), nil
+}
+
+func encodeCheckoutCursor(cursor persistence.CheckoutCursor) string {
+	rawCursor := strconv.FormatInt(cursor.CreatedAt.UnixNano(), 10) + ":" + cursor.Id
+	return base64.RawURLEncoding.EncodeToString([]byte(rawCursor))
+}
+
+func decodeCheckoutCursor(encodedCursor string) (persistence.CheckoutCursor, error) {
+	rawCursor, err := base64.RawURLEncoding.DecodeString(encodedCursor)
+	if err != nil {
+		return persistence.CheckoutCursor{}, err
+	}
+
+	cursorParts := strings.SplitN(string(rawCursor), ":", 2)
+	if len(cursorParts) != 2 {
+		return persistence.CheckoutCursor{}, errors.NewInvalidParameterError("cursor")
+	}
+
+	createdAt, err := strconv.ParseInt(cursorParts[0], 10, 64)
+	if err != nil {
+		return persistence.CheckoutCursor{}, err
+	}
+	return persistence.CheckoutCursor{CreatedAt: time.Unix(0, createdAt), Id: cursorParts[1]}, nil
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListCheckoutsReturnFirstPageWithoutCursorWhenThereAreNoMoreCheckouts(t *testing.T) {
+	checkout := models.Checkout{Id: "a", Products: []string{"PEN"}}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Search", persistence.CheckoutCriteria{
+		ProductCode: "PEN",
+		SortBy:      persistence.SortByCreatedAt,
+		Limit:       defaultCheckoutsPageSize + 1,
+	}).Return([]models.Checkout{checkout})
+	listCheckouts := ListCheckouts{&theCheckoutRepositoryMock}
+
+	checkouts, nextCursor, err := listCheckouts.Do(trustedContext(), commands.ListCheckouts{ProductCode: "PEN"})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, []models.Checkout{checkout}, checkouts)
+	assert.EqualValues(t, "", nextCursor)
+	theCheckoutRepositoryMock.AssertExpectations(t)
+}
+
+func TestListCheckoutsReturnCursorToNextPageWhenThereAreMoreCheckouts(t *testing.T) {
+	createdAt := time.Date(2021, time.March, 1, 10, 0, 0, 0, time.UTC)
+	first := models.Checkout{Id: "a", CreatedAt: createdAt}
+	second := models.Checkout{Id: "b", CreatedAt: createdAt.Add(time.Hour)}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Search", mock.AnythingOfType("persistence.CheckoutCriteria")).Return([]models.Checkout{first, second}).Once()
+	listCheckouts := ListCheckouts{&theCheckoutRepositoryMock}
+
+	checkouts, nextCursor, _ := listCheckouts.Do(trustedContext(), commands.ListCheckouts{Limit: 1, Sort: "-created-at"})
+
+	assert.EqualValues(t, []models.Checkout{first}, checkouts)
+	cursor, err := decodeCheckoutCursor(nextCursor)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "a", cursor.Id)
+	assert.True(t, createdAt.Equal(cursor.CreatedAt))
+	criteria := theCheckoutRepositoryMock.Calls[0].Arguments.Get(0).(persistence.CheckoutCriteria)
+	assert.EqualValues(t, 2, criteria.Limit)
+	assert.EqualValues(t, true, criteria.Descending)
+}
+
+func TestListCheckoutsSearchAfterCursor(t *testing.T) {
+	cursor := persistence.CheckoutCursor{CreatedAt: time.Date(2021, time.March, 1, 10, 0, 0, 0, time.UTC), Id: "a"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("Search", mock.AnythingOfType("persistence.CheckoutCriteria")).Return([]models.Checkout{})
+	listCheckouts := ListCheckouts{&theCheckoutRepositoryMock}
+
+	listCheckouts.Do(trustedContext(), commands.ListCheckouts{Cursor: encodeCheckoutCursor(cursor)})
+
+	criteria := theCheckoutRepositoryMock.Calls[0].Arguments.Get(0).(persistence.CheckoutCriteria)
+	assert.EqualValues(t, "a", criteria.After.Id)
+	assert.True(t, cursor.CreatedAt.Equal(criteria.After.CreatedAt))
+}
+
+func TestListCheckoutsReturnInvalidParameterErrorWhenParametersAreNotValid(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	listCheckouts := ListCheckouts{&theCheckoutRepositoryMock}
+
+	for parameter, listCommand := range map[string]commands.ListCheckouts{
+		"sort":   {Sort: "price"},
+		"limit":  {Limit: maxCheckoutsPageSize + 1},
+		"cursor": {Cursor: "not a cursor"},
+	} {
+		_, _, err := listCheckouts.Do(trustedContext(), listCommand)
+
+		var invalidParameterError *errors.Error
+		assert.EqualValues(t, true, errors.As(err, &invalidParameterError))
+		assert.EqualValues(t, errors.CodeInvalidParameter, invalidParameterError.Code)
+		assert.EqualValues(t, parameter, invalidParameterError.Details["parameter"])
+	}
+	theCheckoutRepositoryMock.AssertNotCalled(t, "Search", mock.Anything)
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/tracing"
+)
+
+type ListPriceLists struct {
+	PriceListRepository persistence.PriceListRepository
+}
+
+func NewListPriceLists(priceListRepository persistence.PriceListRepository) ListPriceLists {
+	return ListPriceLists{priceListRepository}
+}
+
+func (service *ListPriceLists) Do(ctx context.Context) ([]models.PriceList, error) {
+	ctx, span := tracing.Start(ctx, "services.ListPriceLists")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.ListPriceLists); err != nil {
+		return nil, err
+	}
+
+	return service.PriceListRepository.SearchAll(ctx), nil
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/tracing"
+)
+
+type ListProducts struct {
+	ProductRepository persistence.ProductRepository
+}
+
+func NewListProducts(productRepository persistence.ProductRepository) ListProducts {
+	return ListProducts{productRepository}
+}
+
+func (service *ListProducts) Do(ctx context.Context) ([]models.Product, error) {
+	ctx, span := tracing.Start(ctx, "services.ListProducts")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.ListProducts); err != nil {
+		return nil, err
+	}
+
+	return service.ProductRepository.SearchAll(ctx), nil
+}
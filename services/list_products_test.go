@@ -0,0 +1,21 @@
+package services
+
+import (
+	"lana/flagship-store/models"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListProducts(t *testing.T) {
+	products := []models.Product{{Code: "MUG", Name: "Lana Coffee Mug", Price: 750}, {Code: "PEN", Name: "Lana Pen", Price: 500}}
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchAll").Return(products)
+	listProducts := ListProducts{&theProductRepositoryMock}
+
+	listedProducts, err := listProducts.Do(trustedContext())
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, products, listedProducts)
+}
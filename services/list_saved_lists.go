@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/tracing"
+)
+
+type ListSavedLists struct {
+	SavedListRepository persistence.SavedListRepository
+}
+
+func NewListSavedLists(savedListRepository persistence.SavedListRepository) ListSavedLists {
+	return ListSavedLists{savedListRepository}
+}
+
+// Do lists the saved lists of the caller, or of every owner when the policy
+// allows the request to access any checkout.
+func (service *ListSavedLists) Do(ctx context.Context) ([]models.SavedList, error) {
+	ctx, span := tracing.Start(ctx, "services.ListSavedLists")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.ListSavedLists); err != nil {
+		return nil, err
+	}
+
+	owner := ownerOf(ctx)
+	if policy.AllowedAnyCheckout(ctx) {
+		owner = ""
+	}
+
+	return service.SavedListRepository.SearchByOwner(ctx, owner), nil
+}
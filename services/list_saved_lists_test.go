@@ -0,0 +1,33 @@
+package services
+
+import (
+	"lana/flagship-store/auth"
+	"lana/flagship-store/models"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListSavedListsOfTheShopper(t *testing.T) {
+	savedList := models.SavedList{Id: "a-list", Owner: "jwt:a-shopper"}
+	theSavedListRepositoryMock := mocks.SavedListRepositoryMock{}
+	theSavedListRepositoryMock.On("SearchByOwner", "jwt:a-shopper").Return([]models.SavedList{savedList})
+	listSavedLists := ListSavedLists{&theSavedListRepositoryMock}
+
+	savedLists, err := listSavedLists.Do(principalContext(auth.RoleShopper, "a-shopper"))
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, []models.SavedList{savedList}, savedLists)
+}
+
+func TestListSavedListsOfEveryOwnerForMerchandisers(t *testing.T) {
+	theSavedListRepositoryMock := mocks.SavedListRepositoryMock{}
+	theSavedListRepositoryMock.On("SearchByOwner", "").Return([]models.SavedList{})
+	listSavedLists := ListSavedLists{&theSavedListRepositoryMock}
+
+	_, err := listSavedLists.Do(principalContext(auth.RoleMerchandiser, "a-merchandiser"))
+
+	assert.Nil(t, err)
+	theSavedListRepositoryMock.AssertExpectations(t)
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/services/errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// redeemablePoints returns the points redeemed on a checkout worth at most
+// its amount, so the points discount never makes the amount negative.
+func redeemablePoints(redeemedPoints int, amount int) int {
+	if maxPoints := amount / models.LoyaltyPointValue; redeemedPoints > maxPoints {
+		return maxPoints
+	}
+	if redeemedPoints < 0 {
+		return 0
+	}
+	return redeemedPoints
+}
+
+// earnedPoints returns the points earned paying the amount.
+func earnedPoints(amount int) int {
+	return amount / 100 * models.LoyaltyPointsPerEuro
+}
+
+// checkBalance rejects redeeming more points than the customer has.
+func checkBalance(ctx context.Context, loyaltyRepository persistence.LoyaltyRepository, customerId string, points int) error {
+	if points == 0 {
+		return nil
+	}
+	if balance := loyaltyRepository.Balance(ctx, customerId); points > balance {
+		return errors.NewInsufficientPointsError(points, balance)
+	}
+	return nil
+}
+
+// redeemPoints spends the points of the customer of the checkout, unless
+// its balance no longer covers them.
+func redeemPoints(ctx context.Context, loyaltyRepository persistence.LoyaltyRepository, checkout models.Checkout, points int) error {
+	err := loyaltyRepository.Redeem(ctx, newLoyaltyTransaction(checkout, models.LoyaltyTransactionRedeem, -points))
+	if errors.Is(err, persistence.ErrInsufficientBalance) {
+		return errors.NewInsufficientPointsError(points, loyaltyRepository.Balance(ctx, checkout.CustomerId))
+	}
+	return err
+}
+
+func newLoyaltyTransaction(checkout models.Checkout, kind string, points int) models.LoyaltyTransaction {
+	return models.LoyaltyTransaction{
+		Id:         uuid.NewString(),
+		CustomerId: checkout.CustomerId,
+		CheckoutId: checkout.Id,
+		Kind:       kind,
+		Points:     points,
+		CreatedAt:  time.Now(),
+	}
+}
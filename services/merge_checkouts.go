@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/tracing"
+	"time"
+)
+
+type MergeCheckouts struct {
+	CheckoutRepository             persistence.CheckoutRepository
+	ProductRepository              persistence.ProductRepository
+	ProductWithPromotionRepository persistence.ProductRepository
+	ProductWithDiscountRepository  persistence.ProductRepository
+	Pricing                        Pricing
+}
+
+func NewMergeCheckouts(checkoutRepository persistence.CheckoutRepository, productRepository persistence.ProductRepository, productWithPromotionRepository persistence.ProductRepository, productWithDiscountRepository persistence.ProductRepository) MergeCheckouts {
+	return MergeCheckouts{CheckoutRepository: checkoutRepository, ProductRepository: productRepository, ProductWithPromotionRepository: productWithPromotionRepository, ProductWithDiscountRepository: productWithDiscountRepository}
+}
+
+// Do adds the products of the source checkout to the target one and
+// deletes the source, e.g. when a guest logs in on a device where it
+// already has a basket. Nothing is merged when a product of the source is
+// no longer sold or would exceed the quantity limit, or when the checkouts
+// are attached to different customers. The points redeemed on the source
+// are redeemed on the merged checkout, which is returned with its totals.
+func (service *MergeCheckouts) Do(ctx context.Context, mergeCommand commands.MergeCheckouts, targetId string) (models.CheckoutSummary, error) {
+	ctx, span := tracing.Start(ctx, "services.MergeCheckouts")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.MergeCheckouts); err != nil {
+		return models.CheckoutSummary{}, err
+	}
+
+	if err := mergeCommand.Validate(); err != nil {
+		return models.CheckoutSummary{}, err
+	}
+	if mergeCommand.SourceId == targetId {
+		return models.CheckoutSummary{}, errors.NewValidationError(map[string]string{"source-id": "must not be the checkout merged into"})
+	}
+
+	target, existTarget := service.CheckoutRepository.SearchById(ctx, targetId)
+	if !existTarget {
+		return models.CheckoutSummary{}, errors.NewCheckoutNotFoundError(targetId)
+	}
+	if err := checkOwner(ctx, target); err != nil {
+		return models.CheckoutSummary{}, err
+	}
+	if err := checkOpen(target); err != nil {
+		return models.CheckoutSummary{}, err
+	}
+	source, existSource := service.CheckoutRepository.SearchById(ctx, mergeCommand.SourceId)
+	if !existSource {
+		return models.CheckoutSummary{}, errors.NewCheckoutNotFoundError(mergeCommand.SourceId)
+	}
+	if err := checkOwner(ctx, source); err != nil {
+		return models.CheckoutSummary{}, err
+	}
+	if err := checkOpen(source); err != nil {
+		return models.CheckoutSummary{}, err
+	}
+	if target.CustomerId != "" && source.CustomerId != "" && target.CustomerId != source.CustomerId {
+		return models.CheckoutSummary{}, errors.NewCheckoutAttachedError(source.Id)
+	}
+
+	for _, productCode := range distinctProducts(source.Products) {
+		if _, existProduct := service.ProductRepository.SearchById(ctx, productCode); !existProduct {
+			return models.CheckoutSummary{}, errors.NewProductNotFoundError(productCode)
+		}
+	}
+	products, err := mergeProducts(target.Products, source.Products)
+	if err != nil {
+		return models.CheckoutSummary{}, err
+	}
+
+	target.Products = products
+	if target.CustomerId == "" {
+		target.CustomerId = source.CustomerId
+	}
+	target.RedeemedPoints += source.RedeemedPoints
+	target.UpdatedAt = time.Now()
+	if err := checkContext(ctx); err != nil {
+		return models.CheckoutSummary{}, err
+	}
+	if err := moveCheckout(ctx, service.CheckoutRepository, source, target); err != nil {
+		return models.CheckoutSummary{}, err
+	}
+
+	return summarizeCheckout(ctx, target, service.Pricing.productRepositoryOf(ctx, target, service.ProductRepository), service.ProductWithPromotionRepository, service.ProductWithDiscountRepository), nil
+}
+
+// moveCheckout deletes the source checkout and persists the target one it
+// was merged into, both only while unchanged since they were read. The
+// source is restored when the target can not be persisted, nobody else can
+// have changed it once deleted.
+func moveCheckout(ctx context.Context, checkoutRepository persistence.CheckoutRepository, source models.Checkout, target models.Checkout) error {
+	if !checkoutRepository.DeleteIfStatus(ctx, source, source.Status) {
+		return refusedWriteError(ctx, checkoutRepository, source.Id)
+	}
+	if !checkoutRepository.PersistIfStatus(ctx, target, target.Status) {
+		checkoutRepository.Persist(ctx, source)
+		return refusedWriteError(ctx, checkoutRepository, target.Id)
+	}
+	return nil
+}
+
+// mergeProducts appends the source products to the target ones, failing
+// when a product would exceed the quantity limit.
+func mergeProducts(target []string, source []string) ([]string, error) {
+	merged := append([]string{}, target...)
+	for _, productCode := range source {
+		if countProductUnits(merged, productCode) >= models.MaxProductQuantity {
+			return nil, errors.NewQuantityLimitExceededError(productCode, models.MaxProductQuantity)
+		}
+		merged = append(merged, productCode)
+	}
+	return merged, nil
+}
+
+func distinctProducts(products []string) []string {
+	seen := make(map[string]bool)
+	distinct := []string{}
+	for _, productCode := range products {
+		if !seen[productCode] {
+			seen[productCode] = true
+			distinct = append(distinct, productCode)
+		}
+	}
+	return distinct
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"lana/flagship-store/auth"
+	"lana/flagship-store/models"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func mergeCheckoutsWith(theCheckoutRepositoryMock *mocks.CheckoutRepositoryMock) MergeCheckouts {
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "PEN").Return(models.Product{Code: "PEN", Price: 500}, true)
+	theProductRepositoryMock.On("SearchById", "MUG").Return(models.Product{Code: "MUG", Price: 750}, true)
+	theProductRepositoryMock.On("SearchById", "TSHIRT").Return(models.Product{Code: "TSHIRT", Price: 2000}, true)
+	theProductRepositoryMock.On("SearchById", "FAKE").Return(models.Product{}, false)
+	theProductWithPromotionRepositoryMock := mocks.ProductWithPromotionRepositoryMock{}
+	theProductWithPromotionRepositoryMock.On("SearchById", "PEN").Return(models.Product{Code: "PEN", Price: 500}, true)
+	theProductWithPromotionRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	theProductWithDiscountRepositoryMock := mocks.ProductWithDiscountRepositoryMock{}
+	theProductWithDiscountRepositoryMock.On("SearchById", mock.AnythingOfType("string")).Return(models.Product{}, false)
+	return MergeCheckouts{theCheckoutRepositoryMock, &theProductRepositoryMock, &theProductWithPromotionRepositoryMock, &theProductWithDiscountRepositoryMock, Pricing{}}
+}
+
+func TestMergeCheckoutsAndDeleteTheSource(t *testing.T) {
+	target := models.Checkout{Id: "a-target", Products: []string{"PEN"}, Owner: "jwt:a-shopper"}
+	source := models.Checkout{Id: "a-source", Products: []string{"PEN", "MUG"}, Owner: "jwt:a-shopper", CustomerId: "a-customer"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", target.Id).Return(target, true)
+	theCheckoutRepositoryMock.On("SearchById", source.Id).Return(source, true)
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), mock.AnythingOfType("string")).Return(true)
+	theCheckoutRepositoryMock.On("DeleteIfStatus", source, mock.AnythingOfType("string")).Return(true)
+	mergeCheckouts := mergeCheckoutsWith(&theCheckoutRepositoryMock)
+
+	merged, err := mergeCheckouts.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.MergeCheckouts{SourceId: source.Id}, target.Id)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "a-target", merged.Checkout.Id)
+	assert.EqualValues(t, []string{"PEN", "PEN", "MUG"}, merged.Checkout.Products)
+	assert.EqualValues(t, "a-customer", merged.Checkout.CustomerId)
+	assert.Len(t, merged.Lines, 2)
+	assert.EqualValues(t, 1750, merged.Subtotal)
+	assert.EqualValues(t, 1250, merged.Amount)
+	theCheckoutRepositoryMock.AssertCalled(t, "PersistIfStatus", merged.Checkout, mock.Anything)
+	theCheckoutRepositoryMock.AssertCalled(t, "DeleteIfStatus", source, mock.Anything)
+}
+
+func TestMergeCheckoutsRestoresTheSourceWhenTheTargetIsModifiedMeanwhile(t *testing.T) {
+	target := models.Checkout{Id: "a-target", Products: []string{"PEN"}, Owner: "jwt:a-shopper", Status: models.CheckoutStatusOpen}
+	source := models.Checkout{Id: "a-source", Products: []string{"MUG"}, Owner: "jwt:a-shopper", Status: models.CheckoutStatusOpen}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", target.Id).Return(target, true)
+	theCheckoutRepositoryMock.On("SearchById", source.Id).Return(source, true)
+	theCheckoutRepositoryMock.On("DeleteIfStatus", source, models.CheckoutStatusOpen).Return(true)
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), models.CheckoutStatusOpen).Return(false)
+	theCheckoutRepositoryMock.On("Persist", source)
+	mergeCheckouts := mergeCheckoutsWith(&theCheckoutRepositoryMock)
+
+	_, err := mergeCheckouts.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.MergeCheckouts{SourceId: source.Id}, target.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrCheckoutModified))
+	theCheckoutRepositoryMock.AssertCalled(t, "Persist", source)
+}
+
+func TestMergeCheckoutsKeepsThePointsRedeemedOnTheSource(t *testing.T) {
+	target := models.Checkout{Id: "a-target", Products: []string{"TSHIRT"}, Owner: "jwt:a-shopper", CustomerId: "a-customer", RedeemedPoints: 100}
+	source := models.Checkout{Id: "a-source", Products: []string{"MUG"}, Owner: "jwt:a-shopper", CustomerId: "a-customer", RedeemedPoints: 200}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", target.Id).Return(target, true)
+	theCheckoutRepositoryMock.On("SearchById", source.Id).Return(source, true)
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), mock.AnythingOfType("string")).Return(true)
+	theCheckoutRepositoryMock.On("DeleteIfStatus", source, mock.AnythingOfType("string")).Return(true)
+	mergeCheckouts := mergeCheckoutsWith(&theCheckoutRepositoryMock)
+
+	merged, err := mergeCheckouts.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.MergeCheckouts{SourceId: source.Id}, target.Id)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 300, merged.Checkout.RedeemedPoints)
+	theCheckoutRepositoryMock.AssertCalled(t, "PersistIfStatus", merged.Checkout, mock.Anything)
+}
+
+func TestReturnCheckoutAttachedErrorWhenMergingCheckoutsOfDifferentCustomers(t *testing.T) {
+	target := models.Checkout{Id: "a-target", Products: []string{"PEN"}, Owner: "jwt:a-shopper", CustomerId: "a-customer"}
+	source := models.Checkout{Id: "a-source", Products: []string{"MUG"}, Owner: "jwt:a-shopper", CustomerId: "another-customer", RedeemedPoints: 200}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", target.Id).Return(target, true)
+	theCheckoutRepositoryMock.On("SearchById", source.Id).Return(source, true)
+	mergeCheckouts := mergeCheckoutsWith(&theCheckoutRepositoryMock)
+
+	_, err := mergeCheckouts.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.MergeCheckouts{SourceId: source.Id}, target.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrCheckoutAttached))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "PersistIfStatus", mock.Anything, mock.Anything)
+	theCheckoutRepositoryMock.AssertNotCalled(t, "DeleteIfStatus", mock.Anything, mock.Anything)
+}
+
+func TestReturnQuantityLimitExceededErrorWhenMergedCheckoutExceedsTheLimit(t *testing.T) {
+	target := models.Checkout{Id: "a-target", Products: appendProductUnits(nil, commands.Line{ProductCode: "PEN", Quantity: 98})}
+	source := models.Checkout{Id: "a-source", Products: []string{"PEN", "PEN"}}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", target.Id).Return(target, true)
+	theCheckoutRepositoryMock.On("SearchById", source.Id).Return(source, true)
+	mergeCheckouts := mergeCheckoutsWith(&theCheckoutRepositoryMock)
+
+	_, err := mergeCheckouts.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.MergeCheckouts{SourceId: source.Id}, target.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrQuantityLimitExceeded))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "PersistIfStatus", mock.Anything, mock.Anything)
+	theCheckoutRepositoryMock.AssertNotCalled(t, "DeleteIfStatus", mock.Anything, mock.Anything)
+}
+
+func TestReturnProductNotFoundErrorWhenSourceHasProductNoLongerSold(t *testing.T) {
+	target := models.Checkout{Id: "a-target"}
+	source := models.Checkout{Id: "a-source", Products: []string{"FAKE"}}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", target.Id).Return(target, true)
+	theCheckoutRepositoryMock.On("SearchById", source.Id).Return(source, true)
+	mergeCheckouts := mergeCheckoutsWith(&theCheckoutRepositoryMock)
+
+	_, err := mergeCheckouts.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.MergeCheckouts{SourceId: source.Id}, target.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrProductNotFound))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "DeleteIfStatus", mock.Anything, mock.Anything)
+}
+
+func TestReturnValidationErrorWhenMergingCheckoutIntoItself(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	mergeCheckouts := mergeCheckoutsWith(&theCheckoutRepositoryMock)
+
+	_, err := mergeCheckouts.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.MergeCheckouts{SourceId: "a-checkout"}, "a-checkout")
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrValidation))
+	assert.Empty(t, theCheckoutRepositoryMock.Calls)
+}
+
+func TestReturnCheckoutNotFoundErrorWhenSourceDoesNotExist(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", "a-target").Return(models.Checkout{Id: "a-target"}, true)
+	theCheckoutRepositoryMock.On("SearchById", "a-source").Return(models.Checkout{}, false)
+	mergeCheckouts := mergeCheckoutsWith(&theCheckoutRepositoryMock)
+
+	_, err := mergeCheckouts.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.MergeCheckouts{SourceId: "a-source"}, "a-target")
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrCheckoutNotFound))
+}
+
+func TestReturnForbiddenErrorWhenSourceBelongsToAnotherOwner(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", "a-target").Return(models.Checkout{Id: "a-target", Owner: "jwt:a-shopper"}, true)
+	theCheckoutRepositoryMock.On("SearchById", "a-source").Return(models.Checkout{Id: "a-source", Owner: "jwt:another-shopper"}, true)
+	mergeCheckouts := mergeCheckoutsWith(&theCheckoutRepositoryMock)
+
+	_, err := mergeCheckouts.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.MergeCheckouts{SourceId: "a-source"}, "a-target")
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrForbidden))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "DeleteIfStatus", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/auth"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/errors"
+)
+
+// ownerOf returns the id of the principal serving the request, owner of the
+// checkouts it creates, or an empty string when it is not authenticated.
+func ownerOf(ctx context.Context) string {
+	if principal, authenticated := auth.FromContext(ctx); authenticated {
+		return principal.Id()
+	}
+	return ""
+}
+
+// checkOwner rejects the access to a checkout bound to another owner, unless
+// the policy allows the request to access any checkout. Checkouts without
+// owner, created without authentication, are shared.
+func checkOwner(ctx context.Context, checkout models.Checkout) error {
+	if checkout.Owner != "" && checkout.Owner != ownerOf(ctx) && !policy.AllowedAnyCheckout(ctx) {
+		return errors.NewCheckoutForbiddenError(checkout.Id)
+	}
+	return nil
+}
+
+// checkOpen rejects changing or deleting a checkout once completed.
+// Checkouts without status are open.
+func checkOpen(checkout models.Checkout) error {
+	if checkout.Status != "" && checkout.Status != models.CheckoutStatusOpen {
+		return errors.NewCheckoutNotOpenError(checkout.Id, checkout.Status)
+	}
+	return nil
+}
+
+// refusedWriteError explains why a conditional write of the checkout was
+// refused: it was completed or deleted meanwhile, or another request changed
+// it since it was read.
+func refusedWriteError(ctx context.Context, checkoutRepository persistence.CheckoutRepository, checkoutId string) error {
+	current, exists := checkoutRepository.SearchById(ctx, checkoutId)
+	if !exists {
+		return errors.NewCheckoutNotFoundError(checkoutId)
+	}
+	if err := checkOpen(current); err != nil {
+		return err
+	}
+	return errors.NewCheckoutModifiedError(checkoutId)
+}
+
+// checkSavedListOwner rejects the access to a list saved by another owner,
+// unless the policy allows the request to access any checkout.
+func checkSavedListOwner(ctx context.Context, savedList models.SavedList) error {
+	if savedList.Owner != "" && savedList.Owner != ownerOf(ctx) && !policy.AllowedAnyCheckout(ctx) {
+		return errors.NewSavedListForbiddenError(savedList.Id)
+	}
+	return nil
+}
+
+// checkCustomerOwner rejects the access to a customer registered by another
+// owner, unless the policy allows the request to access any customer.
+func checkCustomerOwner(ctx context.Context, customer models.Customer) error {
+	if customer.Owner != "" && customer.Owner != ownerOf(ctx) && !policy.AllowedAnyCustomer(ctx) {
+		return errors.NewCustomerForbiddenError(customer.Id)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/auth"
+	"lana/flagship-store/models"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func principalContext(role auth.Role, subject string) context.Context {
+	return auth.NewContext(context.Background(), auth.Principal{Subject: subject, Method: auth.MethodJWT, Role: role})
+}
+
+// trustedContext is the context of an internal call, authorized whatever
+// the command.
+func trustedContext() context.Context {
+	return policy.WithTrustedCaller(context.Background())
+}
+
+func TestCheckOwnerAllowsTheOwnerAndSharedCheckouts(t *testing.T) {
+	ctx := principalContext(auth.RoleShopper, "a-shopper")
+
+	assert.Nil(t, checkOwner(ctx, models.Checkout{Id: "a", Owner: "jwt:a-shopper"}))
+	assert.Nil(t, checkOwner(ctx, models.Checkout{Id: "b"}))
+}
+
+func TestCheckOwnerRejectsShoppersFromCheckoutsOfAnotherOwner(t *testing.T) {
+	err := checkOwner(principalContext(auth.RoleShopper, "a-shopper"), models.Checkout{Id: "a", Owner: "jwt:another-shopper"})
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrForbidden))
+}
+
+func TestCheckOwnerAllowsRolesAccessingAnyCheckout(t *testing.T) {
+	checkout := models.Checkout{Id: "a", Owner: "jwt:a-shopper"}
+
+	assert.Nil(t, checkOwner(principalContext(auth.RoleMerchandiser, "a-merchandiser"), checkout))
+	assert.Nil(t, checkOwner(principalContext(auth.RoleAdmin, "an-admin"), checkout))
+	assert.Nil(t, checkOwner(trustedContext(), checkout))
+	assert.EqualValues(t, true, errors.Is(checkOwner(context.Background(), checkout), errors.ErrForbidden))
+}
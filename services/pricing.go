@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Pricing resolves the prices negotiated with the customer of a checkout
+// from the price lists. The zero Pricing keeps the catalog prices.
+type Pricing struct {
+	CustomerRepository  persistence.CustomerRepository
+	PriceListRepository persistence.PriceListRepository
+}
+
+func NewPricing(customerRepository persistence.CustomerRepository, priceListRepository persistence.PriceListRepository) Pricing {
+	return Pricing{customerRepository, priceListRepository}
+}
+
+// productRepositoryOf returns the products with the effective prices of the
+// customer of the checkout, so the promotions and discounts apply to them.
+func (pricing Pricing) productRepositoryOf(ctx context.Context, checkout models.Checkout, productRepository persistence.ProductRepository) persistence.ProductRepository {
+	prices := pricing.pricesOf(ctx, checkout.CustomerId)
+	if len(prices) == 0 {
+		return productRepository
+	}
+	return persistence.NewPricedProductRepository(productRepository, prices)
+}
+
+// pricesOf resolves the prices of the customer. A price of its own price
+// lists overrides the price of the price lists of its group and, when many
+// lists of the customer or of the group price a product, the lowest wins.
+func (pricing Pricing) pricesOf(ctx context.Context, customerId string) map[string]int {
+	if customerId == "" || pricing.CustomerRepository == nil || pricing.PriceListRepository == nil {
+		return nil
+	}
+	ctx, span := tracing.Start(ctx, "pricing.ResolvePrices", attribute.String("customer.id", customerId))
+	defer span.End()
+
+	customer, existCustomer := pricing.CustomerRepository.SearchById(ctx, customerId)
+	if !existCustomer {
+		return nil
+	}
+
+	customerPrices := map[string]int{}
+	groupPrices := map[string]int{}
+	for _, priceList := range pricing.PriceListRepository.SearchFor(ctx, customer.Id, customer.Group) {
+		prices := groupPrices
+		if priceList.CustomerId == customer.Id {
+			prices = customerPrices
+		}
+		for productCode, price := range priceList.Prices {
+			if lowest, priced := prices[productCode]; !priced || price < lowest {
+				prices[productCode] = price
+			}
+		}
+	}
+	for productCode, price := range customerPrices {
+		groupPrices[productCode] = price
+	}
+	span.SetAttributes(attribute.Int("prices.count", len(groupPrices)))
+	return groupPrices
+}
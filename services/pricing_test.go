@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolvePricesOfTheCustomerOverTheOnesOfItsGroup(t *testing.T) {
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("SearchById", "a-customer").Return(models.Customer{Id: "a-customer", Group: "wholesale"}, true)
+	thePriceListRepositoryMock := mocks.PriceListRepositoryMock{}
+	thePriceListRepositoryMock.On("SearchFor", "a-customer", "wholesale").Return([]models.PriceList{
+		{Id: "a-group-price-list", Group: "wholesale", Prices: map[string]int{"MUG": 600, "PEN": 450}},
+		{Id: "another-group-price-list", Group: "wholesale", Prices: map[string]int{"MUG": 550}},
+		{Id: "a-price-list", CustomerId: "a-customer", Prices: map[string]int{"MUG": 650}},
+	})
+	pricing := Pricing{&theCustomerRepositoryMock, &thePriceListRepositoryMock}
+
+	prices := pricing.pricesOf(context.Background(), "a-customer")
+
+	assert.EqualValues(t, map[string]int{"MUG": 650, "PEN": 450}, prices)
+}
+
+func TestResolveNoPricesWithoutCustomerOrPriceLists(t *testing.T) {
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("SearchById", "a-missing-customer").Return(models.Customer{}, false)
+	pricing := Pricing{&theCustomerRepositoryMock, &mocks.PriceListRepositoryMock{}}
+
+	assert.Empty(t, pricing.pricesOf(context.Background(), ""))
+	assert.Empty(t, pricing.pricesOf(context.Background(), "a-missing-customer"))
+	assert.Empty(t, Pricing{}.pricesOf(context.Background(), "a-customer"))
+}
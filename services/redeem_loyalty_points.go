@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/tracing"
+	"time"
+)
+
+type RedeemLoyaltyPoints struct {
+	CheckoutRepository persistence.CheckoutRepository
+	LoyaltyRepository  persistence.LoyaltyRepository
+}
+
+func NewRedeemLoyaltyPoints(checkoutRepository persistence.CheckoutRepository, loyaltyRepository persistence.LoyaltyRepository) RedeemLoyaltyPoints {
+	return RedeemLoyaltyPoints{checkoutRepository, loyaltyRepository}
+}
+
+// Do redeems points of the customer of the checkout as a discount on its
+// amount. The points are only taken from the balance of the customer when
+// the checkout is completed, so they must still be there by then.
+func (service *RedeemLoyaltyPoints) Do(ctx context.Context, redeemCommand commands.RedeemPoints, checkoutId string) (models.Checkout, error) {
+	ctx, span := tracing.Start(ctx, "services.RedeemLoyaltyPoints")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.RedeemLoyaltyPoints); err != nil {
+		return models.Checkout{}, err
+	}
+
+	if err := redeemCommand.Validate(); err != nil {
+		return models.Checkout{}, err
+	}
+
+	checkout, existCheckout := service.CheckoutRepository.SearchById(ctx, checkoutId)
+	if !existCheckout {
+		return models.Checkout{}, errors.NewCheckoutNotFoundError(checkoutId)
+	}
+	if err := checkOwner(ctx, checkout); err != nil {
+		return models.Checkout{}, err
+	}
+	if err := checkOpen(checkout); err != nil {
+		return models.Checkout{}, err
+	}
+	if checkout.CustomerId == "" {
+		return models.Checkout{}, errors.NewGuestCheckoutError(checkoutId)
+	}
+
+	if err := checkBalance(ctx, service.LoyaltyRepository, checkout.CustomerId, redeemCommand.Points); err != nil {
+		return models.Checkout{}, err
+	}
+
+	checkout.RedeemedPoints = redeemCommand.Points
+	checkout.UpdatedAt = time.Now()
+	if err := checkContext(ctx); err != nil {
+		return models.Checkout{}, err
+	}
+	if !service.CheckoutRepository.PersistIfStatus(ctx, checkout, checkout.Status) {
+		return models.Checkout{}, refusedWriteError(ctx, service.CheckoutRepository, checkout.Id)
+	}
+
+	return checkout, nil
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"lana/flagship-store/auth"
+	"lana/flagship-store/models"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRedeemLoyaltyPointsOnTheCheckoutOfACustomer(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", Products: []string{"MUG"}, Status: models.CheckoutStatusOpen, Owner: "jwt:a-shopper", CustomerId: "a-customer"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), models.CheckoutStatusOpen).Return(true)
+	theLoyaltyRepositoryMock := mocks.LoyaltyRepositoryMock{}
+	theLoyaltyRepositoryMock.On("Balance", "a-customer").Return(300)
+	redeemPoints := RedeemLoyaltyPoints{&theCheckoutRepositoryMock, &theLoyaltyRepositoryMock}
+
+	redeemed, err := redeemPoints.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.RedeemPoints{Points: 200}, checkout.Id)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 200, redeemed.RedeemedPoints)
+	theCheckoutRepositoryMock.AssertCalled(t, "PersistIfStatus", redeemed, models.CheckoutStatusOpen)
+	theLoyaltyRepositoryMock.AssertNotCalled(t, "Redeem", mock.Anything)
+}
+
+func TestReturnInsufficientPointsErrorWhenRedeemingMoreThanTheBalance(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", Status: models.CheckoutStatusOpen, Owner: "jwt:a-shopper", CustomerId: "a-customer"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theLoyaltyRepositoryMock := mocks.LoyaltyRepositoryMock{}
+	theLoyaltyRepositoryMock.On("Balance", "a-customer").Return(100)
+	redeemPoints := RedeemLoyaltyPoints{&theCheckoutRepositoryMock, &theLoyaltyRepositoryMock}
+
+	_, err := redeemPoints.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.RedeemPoints{Points: 200}, checkout.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrInsufficientPoints))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "PersistIfStatus", mock.Anything, mock.Anything)
+}
+
+func TestReturnGuestCheckoutErrorWhenRedeemingPointsOnACheckoutWithoutCustomer(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", Status: models.CheckoutStatusOpen, Owner: "jwt:a-shopper"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	redeemPoints := RedeemLoyaltyPoints{&theCheckoutRepositoryMock, &mocks.LoyaltyRepositoryMock{}}
+
+	_, err := redeemPoints.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.RedeemPoints{Points: 200}, checkout.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrGuestCheckout))
+}
+
+func TestReturnCheckoutNotOpenErrorWhenRedeemingPointsOnACompletedCheckout(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", Status: models.CheckoutStatusCompleted, Owner: "jwt:a-shopper", CustomerId: "a-customer"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	redeemPoints := RedeemLoyaltyPoints{&theCheckoutRepositoryMock, &mocks.LoyaltyRepositoryMock{}}
+
+	_, err := redeemPoints.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.RedeemPoints{Points: 200}, checkout.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrCheckoutNotOpen))
+}
+
+func TestReturnCheckoutNotOpenErrorWhenTheCheckoutIsCompletedWhileRedeeming(t *testing.T) {
+	checkout := models.Checkout{Id: "a-checkout", Status: models.CheckoutStatusOpen, Owner: "jwt:a-shopper", CustomerId: "a-customer"}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	completed := checkout
+	completed.Status = models.CheckoutStatusCompleted
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true).Once()
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(completed, true)
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), models.CheckoutStatusOpen).Return(false)
+	theLoyaltyRepositoryMock := mocks.LoyaltyRepositoryMock{}
+	theLoyaltyRepositoryMock.On("Balance", "a-customer").Return(300)
+	redeemPoints := RedeemLoyaltyPoints{&theCheckoutRepositoryMock, &theLoyaltyRepositoryMock}
+
+	_, err := redeemPoints.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.RedeemPoints{Points: 200}, checkout.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrCheckoutNotOpen))
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/tracing"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RegisterCustomer struct {
+	CustomerRepository persistence.CustomerRepository
+}
+
+func NewRegisterCustomer(customerRepository persistence.CustomerRepository) RegisterCustomer {
+	return RegisterCustomer{customerRepository}
+}
+
+// Do registers a customer of the caller. Emails are compared lower case, so
+// a customer can not be registered twice with the same address. The group,
+// which grants the prices of its price lists, is only set by the callers
+// managing the price lists.
+func (service *RegisterCustomer) Do(ctx context.Context, registerCommand commands.RegisterCustomer) (models.Customer, error) {
+	ctx, span := tracing.Start(ctx, "services.RegisterCustomer")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.RegisterCustomer); err != nil {
+		return models.Customer{}, err
+	}
+
+	if err := registerCommand.Validate(); err != nil {
+		return models.Customer{}, err
+	}
+	if registerCommand.Group != "" {
+		if err := policy.Authorize(ctx, policy.ManagePriceLists); err != nil {
+			return models.Customer{}, err
+		}
+	}
+
+	email := strings.ToLower(strings.TrimSpace(registerCommand.Email))
+	customer := models.Customer{
+		Id:        uuid.NewString(),
+		Email:     email,
+		Name:      strings.TrimSpace(registerCommand.Name),
+		Group:     registerCommand.Group,
+		Owner:     ownerOf(ctx),
+		CreatedAt: time.Now(),
+	}
+	if err := checkContext(ctx); err != nil {
+		return models.Customer{}, err
+	}
+	if !service.CustomerRepository.PersistIfEmailAbsent(ctx, customer) {
+		return models.Customer{}, errors.NewCustomerAlreadyExistsError(email)
+	}
+
+	return customer, nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"lana/flagship-store/auth"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRegisterCustomerOfTheCaller(t *testing.T) {
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("PersistIfEmailAbsent", mock.AnythingOfType("models.Customer")).Return(true)
+	registerCustomer := RegisterCustomer{&theCustomerRepositoryMock}
+
+	customer, err := registerCustomer.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.RegisterCustomer{Email: " Ada@Example.com", Name: "Ada "})
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, customer.Id)
+	assert.EqualValues(t, "ada@example.com", customer.Email)
+	assert.EqualValues(t, "Ada", customer.Name)
+	assert.EqualValues(t, "jwt:a-shopper", customer.Owner)
+	theCustomerRepositoryMock.AssertNumberOfCalls(t, "PersistIfEmailAbsent", 1)
+}
+
+func TestReturnCustomerAlreadyExistsErrorWhenEmailIsRegistered(t *testing.T) {
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("PersistIfEmailAbsent", mock.AnythingOfType("models.Customer")).Return(false)
+	registerCustomer := RegisterCustomer{&theCustomerRepositoryMock}
+
+	_, err := registerCustomer.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.RegisterCustomer{Email: "ada@example.com", Name: "Ada"})
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrCustomerAlreadyExists))
+}
+
+func TestReturnValidationErrorWhenCustomerHasNoEmail(t *testing.T) {
+	registerCustomer := RegisterCustomer{&mocks.CustomerRepositoryMock{}}
+
+	_, err := registerCustomer.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.RegisterCustomer{Name: "Ada"})
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrValidation))
+}
+
+func TestReturnForbiddenErrorWhenMerchandiserRegistersCustomer(t *testing.T) {
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	registerCustomer := RegisterCustomer{&theCustomerRepositoryMock}
+
+	_, err := registerCustomer.Do(principalContext(auth.RoleMerchandiser, "crm"), commands.RegisterCustomer{Email: "ada@example.com", Name: "Ada"})
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrForbidden))
+	assert.Empty(t, theCustomerRepositoryMock.Calls)
+}
+
+func TestRegisterCustomerOfAGroupByAnAdmin(t *testing.T) {
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("PersistIfEmailAbsent", mock.AnythingOfType("models.Customer")).Return(true)
+	registerCustomer := RegisterCustomer{&theCustomerRepositoryMock}
+
+	customer, err := registerCustomer.Do(principalContext(auth.RoleAdmin, "an-admin"), commands.RegisterCustomer{Email: "ada@example.com", Name: "Ada", Group: "wholesale"})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "wholesale", customer.Group)
+}
+
+func TestReturnForbiddenErrorWhenShopperRegistersCustomerOfAGroup(t *testing.T) {
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	registerCustomer := RegisterCustomer{&theCustomerRepositoryMock}
+
+	_, err := registerCustomer.Do(principalContext(auth.RoleShopper, "a-shopper"), commands.RegisterCustomer{Email: "ada@example.com", Name: "Ada", Group: "wholesale"})
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrForbidden))
+	theCustomerRepositoryMock.AssertNotCalled(t, "PersistIfEmailAbsent", mock.Anything)
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/tracing"
+	"time"
+)
+
+type RemoveProductFromCheckout struct {
+	CheckoutRepository persistence.CheckoutRepository
+}
+
+func NewRemoveProductFromCheckout(checkoutRepository persistence.CheckoutRepository) RemoveProductFromCheckout {
+	return RemoveProductFromCheckout{checkoutRepository}
+}
+
+// Do removes the units of the line from the checkout, or every unit of the
+// product when the checkout has fewer.
+func (service *RemoveProductFromCheckout) Do(ctx context.Context, lineCommand commands.Line, checkoutId string) (models.Checkout, error) {
+	ctx, span := tracing.Start(ctx, "services.RemoveProductFromCheckout")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.RemoveProductFromCheckout); err != nil {
+		return models.Checkout{}, err
+	}
+
+	if err := lineCommand.Validate(); err != nil {
+		return models.Checkout{}, err
+	}
+
+	checkout, existCheckout := service.CheckoutRepository.SearchById(ctx, checkoutId)
+	if !existCheckout {
+		return models.Checkout{}, errors.NewCheckoutNotFoundError(checkoutId)
+	}
+	if err := checkOwner(ctx, checkout); err != nil {
+		return models.Checkout{}, err
+	}
+	if err := checkOpen(checkout); err != nil {
+		return models.Checkout{}, err
+	}
+
+	if countProductUnits(checkout.Products, lineCommand.ProductCode) == 0 {
+		return models.Checkout{}, errors.NewProductNotInCheckoutError(checkoutId, lineCommand.ProductCode)
+	}
+
+	checkout.Products = removeProductUnits(checkout.Products, lineCommand)
+	checkout.UpdatedAt = time.Now()
+	if err := checkContext(ctx); err != nil {
+		return models.Checkout{}, err
+	}
+	if !service.CheckoutRepository.PersistIfStatus(ctx, checkout, checkout.Status) {
+		return models.Checkout{}, refusedWriteError(ctx, service.CheckoutRepository, checkout.Id)
+	}
+
+	return checkout, nil
+}
+
+func removeProductUnits(products []string, line commands.Line) []string {
+	remainingProducts := []string{}
+	unitsToRemove := line.Quantity
+	for i := len(products) - 1; i >= 0; i-- {
+		if products[i] == line.ProductCode && unitsToRemove > 0 {
+			unitsToRemove--
+			continue
+		}
+		remainingProducts = append([]string{products[i]}, remainingProducts...)
+	}
+	return remainingProducts
+}
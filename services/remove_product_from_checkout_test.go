@@ -0,0 +1,83 @@
+package services
+
+import (
+	"lana/flagship-store/models"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRemoveProductFromCheckout(t *testing.T) {
+	checkout := models.Checkout{
+		Id:       uuid.NewString(),
+		Products: []string{"PEN", "MUG", "PEN", "PEN"},
+	}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), mock.AnythingOfType("string")).Return(true)
+	lineCommand := commands.Line{ProductCode: "PEN", Quantity: 2}
+	removeProductFromCheckout := RemoveProductFromCheckout{&theCheckoutRepositoryMock}
+
+	modifiedCheckout, err := removeProductFromCheckout.Do(trustedContext(), lineCommand, checkout.Id)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"PEN", "MUG"}, modifiedCheckout.Products)
+	theCheckoutRepositoryMock.AssertNumberOfCalls(t, "PersistIfStatus", 1)
+	theCheckoutRepositoryMock.AssertExpectations(t)
+}
+
+func TestRemoveProductRemoveEveryUnitWhenQuantityIsGreaterThanCheckoutUnits(t *testing.T) {
+	checkout := models.Checkout{
+		Id:       uuid.NewString(),
+		Products: []string{"PEN", "MUG"},
+	}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCheckoutRepositoryMock.On("PersistIfStatus", mock.AnythingOfType("models.Checkout"), mock.AnythingOfType("string")).Return(true)
+	lineCommand := commands.Line{ProductCode: "PEN", Quantity: 5}
+	removeProductFromCheckout := RemoveProductFromCheckout{&theCheckoutRepositoryMock}
+
+	modifiedCheckout, _ := removeProductFromCheckout.Do(trustedContext(), lineCommand, checkout.Id)
+
+	assert.EqualValues(t, []string{"MUG"}, modifiedCheckout.Products)
+}
+
+func TestRemoveProductReturnCheckoutNotFoundErrorWhenCheckoutDoesnotExists(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", "a_fake_id").Return(models.Checkout{}, false)
+	removeProductFromCheckout := RemoveProductFromCheckout{&theCheckoutRepositoryMock}
+
+	_, err := removeProductFromCheckout.Do(trustedContext(), commands.Line{ProductCode: "PEN", Quantity: 1}, "a_fake_id")
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrCheckoutNotFound))
+}
+
+func TestRemoveProductReturnProductNotInCheckoutErrorWhenCheckoutHasNoUnits(t *testing.T) {
+	checkout := models.Checkout{
+		Id:       uuid.NewString(),
+		Products: []string{"MUG"},
+	}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	removeProductFromCheckout := RemoveProductFromCheckout{&theCheckoutRepositoryMock}
+
+	_, err := removeProductFromCheckout.Do(trustedContext(), commands.Line{ProductCode: "PEN", Quantity: 1}, checkout.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrProductNotInCheckout))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "PersistIfStatus", mock.Anything, mock.Anything)
+}
+
+func TestRemoveProductReturnValidationErrorWhenQuantityIsZero(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	removeProductFromCheckout := RemoveProductFromCheckout{&theCheckoutRepositoryMock}
+
+	_, err := removeProductFromCheckout.Do(trustedContext(), commands.Line{ProductCode: "PEN"}, "an_id")
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrValidation))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "SearchById", mock.Anything)
+}
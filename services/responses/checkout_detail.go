@@ -0,0 +1,25 @@
+package responses
+
+import "time"
+
+type CheckoutDetail struct {
+	Id             string         `json:"id"`
+	Status         string         `json:"status"`
+	CustomerId     string         `json:"customer-id,omitempty"`
+	Lines          []CheckoutLine `json:"lines"`
+	Subtotal       string         `json:"subtotal"`
+	Discount       string         `json:"discount"`
+	RedeemedPoints int            `json:"redeemed-points,omitempty"`
+	PointsDiscount string         `json:"points-discount,omitempty"`
+	Amount         string         `json:"amount"`
+	CreatedAt      time.Time      `json:"created-at"`
+	UpdatedAt      time.Time      `json:"updated-at"`
+}
+
+type CheckoutLine struct {
+	ProductCode string `json:"product-code"`
+	ProductName string `json:"product-name"`
+	UnitPrice   string `json:"unit-price"`
+	Quantity    int    `json:"quantity"`
+	Amount      string `json:"amount"`
+}
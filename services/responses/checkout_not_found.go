@@ -1,5 +0,0 @@
-package responses
-
-type CheckoutNotFound struct {
-	Message string `json:"message"`
-}
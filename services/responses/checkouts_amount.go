@@ -0,0 +1,16 @@
+package responses
+
+type CheckoutsAmount struct {
+	Amounts []CheckoutAmount `json:"amounts"`
+	Errors  []CheckoutError  `json:"errors"`
+}
+
+type CheckoutAmount struct {
+	Id     string `json:"id"`
+	Amount string `json:"amount"`
+}
+
+type CheckoutError struct {
+	Id string `json:"id"`
+	Problem
+}
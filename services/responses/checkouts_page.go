@@ -0,0 +1,8 @@
+package responses
+
+import "lana/flagship-store/models"
+
+type CheckoutsPage struct {
+	Checkouts  []models.Checkout `json:"checkouts"`
+	NextCursor string            `json:"next-cursor,omitempty"`
+}
@@ -0,0 +1,7 @@
+package responses
+
+import "lana/flagship-store/models"
+
+type PriceLists struct {
+	PriceLists []models.PriceList `json:"price-lists"`
+}
@@ -0,0 +1,13 @@
+package responses
+
+// Problem is an RFC 7807 problem details object, extended with the
+// service error code and its details.
+type Problem struct {
+	Type     string                 `json:"type"`
+	Title    string                 `json:"title"`
+	Status   int                    `json:"status"`
+	Detail   string                 `json:"detail,omitempty"`
+	Instance string                 `json:"instance,omitempty"`
+	Code     string                 `json:"code"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+}
@@ -1,5 +0,0 @@
-package responses
-
-type ProductNotFound struct {
-	Message string `json:"message"`
-}
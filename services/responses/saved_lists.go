@@ -0,0 +1,14 @@
+package responses
+
+import "lana/flagship-store/models"
+
+type SavedLists struct {
+	SavedLists []models.SavedList `json:"saved-lists"`
+}
+
+// SavedListCheckout is the checkout created from a saved list, with the
+// codes of the products of the list no longer sold.
+type SavedListCheckout struct {
+	Checkout            models.Checkout `json:"checkout"`
+	UnavailableProducts []string        `json:"unavailable-products"`
+}
@@ -0,0 +1,6 @@
+package responses
+
+type Version struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/tracing"
+	"sort"
+)
+
+type RetrieveCheckout struct {
+	CheckoutRepository             persistence.CheckoutRepository
+	ProductRepository              persistence.ProductRepository
+	ProductWithPromotionRepository persistence.ProductRepository
+	ProductWithDiscountRepository  persistence.ProductRepository
+	Pricing                        Pricing
+}
+
+func NewRetrieveCheckout(checkoutRepository persistence.CheckoutRepository, productRepository persistence.ProductRepository, productWithPromotionRepository persistence.ProductRepository, productWithDiscountRepository persistence.ProductRepository) RetrieveCheckout {
+	return RetrieveCheckout{CheckoutRepository: checkoutRepository, ProductRepository: productRepository, ProductWithPromotionRepository: productWithPromotionRepository, ProductWithDiscountRepository: productWithDiscountRepository}
+}
+
+func (service *RetrieveCheckout) Do(ctx context.Context, checkoutId string) (models.CheckoutSummary, error) {
+	ctx, span := tracing.Start(ctx, "services.RetrieveCheckout")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.RetrieveCheckout); err != nil {
+		return models.CheckoutSummary{}, err
+	}
+
+	if err := checkContext(ctx); err != nil {
+		return models.CheckoutSummary{}, err
+	}
+
+	checkout, existCheckout := service.CheckoutRepository.SearchById(ctx, checkoutId)
+	if !existCheckout {
+		return models.CheckoutSummary{}, errors.NewCheckoutNotFoundError(checkoutId)
+	}
+	if err := checkOwner(ctx, checkout); err != nil {
+		return models.CheckoutSummary{}, err
+	}
+
+	return summarizeCheckout(ctx, checkout, service.Pricing.productRepositoryOf(ctx, checkout, service.ProductRepository), service.ProductWithPromotionRepository, service.ProductWithDiscountRepository), nil
+}
+
+// Summarize calculates the lines and totals of checkouts already read, e.g.
+// a page of checkouts listed, without reading them again and reading every
+// product once for all of them.
+func (service *RetrieveCheckout) Summarize(ctx context.Context, checkouts []models.Checkout) ([]models.CheckoutSummary, error) {
+	ctx, span := tracing.Start(ctx, "services.RetrieveCheckout.Summarize")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.RetrieveCheckout); err != nil {
+		return nil, err
+	}
+
+	productRepository := persistence.NewCachedProductRepository(service.ProductRepository)
+	productWithPromotionRepository := persistence.NewCachedProductRepository(service.ProductWithPromotionRepository)
+	productWithDiscountRepository := persistence.NewCachedProductRepository(service.ProductWithDiscountRepository)
+
+	summaries := make([]models.CheckoutSummary, 0, len(checkouts))
+	for _, checkout := range checkouts {
+		if err := checkContext(ctx); err != nil {
+			return nil, err
+		}
+		if err := checkOwner(ctx, checkout); err != nil {
+			return nil, err
+		}
+
+		summaries = append(summaries, summarizeCheckout(ctx, checkout, service.Pricing.productRepositoryOf(ctx, checkout, productRepository), productWithPromotionRepository, productWithDiscountRepository))
+	}
+	return summaries, nil
+}
+
+// summarizeCheckout calculates the lines of the checkout, sorted by product
+// code, and its totals, less the points redeemed.
+func summarizeCheckout(ctx context.Context, checkout models.Checkout, productsRepository persistence.ProductRepository, productsWithPromotionRepository persistence.ProductRepository, productsWithDiscountRepository persistence.ProductRepository) models.CheckoutSummary {
+	checkoutSummary := models.CheckoutSummary{
+		Checkout: checkout,
+		Lines:    []models.CheckoutLine{},
+	}
+	checkoutLines := calculateCheckoutLines(ctx, checkout.Products, productsRepository, productsWithPromotionRepository, productsWithDiscountRepository)
+	for _, checkoutLine := range checkoutLines {
+		if checkoutLine.Quantity == 0 {
+			continue
+		}
+		checkoutSummary.Lines = append(checkoutSummary.Lines, checkoutLine)
+		checkoutSummary.Subtotal += checkoutLine.Subtotal
+		checkoutSummary.Amount += checkoutLine.Amount
+	}
+	checkoutSummary.RedeemedPoints = redeemablePoints(checkout.RedeemedPoints, checkoutSummary.Amount)
+	checkoutSummary.PointsDiscount = checkoutSummary.RedeemedPoints * models.LoyaltyPointValue
+	checkoutSummary.Amount -= checkoutSummary.PointsDiscount
+	sort.Slice(checkoutSummary.Lines, func(i, j int) bool {
+		return checkoutSummary.Lines[i].Product.Code < checkoutSummary.Lines[j].Product.Code
+	})
+	return checkoutSummary
+}
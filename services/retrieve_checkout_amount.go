@@ -1,8 +1,14 @@
 package services
 
 import (
+	"context"
+	"lana/flagship-store/models"
 	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
 	"lana/flagship-store/services/errors"
+	"lana/flagship-store/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type RetrieveCheckoutAmount struct {
@@ -10,37 +16,72 @@ type RetrieveCheckoutAmount struct {
 	ProductRepository              persistence.ProductRepository
 	ProductWithPromotionRepository persistence.ProductRepository
 	ProductWithDiscountRepository  persistence.ProductRepository
+	Pricing                        Pricing
 }
 
 func NewRetrieveCheckoutAmount(checkoutRepository persistence.CheckoutRepository, productRepository persistence.ProductRepository, productWithPromotionRepository persistence.ProductRepository, productWithDiscountRepository persistence.ProductRepository) RetrieveCheckoutAmount {
-	return RetrieveCheckoutAmount{checkoutRepository, productRepository, productWithPromotionRepository, productWithDiscountRepository}
+	return RetrieveCheckoutAmount{CheckoutRepository: checkoutRepository, ProductRepository: productRepository, ProductWithPromotionRepository: productWithPromotionRepository, ProductWithDiscountRepository: productWithDiscountRepository}
 }
 
-func (service *RetrieveCheckoutAmount) Do(checkoutId string) (int, error) {
-	checkout, existCheckout := service.CheckoutRepository.SearchById(checkoutId)
+func (service *RetrieveCheckoutAmount) Do(ctx context.Context, checkoutId string) (int, error) {
+	ctx, span := tracing.Start(ctx, "services.RetrieveCheckoutAmount")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.RetrieveCheckoutAmount); err != nil {
+		return 0, err
+	}
+
+	if err := checkContext(ctx); err != nil {
+		return 0, err
+	}
+
+	checkout, existCheckout := service.CheckoutRepository.SearchById(ctx, checkoutId)
 	if !existCheckout {
-		return 0, errors.NewCheckoutNotFoundError()
+		return 0, errors.NewCheckoutNotFoundError(checkoutId)
+	}
+	if err := checkOwner(ctx, checkout); err != nil {
+		return 0, err
 	}
 
-	checkoutAmount := calculateCheckoutAmount(checkout.Products, service.ProductRepository, service.ProductWithPromotionRepository, service.ProductWithDiscountRepository)
+	checkoutAmount := calculateCheckoutAmount(ctx, checkout.Products, service.Pricing.productRepositoryOf(ctx, checkout, service.ProductRepository), service.ProductWithPromotionRepository, service.ProductWithDiscountRepository)
+	checkoutAmount -= redeemablePoints(checkout.RedeemedPoints, checkoutAmount) * models.LoyaltyPointValue
 
 	return checkoutAmount, nil
 }
 
-func calculateCheckoutAmount(checkoutProducts []string, productsRepository persistence.ProductRepository, productsWithPromotionRepository persistence.ProductRepository, productsWithDiscountRepository persistence.ProductRepository) int {
+func calculateCheckoutAmount(ctx context.Context, checkoutProducts []string, productsRepository persistence.ProductRepository, productsWithPromotionRepository persistence.ProductRepository, productsWithDiscountRepository persistence.ProductRepository) int {
+	var amount int
+	for _, checkoutLine := range calculateCheckoutLines(ctx, checkoutProducts, productsRepository, productsWithPromotionRepository, productsWithDiscountRepository) {
+		amount += checkoutLine.Amount
+	}
+	return amount
+}
+
+func calculateCheckoutLines(ctx context.Context, checkoutProducts []string, productsRepository persistence.ProductRepository, productsWithPromotionRepository persistence.ProductRepository, productsWithDiscountRepository persistence.ProductRepository) []models.CheckoutLine {
+	ctx, span := tracing.Start(ctx, "pricing.EvaluateRules", attribute.Int("checkout.units", len(checkoutProducts)))
+	defer span.End()
+
 	productRealUnits := calculateRealProductUnits(checkoutProducts)
-	productUnits := calculatePayableProductUnits(productRealUnits, productsWithPromotionRepository)
+	productUnits := calculatePayableProductUnits(ctx, productRealUnits, productsWithPromotionRepository)
 
-	var amount int
+	var checkoutLines []models.CheckoutLine
 	for productCode, quantity := range productUnits {
-		product, _ := productsRepository.SearchById(productCode)
-		if _, hasDiscount := productsWithDiscountRepository.SearchById(productCode); hasDiscount {
-			amount += calculateAmountWithDiscount(quantity, product.Price)
-			continue
+		product, _ := productsRepository.SearchById(ctx, productCode)
+		checkoutLine := models.CheckoutLine{
+			Product:  product,
+			Quantity: productRealUnits[productCode],
+			Subtotal: product.Price * productRealUnits[productCode],
+			Amount:   product.Price * quantity,
 		}
-		amount += (product.Price * quantity)
+		if _, hasDiscount := productsWithDiscountRepository.SearchById(ctx, productCode); hasDiscount {
+			_, ruleSpan := tracing.Start(ctx, "pricing.ApplyBulkDiscount", attribute.String("product.code", productCode), attribute.Int("product.units", quantity))
+			checkoutLine.Amount = calculateAmountWithDiscount(quantity, product.Price)
+			ruleSpan.SetAttributes(attribute.Int("line.amount", checkoutLine.Amount))
+			ruleSpan.End()
+		}
+		checkoutLines = append(checkoutLines, checkoutLine)
 	}
-	return amount
+	return checkoutLines
 }
 
 func calculateRealProductUnits(checkoutProducts []string) map[string]int {
@@ -51,11 +92,14 @@ func calculateRealProductUnits(checkoutProducts []string) map[string]int {
 	return productUnits
 }
 
-func calculatePayableProductUnits(productRealUnits map[string]int, productsWithPromotionRepository persistence.ProductRepository) map[string]int {
+func calculatePayableProductUnits(ctx context.Context, productRealUnits map[string]int, productsWithPromotionRepository persistence.ProductRepository) map[string]int {
 	var productUnits = map[string]int{"PEN": 0, "TSHIRT": 0, "MUG": 0}
 	for productCode, quantity := range productRealUnits {
-		if _, found := productsWithPromotionRepository.SearchById(productCode); found {
+		if _, found := productsWithPromotionRepository.SearchById(ctx, productCode); found {
+			_, ruleSpan := tracing.Start(ctx, "pricing.Apply2X1Promotion", attribute.String("product.code", productCode), attribute.Int("product.units", quantity))
 			productUnits[productCode] = calculatePayableUnitsApplying2X1Promotion(quantity)
+			ruleSpan.SetAttributes(attribute.Int("product.payable_units", productUnits[productCode]))
+			ruleSpan.End()
 			continue
 		}
 		productUnits[productCode] = quantity
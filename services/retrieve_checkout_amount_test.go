@@ -1,15 +1,19 @@
 package services
 
 import (
+	"lana/flagship-store/auth"
+	"lana/flagship-store/metrics"
 	"lana/flagship-store/models"
+	"lana/flagship-store/services/errors"
 	"lana/flagship-store/utils/mocks"
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
-func ProductRepositoryMockWithAllProducts() mocks.ProductRepositoryMock {
+func ProductRepositoryMockWithAllProducts() *mocks.ProductRepositoryMock {
 	pen := models.Product{
 		Code:  "PEN",
 		Name:  "Lana Pen",
@@ -30,10 +34,10 @@ func ProductRepositoryMockWithAllProducts() mocks.ProductRepositoryMock {
 	theProductRepositoryMock.On("SearchById", mug.Code).Return(mug, true)
 	theProductRepositoryMock.On("SearchById", tshirt.Code).Return(tshirt, true)
 
-	return theProductRepositoryMock
+	return &theProductRepositoryMock
 }
 
-func ProductWithPromotionRepositoryMockWithProducts() mocks.ProductWithPromotionRepositoryMock {
+func ProductWithPromotionRepositoryMockWithProducts() *mocks.ProductWithPromotionRepositoryMock {
 	pen := models.Product{
 		Code:  "PEN",
 		Name:  "Lana Pen",
@@ -44,10 +48,10 @@ func ProductWithPromotionRepositoryMockWithProducts() mocks.ProductWithPromotion
 	theProductWithPromotionRepositoryMock.On("SearchById", "MUG").Return(models.Product{}, false)
 	theProductWithPromotionRepositoryMock.On("SearchById", "TSHIRT").Return(models.Product{}, false)
 
-	return theProductWithPromotionRepositoryMock
+	return &theProductWithPromotionRepositoryMock
 }
 
-func ProductWithDiscountRepositoryMockWithProducts() mocks.ProductWithDiscountRepositoryMock {
+func ProductWithDiscountRepositoryMockWithProducts() *mocks.ProductWithDiscountRepositoryMock {
 	tshirt := models.Product{
 		Code:  "TSHIRT",
 		Name:  "Lana T-Shirt",
@@ -58,7 +62,7 @@ func ProductWithDiscountRepositoryMockWithProducts() mocks.ProductWithDiscountRe
 	theProductWithDiscountRepositoryMock.On("SearchById", "MUG").Return(models.Product{}, false)
 	theProductWithDiscountRepositoryMock.On("SearchById", "PEN").Return(models.Product{}, false)
 
-	return theProductWithDiscountRepositoryMock
+	return &theProductWithDiscountRepositoryMock
 }
 
 func TestRetrieveCheckoutAmountWhenCheckoutExists(t *testing.T) {
@@ -73,11 +77,12 @@ func TestRetrieveCheckoutAmountWhenCheckoutExists(t *testing.T) {
 	theProductWithPromotionRepositoryMock := ProductWithPromotionRepositoryMockWithProducts()
 	retrieveCheckoutAmountService := RetrieveCheckoutAmount{
 		&theCheckoutRepositoryMock,
-		&theProductRepositoryMock,
-		&theProductWithPromotionRepositoryMock,
-		&theProductWithDiscountRepositoryMock}
+		theProductRepositoryMock,
+		theProductWithPromotionRepositoryMock,
+		theProductWithDiscountRepositoryMock,
+		Pricing{}}
 
-	checkoutAmount, _ := retrieveCheckoutAmountService.Do(checkout.Id)
+	checkoutAmount, _ := retrieveCheckoutAmountService.Do(trustedContext(), checkout.Id)
 
 	assert.EqualValues(t, 750, checkoutAmount)
 }
@@ -94,13 +99,17 @@ func TestAmountWith2X1PromotionWhenCheckoutContainsTwoOfSameProductWithPromotion
 	theProductWithPromotionRepositoryMock := ProductWithPromotionRepositoryMockWithProducts()
 	retrieveCheckoutAmountService := RetrieveCheckoutAmount{
 		&theCheckoutRepositoryMock,
-		&theProductRepositoryMock,
-		&theProductWithPromotionRepositoryMock,
-		&theProductWithDiscountRepositoryMock}
+		theProductRepositoryMock,
+		theProductWithPromotionRepositoryMock,
+		theProductWithDiscountRepositoryMock,
+		Pricing{}}
 
-	checkoutAmount, _ := retrieveCheckoutAmountService.Do(checkout.Id)
+	penSavings := testutil.ToFloat64(metrics.PromotionSavings.WithLabelValues("PEN"))
+
+	checkoutAmount, _ := retrieveCheckoutAmountService.Do(trustedContext(), checkout.Id)
 
 	assert.EqualValues(t, 500, checkoutAmount)
+	assert.EqualValues(t, penSavings, testutil.ToFloat64(metrics.PromotionSavings.WithLabelValues("PEN")))
 }
 
 func TestAmountWithNo2X1PromotionWhenCheckoutDoesNotContainsTwoOfSameProductWithPromotion(t *testing.T) {
@@ -115,11 +124,12 @@ func TestAmountWithNo2X1PromotionWhenCheckoutDoesNotContainsTwoOfSameProductWith
 	theProductWithPromotionRepositoryMock := ProductWithPromotionRepositoryMockWithProducts()
 	retrieveCheckoutAmountService := RetrieveCheckoutAmount{
 		&theCheckoutRepositoryMock,
-		&theProductRepositoryMock,
-		&theProductWithPromotionRepositoryMock,
-		&theProductWithDiscountRepositoryMock}
+		theProductRepositoryMock,
+		theProductWithPromotionRepositoryMock,
+		theProductWithDiscountRepositoryMock,
+		Pricing{}}
 
-	checkoutAmount, _ := retrieveCheckoutAmountService.Do(checkout.Id)
+	checkoutAmount, _ := retrieveCheckoutAmountService.Do(trustedContext(), checkout.Id)
 
 	assert.EqualValues(t, 1500, checkoutAmount)
 }
@@ -136,11 +146,12 @@ func TestAmountWithDiscountWhenCheckoutContainsThreeOfSameProductWithDiscount(t
 	theProductWithPromotionRepositoryMock := ProductWithPromotionRepositoryMockWithProducts()
 	retrieveCheckoutAmountService := RetrieveCheckoutAmount{
 		&theCheckoutRepositoryMock,
-		&theProductRepositoryMock,
-		&theProductWithPromotionRepositoryMock,
-		&theProductWithDiscountRepositoryMock}
+		theProductRepositoryMock,
+		theProductWithPromotionRepositoryMock,
+		theProductWithDiscountRepositoryMock,
+		Pricing{}}
 
-	checkoutAmount, _ := retrieveCheckoutAmountService.Do(checkout.Id)
+	checkoutAmount, _ := retrieveCheckoutAmountService.Do(trustedContext(), checkout.Id)
 
 	assert.EqualValues(t, 4500, checkoutAmount)
 }
@@ -157,11 +168,12 @@ func TestAmountWithNoDiscountWhenCheckoutContainsLessThanThreeOfSameProductWithD
 	theProductWithPromotionRepositoryMock := ProductWithPromotionRepositoryMockWithProducts()
 	retrieveCheckoutAmountService := RetrieveCheckoutAmount{
 		&theCheckoutRepositoryMock,
-		&theProductRepositoryMock,
-		&theProductWithPromotionRepositoryMock,
-		&theProductWithDiscountRepositoryMock}
+		theProductRepositoryMock,
+		theProductWithPromotionRepositoryMock,
+		theProductWithDiscountRepositoryMock,
+		Pricing{}}
 
-	checkoutAmount, _ := retrieveCheckoutAmountService.Do(checkout.Id)
+	checkoutAmount, _ := retrieveCheckoutAmountService.Do(trustedContext(), checkout.Id)
 
 	assert.EqualValues(t, 4000, checkoutAmount)
 }
@@ -178,11 +190,56 @@ func TestAmountWithNoDiscountWhenCheckoutDoesNotContainsThreeOfSameProductWithDi
 	theProductWithPromotionRepositoryMock := ProductWithPromotionRepositoryMockWithProducts()
 	retrieveCheckoutAmountService := RetrieveCheckoutAmount{
 		&theCheckoutRepositoryMock,
-		&theProductRepositoryMock,
-		&theProductWithPromotionRepositoryMock,
-		&theProductWithDiscountRepositoryMock}
+		theProductRepositoryMock,
+		theProductWithPromotionRepositoryMock,
+		theProductWithDiscountRepositoryMock,
+		Pricing{}}
 
-	checkoutAmount, _ := retrieveCheckoutAmountService.Do(checkout.Id)
+	checkoutAmount, _ := retrieveCheckoutAmountService.Do(trustedContext(), checkout.Id)
 
 	assert.EqualValues(t, 2250, checkoutAmount)
 }
+
+func TestRetrieveCheckoutAmountReturnForbiddenErrorWhenCheckoutBelongsToAnotherOwner(t *testing.T) {
+	checkout := models.Checkout{
+		Id:       uuid.NewString(),
+		Products: []string{"MUG"},
+		Owner:    "jwt:another-shopper",
+	}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	retrieveCheckoutAmountService := RetrieveCheckoutAmount{
+		&theCheckoutRepositoryMock,
+		ProductRepositoryMockWithAllProducts(),
+		ProductWithPromotionRepositoryMockWithProducts(),
+		ProductWithDiscountRepositoryMockWithProducts(),
+		Pricing{}}
+
+	_, err := retrieveCheckoutAmountService.Do(principalContext(auth.RoleShopper, "a-shopper"), checkout.Id)
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrForbidden))
+}
+
+func TestAmountWithThePriceOfTheCustomerBeforeApplyingPromotions(t *testing.T) {
+	checkout := models.Checkout{
+		Id:         uuid.NewString(),
+		Products:   []string{"PEN", "PEN", "MUG"},
+		CustomerId: "a-customer",
+	}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("SearchById", "a-customer").Return(models.Customer{Id: "a-customer"}, true)
+	thePriceListRepositoryMock := mocks.PriceListRepositoryMock{}
+	thePriceListRepositoryMock.On("SearchFor", "a-customer", "").Return([]models.PriceList{{Id: "a-price-list", CustomerId: "a-customer", Prices: map[string]int{"PEN": 400}}})
+	retrieveCheckoutAmountService := RetrieveCheckoutAmount{
+		&theCheckoutRepositoryMock,
+		ProductRepositoryMockWithAllProducts(),
+		ProductWithPromotionRepositoryMockWithProducts(),
+		ProductWithDiscountRepositoryMockWithProducts(),
+		Pricing{&theCustomerRepositoryMock, &thePriceListRepositoryMock}}
+
+	checkoutAmount, _ := retrieveCheckoutAmountService.Do(trustedContext(), checkout.Id)
+
+	assert.EqualValues(t, 400+750, checkoutAmount)
+}
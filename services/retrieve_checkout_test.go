@@ -0,0 +1,96 @@
+package services
+
+import (
+	"lana/flagship-store/auth"
+	"lana/flagship-store/models"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRetrieveCheckoutWhenCheckoutExists(t *testing.T) {
+	checkout := models.Checkout{
+		Id:       uuid.NewString(),
+		Products: []string{"TSHIRT", "PEN", "TSHIRT", "PEN", "TSHIRT"},
+	}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	retrieveCheckoutService := RetrieveCheckout{
+		&theCheckoutRepositoryMock,
+		ProductRepositoryMockWithAllProducts(),
+		ProductWithPromotionRepositoryMockWithProducts(),
+		ProductWithDiscountRepositoryMockWithProducts(),
+		Pricing{}}
+
+	checkoutSummary, err := retrieveCheckoutService.Do(trustedContext(), checkout.Id)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, checkout, checkoutSummary.Checkout)
+	assert.EqualValues(t, 2, len(checkoutSummary.Lines))
+	assert.EqualValues(t, "PEN", checkoutSummary.Lines[0].Product.Code)
+	assert.EqualValues(t, 2, checkoutSummary.Lines[0].Quantity)
+	assert.EqualValues(t, 1000, checkoutSummary.Lines[0].Subtotal)
+	assert.EqualValues(t, 500, checkoutSummary.Lines[0].Amount)
+	assert.EqualValues(t, "TSHIRT", checkoutSummary.Lines[1].Product.Code)
+	assert.EqualValues(t, 3, checkoutSummary.Lines[1].Quantity)
+	assert.EqualValues(t, 6000, checkoutSummary.Lines[1].Subtotal)
+	assert.EqualValues(t, 4500, checkoutSummary.Lines[1].Amount)
+	assert.EqualValues(t, 7000, checkoutSummary.Subtotal)
+	assert.EqualValues(t, 5000, checkoutSummary.Amount)
+}
+
+func TestRetrieveCheckoutReturnCheckoutNotFoundErrorWhenCheckoutDoesnotExists(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", "a_fake_id").Return(models.Checkout{}, false)
+	retrieveCheckoutService := RetrieveCheckout{
+		&theCheckoutRepositoryMock,
+		ProductRepositoryMockWithAllProducts(),
+		ProductWithPromotionRepositoryMockWithProducts(),
+		ProductWithDiscountRepositoryMockWithProducts(),
+		Pricing{}}
+
+	_, err := retrieveCheckoutService.Do(trustedContext(), "a_fake_id")
+
+	isCheckoutNotFoundError := errors.Is(err, errors.ErrCheckoutNotFound)
+	assert.EqualValues(t, true, isCheckoutNotFoundError)
+}
+
+func TestSummarizeCheckoutsWithoutReadingThemAgainAndReadingEachProductOnce(t *testing.T) {
+	mugCheckout := models.Checkout{Id: uuid.NewString(), Products: []string{"MUG"}}
+	penCheckout := models.Checkout{Id: uuid.NewString(), Products: []string{"PEN", "PEN", "MUG"}}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theProductRepositoryMock := ProductRepositoryMockWithAllProducts()
+	retrieveCheckoutService := RetrieveCheckout{
+		&theCheckoutRepositoryMock,
+		theProductRepositoryMock,
+		ProductWithPromotionRepositoryMockWithProducts(),
+		ProductWithDiscountRepositoryMockWithProducts(),
+		Pricing{}}
+
+	summaries, err := retrieveCheckoutService.Summarize(trustedContext(), []models.Checkout{mugCheckout, penCheckout})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, len(summaries))
+	assert.EqualValues(t, 750, summaries[0].Amount)
+	assert.EqualValues(t, 1250, summaries[1].Amount)
+	theProductRepositoryMock.AssertNumberOfCalls(t, "SearchById", 3)
+	theCheckoutRepositoryMock.AssertNotCalled(t, "SearchById", mock.Anything)
+}
+
+func TestSummarizeCheckoutsReturnForbiddenErrorForCheckoutsOfAnotherOwner(t *testing.T) {
+	checkout := models.Checkout{Id: uuid.NewString(), Owner: "jwt:another-shopper"}
+	retrieveCheckoutService := RetrieveCheckout{
+		&mocks.CheckoutRepositoryMock{},
+		ProductRepositoryMockWithAllProducts(),
+		ProductWithPromotionRepositoryMockWithProducts(),
+		ProductWithDiscountRepositoryMockWithProducts(),
+		Pricing{}}
+
+	_, err := retrieveCheckoutService.Summarize(principalContext(auth.RoleShopper, "a-shopper"), []models.Checkout{checkout})
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrForbidden))
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/tracing"
+)
+
+type RetrieveCheckoutsAmount struct {
+	CheckoutRepository             persistence.CheckoutRepository
+	ProductRepository              persistence.ProductRepository
+	ProductWithPromotionRepository persistence.ProductRepository
+	ProductWithDiscountRepository  persistence.ProductRepository
+	Pricing                        Pricing
+}
+
+type CheckoutAmount struct {
+	CheckoutId string
+	Amount     int
+	Err        error
+}
+
+func NewRetrieveCheckoutsAmount(checkoutRepository persistence.CheckoutRepository, productRepository persistence.ProductRepository, productWithPromotionRepository persistence.ProductRepository, productWithDiscountRepository persistence.ProductRepository) RetrieveCheckoutsAmount {
+	return RetrieveCheckoutsAmount{CheckoutRepository: checkoutRepository, ProductRepository: productRepository, ProductWithPromotionRepository: productWithPromotionRepository, ProductWithDiscountRepository: productWithDiscountRepository}
+}
+
+func (service *RetrieveCheckoutsAmount) Do(ctx context.Context, checkoutsCommand commands.Checkouts) ([]CheckoutAmount, error) {
+	ctx, span := tracing.Start(ctx, "services.RetrieveCheckoutsAmount")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.RetrieveCheckoutsAmount); err != nil {
+		return nil, err
+	}
+
+	if err := checkoutsCommand.Validate(); err != nil {
+		return nil, err
+	}
+
+	productRepository := persistence.NewCachedProductRepository(service.ProductRepository)
+	productWithPromotionRepository := persistence.NewCachedProductRepository(service.ProductWithPromotionRepository)
+	productWithDiscountRepository := persistence.NewCachedProductRepository(service.ProductWithDiscountRepository)
+
+	checkoutAmounts := make([]CheckoutAmount, 0, len(checkoutsCommand.Ids))
+	for _, checkoutId := range checkoutsCommand.Ids {
+		if err := checkContext(ctx); err != nil {
+			return nil, err
+		}
+
+		checkout, existCheckout := service.CheckoutRepository.SearchById(ctx, checkoutId)
+		if !existCheckout {
+			checkoutAmounts = append(checkoutAmounts, CheckoutAmount{CheckoutId: checkoutId, Err: errors.NewCheckoutNotFoundError(checkoutId)})
+			continue
+		}
+		if err := checkOwner(ctx, checkout); err != nil {
+			checkoutAmounts = append(checkoutAmounts, CheckoutAmount{CheckoutId: checkoutId, Err: err})
+			continue
+		}
+
+		amount := calculateCheckoutAmount(ctx, checkout.Products, service.Pricing.productRepositoryOf(ctx, checkout, productRepository), productWithPromotionRepository, productWithDiscountRepository)
+		amount -= redeemablePoints(checkout.RedeemedPoints, amount) * models.LoyaltyPointValue
+		checkoutAmounts = append(checkoutAmounts, CheckoutAmount{CheckoutId: checkoutId, Amount: amount})
+	}
+
+	return checkoutAmounts, nil
+}
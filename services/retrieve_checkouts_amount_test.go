@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/services/commands"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRetrieveCheckoutsAmountWhenCheckoutsExist(t *testing.T) {
+	mugCheckout := models.Checkout{
+		Id:       uuid.NewString(),
+		Products: []string{"MUG"},
+	}
+	penCheckout := models.Checkout{
+		Id:       uuid.NewString(),
+		Products: []string{"PEN", "PEN", "MUG"},
+	}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", mugCheckout.Id).Return(mugCheckout, true)
+	theCheckoutRepositoryMock.On("SearchById", penCheckout.Id).Return(penCheckout, true)
+	retrieveCheckoutsAmountService := RetrieveCheckoutsAmount{
+		&theCheckoutRepositoryMock,
+		ProductRepositoryMockWithAllProducts(),
+		ProductWithPromotionRepositoryMockWithProducts(),
+		ProductWithDiscountRepositoryMockWithProducts(),
+		Pricing{}}
+	checkoutsCommand := commands.Checkouts{Ids: []string{mugCheckout.Id, penCheckout.Id}}
+
+	checkoutAmounts, _ := retrieveCheckoutsAmountService.Do(trustedContext(), checkoutsCommand)
+
+	assert.EqualValues(t, 2, len(checkoutAmounts))
+	assert.EqualValues(t, mugCheckout.Id, checkoutAmounts[0].CheckoutId)
+	assert.EqualValues(t, 750, checkoutAmounts[0].Amount)
+	assert.Nil(t, checkoutAmounts[0].Err)
+	assert.EqualValues(t, penCheckout.Id, checkoutAmounts[1].CheckoutId)
+	assert.EqualValues(t, 1250, checkoutAmounts[1].Amount)
+	assert.Nil(t, checkoutAmounts[1].Err)
+}
+
+func TestRetrieveCheckoutsAmountSearchEachProductOnlyOnce(t *testing.T) {
+	firstCheckout := models.Checkout{
+		Id:       uuid.NewString(),
+		Products: []string{"MUG"},
+	}
+	secondCheckout := models.Checkout{
+		Id:       uuid.NewString(),
+		Products: []string{"MUG", "TSHIRT"},
+	}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", firstCheckout.Id).Return(firstCheckout, true)
+	theCheckoutRepositoryMock.On("SearchById", secondCheckout.Id).Return(secondCheckout, true)
+	theProductRepositoryMock := ProductRepositoryMockWithAllProducts()
+	retrieveCheckoutsAmountService := RetrieveCheckoutsAmount{
+		&theCheckoutRepositoryMock,
+		theProductRepositoryMock,
+		ProductWithPromotionRepositoryMockWithProducts(),
+		ProductWithDiscountRepositoryMockWithProducts(),
+		Pricing{}}
+	checkoutsCommand := commands.Checkouts{Ids: []string{firstCheckout.Id, secondCheckout.Id}}
+
+	retrieveCheckoutsAmountService.Do(trustedContext(), checkoutsCommand)
+
+	theProductRepositoryMock.AssertNumberOfCalls(t, "SearchById", 3)
+}
+
+func TestRetrieveCheckoutsAmountReturnCheckoutNotFoundErrorForMissingCheckouts(t *testing.T) {
+	checkout := models.Checkout{
+		Id:       uuid.NewString(),
+		Products: []string{"MUG"},
+	}
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	theCheckoutRepositoryMock.On("SearchById", checkout.Id).Return(checkout, true)
+	theCheckoutRepositoryMock.On("SearchById", "a_fake_id").Return(models.Checkout{}, false)
+	retrieveCheckoutsAmountService := RetrieveCheckoutsAmount{
+		&theCheckoutRepositoryMock,
+		ProductRepositoryMockWithAllProducts(),
+		ProductWithPromotionRepositoryMockWithProducts(),
+		ProductWithDiscountRepositoryMockWithProducts(),
+		Pricing{}}
+	checkoutsCommand := commands.Checkouts{Ids: []string{"a_fake_id", checkout.Id}}
+
+	checkoutAmounts, _ := retrieveCheckoutsAmountService.Do(trustedContext(), checkoutsCommand)
+
+	isCheckoutNotFoundError := errors.Is(checkoutAmounts[0].Err, errors.ErrCheckoutNotFound)
+	assert.EqualValues(t, true, isCheckoutNotFoundError)
+	assert.EqualValues(t, "a_fake_id", checkoutAmounts[0].CheckoutId)
+	assert.EqualValues(t, 750, checkoutAmounts[1].Amount)
+}
+
+func TestRetrieveCheckoutsAmountReturnValidationErrorWithoutIds(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	retrieveCheckoutsAmountService := RetrieveCheckoutsAmount{
+		&theCheckoutRepositoryMock,
+		ProductRepositoryMockWithAllProducts(),
+		ProductWithPromotionRepositoryMockWithProducts(),
+		ProductWithDiscountRepositoryMockWithProducts(),
+		Pricing{}}
+
+	_, err := retrieveCheckoutsAmountService.Do(trustedContext(), commands.Checkouts{})
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrValidation))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "SearchById", mock.Anything)
+}
+
+func TestRetrieveCheckoutsAmountReturnDeadlineExceededErrorWhenDeadlineIsExceeded(t *testing.T) {
+	theCheckoutRepositoryMock := mocks.CheckoutRepositoryMock{}
+	retrieveCheckoutsAmount := RetrieveCheckoutsAmount{&theCheckoutRepositoryMock, &mocks.ProductRepositoryMock{}, &mocks.ProductWithPromotionRepositoryMock{}, &mocks.ProductWithDiscountRepositoryMock{}, Pricing{}}
+	ctx, cancel := context.WithDeadline(trustedContext(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_, err := retrieveCheckoutsAmount.Do(ctx, commands.Checkouts{Ids: []string{"an_id"}})
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrDeadlineExceeded))
+	theCheckoutRepositoryMock.AssertNotCalled(t, "SearchById", mock.Anything)
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/tracing"
+)
+
+type RetrieveCustomer struct {
+	CustomerRepository persistence.CustomerRepository
+}
+
+func NewRetrieveCustomer(customerRepository persistence.CustomerRepository) RetrieveCustomer {
+	return RetrieveCustomer{customerRepository}
+}
+
+func (service *RetrieveCustomer) Do(ctx context.Context, customerId string) (models.Customer, error) {
+	ctx, span := tracing.Start(ctx, "services.RetrieveCustomer")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.RetrieveCustomer); err != nil {
+		return models.Customer{}, err
+	}
+
+	customer, existCustomer := service.CustomerRepository.SearchById(ctx, customerId)
+	if !existCustomer {
+		return models.Customer{}, errors.NewCustomerNotFoundError(customerId)
+	}
+	if err := checkCustomerOwner(ctx, customer); err != nil {
+		return models.Customer{}, err
+	}
+
+	return customer, nil
+}
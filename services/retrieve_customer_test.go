@@ -0,0 +1,45 @@
+package services
+
+import (
+	"lana/flagship-store/auth"
+	"lana/flagship-store/models"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetrieveCustomerOfTheCaller(t *testing.T) {
+	customer := models.Customer{Id: "a-customer", Owner: "jwt:a-shopper"}
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("SearchById", "a-customer").Return(customer, true)
+	retrieveCustomer := RetrieveCustomer{&theCustomerRepositoryMock}
+
+	customerRetrieved, err := retrieveCustomer.Do(principalContext(auth.RoleShopper, "a-shopper"), "a-customer")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, customer, customerRetrieved)
+}
+
+func TestReturnCustomerNotFoundErrorWhenCustomerDoesNotExist(t *testing.T) {
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("SearchById", "a-customer").Return(models.Customer{}, false)
+	retrieveCustomer := RetrieveCustomer{&theCustomerRepositoryMock}
+
+	_, err := retrieveCustomer.Do(principalContext(auth.RoleShopper, "a-shopper"), "a-customer")
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrCustomerNotFound))
+}
+
+func TestReturnForbiddenErrorWhenCustomerBelongsToAnotherOwner(t *testing.T) {
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("SearchById", "a-customer").Return(models.Customer{Id: "a-customer", Owner: "jwt:another-shopper"}, true)
+	retrieveCustomer := RetrieveCustomer{&theCustomerRepositoryMock}
+
+	_, err := retrieveCustomer.Do(principalContext(auth.RoleShopper, "a-shopper"), "a-customer")
+	_, merchandiserErr := retrieveCustomer.Do(principalContext(auth.RoleMerchandiser, "crm"), "a-customer")
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrForbidden))
+	assert.Nil(t, merchandiserErr)
+}
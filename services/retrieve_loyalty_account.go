@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/tracing"
+)
+
+type RetrieveLoyaltyAccount struct {
+	CustomerRepository persistence.CustomerRepository
+	LoyaltyRepository  persistence.LoyaltyRepository
+}
+
+func NewRetrieveLoyaltyAccount(customerRepository persistence.CustomerRepository, loyaltyRepository persistence.LoyaltyRepository) RetrieveLoyaltyAccount {
+	return RetrieveLoyaltyAccount{customerRepository, loyaltyRepository}
+}
+
+func (service *RetrieveLoyaltyAccount) Do(ctx context.Context, customerId string) (models.LoyaltyAccount, error) {
+	ctx, span := tracing.Start(ctx, "services.RetrieveLoyaltyAccount")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.RetrieveLoyaltyAccount); err != nil {
+		return models.LoyaltyAccount{}, err
+	}
+
+	customer, existCustomer := service.CustomerRepository.SearchById(ctx, customerId)
+	if !existCustomer {
+		return models.LoyaltyAccount{}, errors.NewCustomerNotFoundError(customerId)
+	}
+	if err := checkCustomerOwner(ctx, customer); err != nil {
+		return models.LoyaltyAccount{}, err
+	}
+
+	return models.LoyaltyAccount{
+		CustomerId:   customer.Id,
+		Balance:      service.LoyaltyRepository.Balance(ctx, customer.Id),
+		Transactions: service.LoyaltyRepository.SearchByCustomer(ctx, customer.Id),
+	}, nil
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"lana/flagship-store/auth"
+	"lana/flagship-store/models"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetrieveLoyaltyAccountOfTheCustomerOfTheCaller(t *testing.T) {
+	transactions := []models.LoyaltyTransaction{
+		{Id: "an-earn", CustomerId: "a-customer", Kind: models.LoyaltyTransactionEarn, Points: 50},
+		{Id: "a-redeem", CustomerId: "a-customer", Kind: models.LoyaltyTransactionRedeem, Points: -20},
+	}
+	theLoyaltyRepositoryMock := mocks.LoyaltyRepositoryMock{}
+	theLoyaltyRepositoryMock.On("Balance", "a-customer").Return(30)
+	theLoyaltyRepositoryMock.On("SearchByCustomer", "a-customer").Return(transactions)
+	retrieveLoyaltyAccount := RetrieveLoyaltyAccount{customerRepositoryMockWith(models.Customer{Id: "a-customer", Owner: "jwt:a-shopper"}), &theLoyaltyRepositoryMock}
+
+	account, err := retrieveLoyaltyAccount.Do(principalContext(auth.RoleShopper, "a-shopper"), "a-customer")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, models.LoyaltyAccount{CustomerId: "a-customer", Balance: 30, Transactions: transactions}, account)
+}
+
+func TestReturnCustomerNotFoundErrorWhenRetrievingTheLoyaltyAccountOfAnUnknownCustomer(t *testing.T) {
+	theCustomerRepositoryMock := mocks.CustomerRepositoryMock{}
+	theCustomerRepositoryMock.On("SearchById", "a-customer").Return(models.Customer{}, false)
+	retrieveLoyaltyAccount := RetrieveLoyaltyAccount{&theCustomerRepositoryMock, &mocks.LoyaltyRepositoryMock{}}
+
+	_, err := retrieveLoyaltyAccount.Do(principalContext(auth.RoleShopper, "a-shopper"), "a-customer")
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrCustomerNotFound))
+}
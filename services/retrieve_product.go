@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
+	"lana/flagship-store/policy"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/tracing"
+)
+
+type RetrieveProduct struct {
+	ProductRepository persistence.ProductRepository
+}
+
+func NewRetrieveProduct(productRepository persistence.ProductRepository) RetrieveProduct {
+	return RetrieveProduct{productRepository}
+}
+
+func (service *RetrieveProduct) Do(ctx context.Context, productCode string) (models.Product, error) {
+	ctx, span := tracing.Start(ctx, "services.RetrieveProduct")
+	defer span.End()
+
+	if err := policy.Authorize(ctx, policy.RetrieveProduct); err != nil {
+		return models.Product{}, err
+	}
+
+	if err := checkContext(ctx); err != nil {
+		return models.Product{}, err
+	}
+
+	product, existProduct := service.ProductRepository.SearchById(ctx, productCode)
+	if !existProduct {
+		return models.Product{}, errors.NewProductNotFoundError(productCode)
+	}
+
+	return product, nil
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"lana/flagship-store/models"
+	"lana/flagship-store/services/errors"
+	"lana/flagship-store/utils/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetrieveProduct(t *testing.T) {
+	pen := models.Product{Code: "PEN", Name: "Lana Pen", Price: 500}
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "PEN").Return(pen, true)
+	retrieveProduct := RetrieveProduct{&theProductRepositoryMock}
+
+	product, err := retrieveProduct.Do(trustedContext(), "PEN")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, pen, product)
+}
+
+func TestRetrieveProductReturnProductNotFoundErrorWhenProductDoesnotExists(t *testing.T) {
+	theProductRepositoryMock := mocks.ProductRepositoryMock{}
+	theProductRepositoryMock.On("SearchById", "FAKE").Return(models.Product{}, false)
+	retrieveProduct := RetrieveProduct{&theProductRepositoryMock}
+
+	_, err := retrieveProduct.Do(trustedContext(), "FAKE")
+
+	assert.EqualValues(t, true, errors.Is(err, errors.ErrProductNotFound))
+}
@@ -0,0 +1,19 @@
+package main
+
+import "net/http"
+
+// statusRecorder records the status written to the response, which is 200
+// when the handler writes a body without calling WriteHeader.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func newStatusRecorder(response http.ResponseWriter) *statusRecorder {
+	return &statusRecorder{ResponseWriter: response, status: http.StatusOK}
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	recorder.status = status
+	recorder.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+const serviceName = "flagship-store"
+
+const (
+	ExporterNone   = ""
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Config selects where the spans are exported: nowhere, to stdout or to an
+// OTLP collector over gRPC.
+type Config struct {
+	Exporter       string
+	OTLPEndpoint   string
+	OTLPInsecure   bool
+	ServiceVersion string
+}
+
+// NewProvider returns a tracer provider exporting the spans in batches as
+// configured. Without exporter the spans are still sampled, so the trace
+// ids are propagated and logged. A provider with exporter must be shut down
+// to flush the last batch.
+func NewProvider(ctx context.Context, config Config) (*sdktrace.TracerProvider, error) {
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceNameKey.String(serviceName),
+			semconv.ServiceVersionKey.String(config.ServiceVersion),
+		)),
+	}
+
+	switch config.Exporter {
+	case ExporterNone:
+	case ExporterStdout:
+		exporter, err := stdouttrace.New()
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	case ExporterOTLP:
+		otlpOptions := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(config.OTLPEndpoint)}
+		if config.OTLPInsecure {
+			otlpOptions = append(otlpOptions, otlptracegrpc.WithInsecure())
+		}
+		exporter, err := otlptracegrpc.New(ctx, otlpOptions...)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	default:
+		return nil, errors.New("unknown trace exporter " + config.Exporter + ", must be stdout or otlp")
+	}
+	return sdktrace.NewTracerProvider(options...), nil
+}
@@ -0,0 +1,40 @@
+// Package tracing traces the requests of the store with OpenTelemetry. The
+// spans are started with Start on the global tracer provider, installed by
+// main from NewProvider.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "lana/flagship-store"
+
+// Tracer returns the tracer of the store from the global tracer provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start starts a span child of the span in ctx, if any. The caller must end
+// the returned span.
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// RecordError records the error as an event of the span in ctx.
+func RecordError(ctx context.Context, err error) {
+	trace.SpanFromContext(ctx).RecordError(err)
+}
+
+// TraceId returns the id of the trace in ctx, or an empty string when the
+// context carries no valid span.
+func TraceId(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
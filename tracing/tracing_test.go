@@ -0,0 +1,33 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestNewProviderRejectsUnknownExporters(t *testing.T) {
+	_, err := NewProvider(context.Background(), Config{Exporter: "zipkin"})
+
+	assert.EqualError(t, err, "unknown trace exporter zipkin, must be stdout or otlp")
+}
+
+func TestNewProviderWithoutExporterStillStartsSampledSpans(t *testing.T) {
+	provider, err := NewProvider(context.Background(), Config{})
+	_, span := provider.Tracer(instrumentationName).Start(context.Background(), "test")
+	defer span.End()
+
+	assert.NoError(t, err)
+	assert.True(t, span.SpanContext().IsSampled())
+}
+
+func TestTraceIdOfTheSpanInContext(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer(instrumentationName).Start(context.Background(), "test")
+	defer span.End()
+
+	assert.Equal(t, span.SpanContext().TraceID().String(), TraceId(ctx))
+	assert.Equal(t, "", TraceId(context.Background()))
+}
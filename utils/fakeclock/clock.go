@@ -0,0 +1,22 @@
+// Package fakeclock provides a clock for the tests of code depending on
+// the time, which only moves when the test advances it.
+package fakeclock
+
+import "time"
+
+type Clock struct {
+	time time.Time
+}
+
+// New returns a clock stopped at a fixed time.
+func New() *Clock {
+	return &Clock{time: time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC)}
+}
+
+func (clock *Clock) Now() time.Time {
+	return clock.time
+}
+
+func (clock *Clock) Advance(duration time.Duration) {
+	clock.time = clock.time.Add(duration)
+}
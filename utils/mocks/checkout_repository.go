@@ -1,7 +1,9 @@
 package mocks
 
 import (
+	"context"
 	"lana/flagship-store/models"
+	"lana/flagship-store/persistence"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -10,22 +12,37 @@ type CheckoutRepositoryMock struct {
 	mock.Mock
 }
 
-func (repository *CheckoutRepositoryMock) SearchById(id string) (models.Checkout, bool) {
+func (repository *CheckoutRepositoryMock) SearchById(ctx context.Context, id string) (models.Checkout, bool) {
 	args := repository.Called(id)
 	return args.Get(0).(models.Checkout), args.Bool(1)
 }
 
-func (repository *CheckoutRepositoryMock) Persist(checkout models.Checkout) {
+func (repository *CheckoutRepositoryMock) Search(ctx context.Context, criteria persistence.CheckoutCriteria) []models.Checkout {
+	args := repository.Called(criteria)
+	return args.Get(0).([]models.Checkout)
+}
+
+func (repository *CheckoutRepositoryMock) Persist(ctx context.Context, checkout models.Checkout) {
 	repository.Called(checkout)
 	return
 }
 
-func (repository *CheckoutRepositoryMock) Delete(checkout models.Checkout) {
+func (repository *CheckoutRepositoryMock) PersistIfStatus(ctx context.Context, checkout models.Checkout, status string) bool {
+	args := repository.Called(checkout, status)
+	return args.Bool(0)
+}
+
+func (repository *CheckoutRepositoryMock) Delete(ctx context.Context, checkout models.Checkout) {
 	repository.Called(checkout)
 	return
 }
 
-func (repository *CheckoutRepositoryMock) Count() int {
+func (repository *CheckoutRepositoryMock) DeleteIfStatus(ctx context.Context, checkout models.Checkout, status string) bool {
+	args := repository.Called(checkout, status)
+	return args.Bool(0)
+}
+
+func (repository *CheckoutRepositoryMock) Count(ctx context.Context) int {
 	args := repository.Called()
 	return args.Int(0)
 }
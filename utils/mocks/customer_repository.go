@@ -0,0 +1,32 @@
+package mocks
+
+import (
+	"context"
+	"lana/flagship-store/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type CustomerRepositoryMock struct {
+	mock.Mock
+}
+
+func (repository *CustomerRepositoryMock) SearchById(ctx context.Context, id string) (models.Customer, bool) {
+	args := repository.Called(id)
+	return args.Get(0).(models.Customer), args.Bool(1)
+}
+
+func (repository *CustomerRepositoryMock) SearchByEmail(ctx context.Context, email string) (models.Customer, bool) {
+	args := repository.Called(email)
+	return args.Get(0).(models.Customer), args.Bool(1)
+}
+
+func (repository *CustomerRepositoryMock) Persist(ctx context.Context, customer models.Customer) {
+	repository.Called(customer)
+	return
+}
+
+func (repository *CustomerRepositoryMock) PersistIfEmailAbsent(ctx context.Context, customer models.Customer) bool {
+	args := repository.Called(customer)
+	return args.Bool(0)
+}
@@ -0,0 +1,4 @@
+// Package mocks provides testify mocks of the repositories. The context
+// passed to the repositories is not part of the arguments of the calls, so
+// expectations are set on the remaining arguments only.
+package mocks
@@ -0,0 +1,32 @@
+package mocks
+
+import (
+	"context"
+	"lana/flagship-store/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type LoyaltyRepositoryMock struct {
+	mock.Mock
+}
+
+func (repository *LoyaltyRepositoryMock) Balance(ctx context.Context, customerId string) int {
+	args := repository.Called(customerId)
+	return args.Int(0)
+}
+
+func (repository *LoyaltyRepositoryMock) Record(ctx context.Context, transaction models.LoyaltyTransaction) {
+	repository.Called(transaction)
+	return
+}
+
+func (repository *LoyaltyRepositoryMock) Redeem(ctx context.Context, transaction models.LoyaltyTransaction) error {
+	args := repository.Called(transaction)
+	return args.Error(0)
+}
+
+func (repository *LoyaltyRepositoryMock) SearchByCustomer(ctx context.Context, customerId string) []models.LoyaltyTransaction {
+	args := repository.Called(customerId)
+	return args.Get(0).([]models.LoyaltyTransaction)
+}
@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"context"
+	"lana/flagship-store/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type PriceListRepositoryMock struct {
+	mock.Mock
+}
+
+func (repository *PriceListRepositoryMock) SearchFor(ctx context.Context, customerId string, group string) []models.PriceList {
+	args := repository.Called(customerId, group)
+	return args.Get(0).([]models.PriceList)
+}
+
+func (repository *PriceListRepositoryMock) SearchAll(ctx context.Context) []models.PriceList {
+	args := repository.Called()
+	return args.Get(0).([]models.PriceList)
+}
+
+func (repository *PriceListRepositoryMock) Persist(ctx context.Context, priceList models.PriceList) {
+	repository.Called(priceList)
+	return
+}
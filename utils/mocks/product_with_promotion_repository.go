@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"lana/flagship-store/models"
 
 	"github.com/stretchr/testify/mock"
@@ -10,7 +11,12 @@ type ProductWithPromotionRepositoryMock struct {
 	mock.Mock
 }
 
-func (repository *ProductWithPromotionRepositoryMock) SearchById(id string) (models.Product, bool) {
+func (repository *ProductWithPromotionRepositoryMock) SearchById(ctx context.Context, id string) (models.Product, bool) {
 	args := repository.Called(id)
 	return args.Get(0).(models.Product), args.Bool(1)
 }
+
+func (repository *ProductWithPromotionRepositoryMock) SearchAll(ctx context.Context) []models.Product {
+	args := repository.Called()
+	return args.Get(0).([]models.Product)
+}
@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"context"
+	"lana/flagship-store/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type SavedListRepositoryMock struct {
+	mock.Mock
+}
+
+func (repository *SavedListRepositoryMock) SearchById(ctx context.Context, id string) (models.SavedList, bool) {
+	args := repository.Called(id)
+	return args.Get(0).(models.SavedList), args.Bool(1)
+}
+
+func (repository *SavedListRepositoryMock) SearchByOwner(ctx context.Context, owner string) []models.SavedList {
+	args := repository.Called(owner)
+	return args.Get(0).([]models.SavedList)
+}
+
+func (repository *SavedListRepositoryMock) Persist(ctx context.Context, savedList models.SavedList) {
+	repository.Called(savedList)
+	return
+}
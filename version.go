@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Deprecation announces that an API version will be removed. Responses of
+// a deprecated version carry the Deprecation header, plus the Sunset header
+// (RFC 8594) and a link to the successor version when they are known.
+type Deprecation struct {
+	Date      time.Time
+	Sunset    time.Time
+	Successor string
+}
+
+func (app *App) versionHeaders(version string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			response.Header().Set("API-Version", version)
+			if deprecation, deprecated := app.Deprecations[version]; deprecated {
+				response.Header().Set("Deprecation", deprecation.Date.UTC().Format(http.TimeFormat))
+				if !deprecation.Sunset.IsZero() {
+					response.Header().Set("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+				}
+				if deprecation.Successor != "" {
+					response.Header().Add("Link", "<"+deprecation.Successor+">; rel=\"successor-version\"")
+				}
+			}
+			next.ServeHTTP(response, request)
+		})
+	}
+}